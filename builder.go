@@ -0,0 +1,558 @@
+package parseme
+
+import (
+	"strings"
+)
+
+type treeBuilder struct {
+	tokenizer *tokenizer
+	mode      ParserMode
+	document  *Element
+	open      stack[*Element]
+	scopes    []map[string]string
+	errors    *stack[ErrorData]
+	hasRoot   bool
+
+	// In lossless mode every byte of the input is attached to a node.
+	// Markup that produces no node is appended to the last raw piece.
+	lossless bool
+	consumed int
+	lastRaw  *string
+
+	dialect AttributeDialect
+}
+
+func newTreeBuilder(input *[]byte, mode ParserMode, lossless bool, dialect AttributeDialect, duplicates DuplicatePolicy, errors *stack[ErrorData]) *treeBuilder {
+	builder := &treeBuilder{
+		tokenizer: newTokenizer(input, mode, errors),
+		mode:      mode,
+		document:  newDocument(),
+		errors:    errors,
+		lossless:  lossless,
+		dialect:   dialect,
+	}
+
+	switch {
+	case mode == XmlMode:
+		builder.dialect = XmlDialect
+	case dialect == nil:
+		builder.dialect = HtmlDialect
+	}
+
+	builder.tokenizer.duplicates = duplicates
+	builder.document.mode = mode
+	builder.document.dialect = builder.dialect
+
+	if lossless {
+		builder.document.source = &elementSource{}
+		builder.lastRaw = &builder.document.source.head
+	}
+
+	builder.open.Push(builder.document)
+	builder.scopes = []map[string]string{{"xml": xmlNamespace, "xmlns": xmlnsNamespace}}
+	return builder
+}
+
+func (b *treeBuilder) build() *Element {
+	for {
+		tok, ok := b.tokenizer.next()
+		if !ok {
+			break
+		}
+
+		if b.lossless && tok.start > b.consumed {
+			b.ignore(b.consumed, tok.start)
+		}
+
+		b.process(tok)
+		b.consumed = tok.end
+	}
+
+	if b.lossless && b.consumed < len(b.tokenizer.input) {
+		b.ignore(b.consumed, len(b.tokenizer.input))
+	}
+
+	if b.mode == XmlMode {
+		for b.open.Size() > 1 {
+			unclosed := b.open.Pop()
+			b.fail(unclosed.line, unclosed.column, xmlUnclosedElementError, unclosed.name)
+		}
+
+		if !b.hasRoot {
+			b.fail(1, 1, xmlMissingRootError)
+		}
+	}
+
+	return b.document
+}
+
+func (b *treeBuilder) fail(line int, column int, data ErrorData, args ...any) {
+	b.errors.Push(data.at(line, column, args...))
+}
+
+func (b *treeBuilder) raw(start int, end int) string {
+	return string(b.tokenizer.input[start:end])
+}
+
+// ignore keeps markup that does not produce a node next to the markup that
+// precedes it.
+func (b *treeBuilder) ignore(start int, end int) {
+	*b.lastRaw += b.raw(start, end)
+}
+
+func (b *treeBuilder) ignoreToken(tok *token) {
+	if b.lossless {
+		b.ignore(tok.start, tok.end)
+	}
+}
+
+// record attaches the raw markup of a token to a node that is not a tag.
+func (b *treeBuilder) record(node *Element, tok *token) {
+	if !b.lossless {
+		return
+	}
+
+	node.source = &elementSource{head: b.raw(tok.start, tok.end), name: node.name, value: node.value}
+	b.lastRaw = &node.source.head
+}
+
+func (b *treeBuilder) recordEnd(node *Element, tok *token) {
+	if !b.lossless {
+		return
+	}
+
+	node.source.end = b.raw(tok.start, tok.end)
+	b.lastRaw = &node.source.end
+}
+
+func (b *treeBuilder) current() *Element {
+	return b.open.Peek()
+}
+
+func (b *treeBuilder) newNode(elementType ElementType, tok *token) *Element {
+	line, column := b.tokenizer.position(tok.start)
+	return &Element{
+		elementType: elementType,
+		name:        tok.name,
+		value:       tok.value,
+		line:        line,
+		column:      column,
+	}
+}
+
+func (b *treeBuilder) process(tok *token) {
+	switch tok.tokenType {
+	case textToken:
+		b.insertText(tok)
+	case commentToken:
+		b.insertNode(b.newNode(CommentElement, tok), tok)
+	case cdataToken:
+		if b.current() == b.document {
+			line, column := b.tokenizer.position(tok.start)
+			b.fail(line, column, xmlContentOutsideRootError)
+		}
+		b.insertNode(b.newNode(CDATAElement, tok), tok)
+	case doctypeToken:
+		b.insertNode(b.newNode(DoctypeElement, tok), tok)
+	case instructionToken:
+		b.insertInstruction(tok)
+	case startTagToken:
+		if b.mode == XmlMode {
+			b.insertXmlElement(tok)
+		} else {
+			b.insertHtmlElement(tok)
+		}
+	case endTagToken:
+		if b.mode == XmlMode {
+			b.closeXmlElement(tok)
+		} else {
+			b.closeHtmlElement(tok)
+		}
+	}
+}
+
+func (b *treeBuilder) insertNode(node *Element, tok *token) {
+	b.record(node, tok)
+	b.current().appendChild(node)
+}
+
+func (b *treeBuilder) insertText(tok *token) {
+	parent := b.current()
+
+	if b.mode == XmlMode && parent == b.document {
+		if strings.TrimLeft(tok.value, " \t\r\n") != "" {
+			line, column := b.tokenizer.position(tok.start)
+			b.fail(line, column, xmlContentOutsideRootError)
+		}
+		b.ignoreToken(tok)
+		return
+	}
+
+	// Adjacent character data is merged into a single node
+	if last := parent.lastChild; last != nil && last.elementType == TextElement {
+		last.value += tok.value
+		if b.lossless {
+			last.source.head += b.raw(tok.start, tok.end)
+			last.source.value = last.value
+			b.lastRaw = &last.source.head
+		}
+		return
+	}
+
+	b.insertNode(b.newNode(TextElement, tok), tok)
+}
+
+func (b *treeBuilder) insertInstruction(tok *token) {
+	node := b.newNode(InstructionElement, tok)
+
+	if tok.name != "xml" {
+		if strings.EqualFold(tok.name, "xml") {
+			b.fail(node.line, node.column, xmlReservedTargetError, tok.name)
+		}
+		b.insertNode(node, tok)
+		return
+	}
+
+	if tok.start != 0 {
+		b.fail(node.line, node.column, xmlMisplacedDeclarationError)
+	}
+
+	properties, problem := parsePseudoAttributes(tok.value)
+	if problem == "" && (len(properties) == 0 || properties[0].name != "version") {
+		problem = "the version is missing"
+	}
+
+	if problem != "" {
+		b.fail(node.line, node.column, xmlInvalidDeclarationError, problem)
+	}
+
+	node.elementType = DeclarationElement
+	node.properties = properties
+	node.indexProperties()
+	b.insertNode(node, tok)
+}
+
+func (b *treeBuilder) newElement(tok *token) *Element {
+	node := b.newNode(TagElement, tok)
+	node.value = ""
+
+	previous := tok.nameEnd
+	for _, attribute := range tok.attributes {
+		property := &Property{propertyType: Value, name: attribute.name, value: attribute.value, dialect: b.dialect}
+
+		// The XML tokenizer already reports invalid names
+		if b.mode == HtmlMode && !b.dialect(attribute.name) {
+			line, column := b.tokenizer.position(attribute.offset)
+			b.fail(line, column, htmlInvalidAttributeNameError, attribute.name)
+		}
+
+		// HTML boolean properties are true whatever their value is. Other
+		// HTML properties without a value are empty, while XML has no empty
+		// form and keeps them boolean.
+		if (b.mode == HtmlMode && booleanAttributes[attribute.name]) || (b.mode == XmlMode && !attribute.hasValue) {
+			property.propertyType = Boolean
+			property.value = "true"
+			property.literal = attribute.value
+		}
+
+		if b.lossless {
+			start := previous
+			for _, dropped := range tok.dropped {
+				if dropped.offset >= previous && dropped.end <= attribute.offset {
+					start = max(start, dropped.end)
+				}
+			}
+
+			property.source = &propertySource{
+				dropped:      b.raw(previous, start),
+				prefix:       b.raw(start, attribute.offset),
+				raw:          b.raw(attribute.offset, attribute.end),
+				rawName:      b.raw(attribute.offset, attribute.nameEnd),
+				quote:        attribute.quote,
+				propertyType: property.propertyType,
+				name:         property.name,
+				value:        property.value,
+				literal:      property.literal,
+			}
+			previous = attribute.end
+		}
+
+		node.properties = append(node.properties, property)
+	}
+	node.indexProperties()
+
+	if b.lossless {
+		node.source = &elementSource{
+			head:    b.raw(tok.start, tok.nameEnd),
+			closing: b.raw(previous, tok.end),
+			name:    node.name,
+		}
+		b.lastRaw = &node.source.closing
+	}
+
+	return node
+}
+
+func (b *treeBuilder) insertHtmlElement(tok *token) {
+	b.closeImplied(tok.name)
+
+	node := b.newElement(tok)
+	b.current().appendChild(node)
+
+	if isVoidElement(tok.name) {
+		return
+	}
+
+	if tok.selfClosing && b.inForeignContent(tok.name) {
+		return
+	}
+
+	b.open.Push(node)
+}
+
+func (b *treeBuilder) inForeignContent(name string) bool {
+	if name == "svg" || name == "math" {
+		return true
+	}
+
+	for i := b.open.Size() - 1; i > 0; i-- {
+		switch b.open.values[i].name {
+		case "svg", "math":
+			return true
+		case "foreignobject", "desc", "title":
+			return false
+		}
+	}
+
+	return false
+}
+
+// closeImplied pops the elements whose end tag may be omitted before the
+// start tag of the given name.
+func (b *treeBuilder) closeImplied(name string) {
+	if paragraphClosers[name] {
+		b.closeInScope("p", nil)
+	}
+
+	switch name {
+	case "li":
+		b.closeInScope("li", map[string]bool{"ul": true, "ol": true})
+	case "dt", "dd":
+		b.closeInScope("dt", map[string]bool{"dl": true})
+		b.closeInScope("dd", map[string]bool{"dl": true})
+	case "option":
+		b.closeCurrent("option")
+	case "optgroup":
+		b.closeCurrent("option")
+		b.closeCurrent("optgroup")
+	case "tr":
+		b.closeCells()
+		b.closeInScope("tr", map[string]bool{"tbody": true, "thead": true, "tfoot": true})
+	case "td", "th":
+		b.closeCells()
+	case "thead", "tbody", "tfoot":
+		b.closeCells()
+		b.closeInScope("tr", nil)
+		b.closeInScope("thead", nil)
+		b.closeInScope("tbody", nil)
+		b.closeInScope("tfoot", nil)
+	case "body":
+		b.closeInScope("head", nil)
+	case "rb", "rt", "rtc", "rp":
+		b.closeInScope("rb", map[string]bool{"ruby": true})
+		b.closeInScope("rt", map[string]bool{"ruby": true})
+		b.closeInScope("rp", map[string]bool{"ruby": true})
+	}
+}
+
+func (b *treeBuilder) closeCells() {
+	b.closeInScope("td", map[string]bool{"tr": true})
+	b.closeInScope("th", map[string]bool{"tr": true})
+}
+
+func (b *treeBuilder) closeCurrent(name string) {
+	if b.current().name == name {
+		b.open.Pop()
+	}
+}
+
+func (b *treeBuilder) closeInScope(name string, boundaries map[string]bool) {
+	index := b.findInScope(name, boundaries)
+	if index == -1 {
+		return
+	}
+
+	for b.open.Size() > index {
+		b.open.Pop()
+	}
+}
+
+func (b *treeBuilder) findInScope(name string, boundaries map[string]bool) int {
+	for i := b.open.Size() - 1; i > 0; i-- {
+		current := b.open.values[i].name
+
+		if current == name {
+			return i
+		}
+
+		if scopeElements[current] || boundaries[current] {
+			return -1
+		}
+	}
+
+	return -1
+}
+
+func (b *treeBuilder) closeHtmlElement(tok *token) {
+	// </br> is treated as <br> by browsers
+	if tok.name == "br" {
+		b.insertNode(b.newNode(TagElement, tok), tok)
+		return
+	}
+
+	for i := b.open.Size() - 1; i > 0; i-- {
+		if b.open.values[i].name != tok.name {
+			continue
+		}
+
+		var closed *Element
+		for b.open.Size() > i {
+			closed = b.open.Pop()
+		}
+		b.recordEnd(closed, tok)
+		return
+	}
+
+	b.ignoreToken(tok)
+}
+
+func (b *treeBuilder) insertXmlElement(tok *token) {
+	node := b.newElement(tok)
+	parent := b.current()
+
+	if parent == b.document {
+		if b.hasRoot {
+			b.fail(node.line, node.column, xmlMultipleRootsError, node.name)
+		}
+		b.hasRoot = true
+	}
+
+	scope := map[string]string{}
+	for _, property := range node.properties {
+		if property.name == "xmlns" {
+			scope[""] = property.value
+		} else if strings.HasPrefix(property.name, "xmlns:") {
+			scope[property.name[len("xmlns:"):]] = property.value
+		}
+	}
+	b.scopes = append(b.scopes, scope)
+
+	node.prefix, node.namespace = b.resolve(node, node.name, true)
+	for _, property := range node.properties {
+		_, property.namespace = b.resolve(node, property.name, false)
+	}
+
+	parent.appendChild(node)
+
+	if tok.selfClosing {
+		b.scopes = b.scopes[:len(b.scopes)-1]
+		return
+	}
+
+	b.open.Push(node)
+}
+
+// resolve returns the prefix of a qualified name and the namespace bound to
+// it. Unprefixed attributes are never in a namespace.
+func (b *treeBuilder) resolve(node *Element, name string, isElement bool) (string, string) {
+	prefix := ""
+	if index := strings.IndexByte(name, ':'); index != -1 {
+		prefix = name[:index]
+	}
+
+	if name == "xmlns" {
+		return "", xmlnsNamespace
+	}
+
+	if prefix == "" && !isElement {
+		return "", ""
+	}
+
+	for i := len(b.scopes) - 1; i >= 0; i-- {
+		if namespace, ok := b.scopes[i][prefix]; ok {
+			return prefix, namespace
+		}
+	}
+
+	if prefix != "" {
+		b.fail(node.line, node.column, xmlUndeclaredPrefixError, prefix)
+	}
+
+	return prefix, ""
+}
+
+func (b *treeBuilder) closeXmlElement(tok *token) {
+	line, column := b.tokenizer.position(tok.start)
+
+	if b.open.Size() == 1 {
+		b.fail(line, column, xmlUnexpectedEndTagError, tok.name)
+		b.ignoreToken(tok)
+		return
+	}
+
+	current := b.open.Pop()
+	b.scopes = b.scopes[:len(b.scopes)-1]
+	b.recordEnd(current, tok)
+
+	if current.name != tok.name {
+		b.fail(line, column, xmlMismatchedTagError, current.name, tok.name)
+	}
+}
+
+// parsePseudoAttributes reads the name="value" pairs of an XML declaration.
+// A non-empty string describes the first problem found.
+func parsePseudoAttributes(data string) ([]*Property, string) {
+	properties := []*Property{}
+	allowed := map[string]int{"version": 0, "encoding": 1, "standalone": 2}
+	last := -1
+
+	rest := strings.TrimSpace(data)
+	for rest != "" {
+		index := strings.IndexByte(rest, '=')
+		if index == -1 {
+			return properties, "expected '=' after '" + rest + "'"
+		}
+
+		name := strings.TrimSpace(rest[:index])
+		rest = strings.TrimSpace(rest[index+1:])
+
+		order, ok := allowed[name]
+		if !ok {
+			return properties, "unknown pseudo attribute '" + name + "'"
+		}
+
+		if order <= last {
+			return properties, "pseudo attribute '" + name + "' is out of order"
+		}
+		last = order
+
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			return properties, "value of '" + name + "' must be quoted"
+		}
+
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end == -1 {
+			return properties, "value of '" + name + "' is not terminated"
+		}
+
+		value := rest[1 : end+1]
+		if name == "standalone" && value != "yes" && value != "no" {
+			return properties, "standalone must be 'yes' or 'no'"
+		}
+
+		properties = append(properties, &Property{propertyType: Value, name: name, value: value})
+		rest = strings.TrimSpace(rest[end+2:])
+	}
+
+	return properties, ""
+}
@@ -0,0 +1,526 @@
+package parseme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fueripe-desu/parseme/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// outline describes a tree in a compact form that is easy to compare in
+// tests: tags are written as name[attributes](children) and every other
+// node as a short prefix followed by its quoted value.
+func outline(element *Element) string {
+	builder := &strings.Builder{}
+	writeOutline(element, builder)
+	return builder.String()
+}
+
+func writeOutline(element *Element, builder *strings.Builder) {
+	switch element.elementType {
+	case TextElement:
+		builder.WriteString("'" + element.value + "'")
+		return
+	case CommentElement:
+		builder.WriteString("!'" + element.value + "'")
+		return
+	case CDATAElement:
+		builder.WriteString("#'" + element.value + "'")
+		return
+	case DoctypeElement:
+		builder.WriteString("doctype " + element.name)
+		return
+	case InstructionElement:
+		builder.WriteString("?" + element.name + "'" + element.value + "'")
+		return
+	case DeclarationElement:
+		builder.WriteString("?xml")
+	case TagElement:
+		builder.WriteString(element.name)
+	}
+
+	if len(element.properties) > 0 {
+		pairs := []string{}
+		for _, property := range element.properties {
+			pairs = append(pairs, property.name+"="+property.value)
+		}
+		builder.WriteString("[" + strings.Join(pairs, " ") + "]")
+	}
+
+	if element.firstChild == nil {
+		return
+	}
+
+	if element.elementType != DocumentElement {
+		builder.WriteString("(")
+	}
+
+	for child := element.firstChild; child != nil; child = child.nextSibling {
+		writeOutline(child, builder)
+		if child.nextSibling != nil {
+			builder.WriteString(" ")
+		}
+	}
+
+	if element.elementType != DocumentElement {
+		builder.WriteString(")")
+	}
+}
+
+func parseString(mode ParserMode, input string) (*Element, []ErrorData, error) {
+	parser := NewHtmlParser("")
+	parser.SetMode(mode)
+	document, err := parser.ParseString(input)
+	return document, parser.Errors(), err
+}
+
+func Test_buildHtml(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			"nested tags",
+			"<html><p>This is a paragraph</p></html>",
+			"html(p('This is a paragraph'))",
+		},
+		{
+			"names are lowercased",
+			"<HTML LANG=en><P>text</p></HTML>",
+			"html[lang=en](p('text'))",
+		},
+		{
+			"quoted values with spaces",
+			"<div class=\"a b\" title='c d'></div>",
+			"div[class=a b title=c d]",
+		},
+		{
+			"boolean property",
+			"<input disabled>",
+			"input[disabled=true]",
+		},
+		{
+			"duplicate property keeps first",
+			"<a href=x href=y></a>",
+			"a[href=x]",
+		},
+		{
+			"void elements are never opened",
+			"<p>a<br>b<img src=x>c</p>",
+			"p('a' br 'b' img[src=x] 'c')",
+		},
+		{
+			"self closing syntax on non void element",
+			"<div/>text</div>",
+			"div('text')",
+		},
+		{
+			"self closing syntax in svg",
+			"<svg><circle/><rect/></svg>",
+			"svg(circle rect)",
+		},
+		{
+			"paragraph closed by block",
+			"<p>one<div>two</div>",
+			"p('one') div('two')",
+		},
+		{
+			"paragraph closed by paragraph",
+			"<p>one<p>two",
+			"p('one') p('two')",
+		},
+		{
+			"list items close each other",
+			"<ul><li>a<li>b</ul>",
+			"ul(li('a') li('b'))",
+		},
+		{
+			"nested lists",
+			"<ul><li>a<ul><li>b</ul><li>c</ul>",
+			"ul(li('a' ul(li('b'))) li('c'))",
+		},
+		{
+			"table cells and rows",
+			"<table><tr><td>a<td>b<tr><td>c</table>",
+			"table(tr(td('a') td('b')) tr(td('c')))",
+		},
+		{
+			"definition list",
+			"<dl><dt>a<dd>b<dt>c</dl>",
+			"dl(dt('a') dd('b') dt('c'))",
+		},
+		{
+			"options",
+			"<select><option>a<option>b</select>",
+			"select(option('a') option('b'))",
+		},
+		{
+			"stray end tag is ignored",
+			"<div>a</span>b</div>",
+			"div('ab')",
+		},
+		{
+			"end tag closes inner elements",
+			"<div><b><i>a</div>b",
+			"div(b(i('a'))) 'b'",
+		},
+		{
+			"end br becomes a break",
+			"a</br>b",
+			"'a' br 'b'",
+		},
+		{
+			"raw text in script",
+			"<script>if (a < b && c) { x = '</p>' }</script>",
+			"script('if (a < b && c) { x = '</p>' }')",
+		},
+		{
+			"escapable raw text in title",
+			"<title>a &amp; <b></title>",
+			"title('a & <b>')",
+		},
+		{
+			"comments",
+			"<!-- one --><p><!---->",
+			"!' one ' p(!'')",
+		},
+		{
+			"comment ended by --!>",
+			"<!-- a --!><p>b</p> -->",
+			"!' a ' p('b') ' -->'",
+		},
+		{
+			"doctype",
+			"<!DOCTYPE html><html></html>",
+			"doctype html html",
+		},
+		{
+			"processing instruction is a bogus comment",
+			"<?php echo 1 ?>",
+			"!'?php echo 1 ?'",
+		},
+		{
+			"entities in text and attributes",
+			"<a title=\"&lt;&#x41;&copy\">&amp;&nbsp;&#65;</a>",
+			"a[title=<A©]('&\u00a0A')",
+		},
+		{
+			"whitespace is preserved",
+			"<p> a\n b </p>",
+			"p(' a\n b ')",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, err := parseString(HtmlMode, tc.value)
+			assert.Nil(err)
+			assert.Equal(outline(document), tc.expected)
+		})
+	}
+}
+
+func Test_buildXml(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			"names keep their case",
+			"<Root><Child Attr='v'/></Root>",
+			"Root(Child[Attr=v])",
+		},
+		{
+			"declaration",
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?><root/>",
+			"?xml[version=1.0 encoding=UTF-8] root",
+		},
+		{
+			"processing instruction",
+			"<root><?xml-stylesheet href='a.css'?></root>",
+			"root(?xml-stylesheet'href='a.css'')",
+		},
+		{
+			"cdata section",
+			"<root><![CDATA[<b> & </b>]]></root>",
+			"root(#'<b> & </b>')",
+		},
+		{
+			"whitespace outside root is dropped",
+			"\n<root/>\n",
+			"root",
+		},
+		{
+			"predefined entities",
+			"<root>&lt;&gt;&amp;&quot;&apos;&#65;</root>",
+			"root('<>&\"'A')",
+		},
+		{
+			"no implied end tags",
+			"<p><p>a</p></p>",
+			"p(p('a'))",
+		},
+		{
+			"script content is parsed as markup",
+			"<script><b/></script>",
+			"script(b)",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, errors, err := parseString(XmlMode, tc.value)
+			assert.Nil(err)
+			assert.Empty(errors)
+			assert.Equal(outline(document), tc.expected)
+		})
+	}
+}
+
+func Test_buildXmlErrors(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"mismatched end tag", "<a><b></a></b>", []string{"X01", "X01"}},
+		{"unexpected end tag", "<a/></b>", []string{"X02"}},
+		{"unclosed element", "<a><b>", []string{"X03", "X03"}},
+		{"multiple roots", "<a/><b/>", []string{"X04"}},
+		{"text outside root", "text<a/>", []string{"X05"}},
+		{"missing root", "<!-- only a comment -->", []string{"X06"}},
+		{"undefined entity", "<a>&nbsp;</a>", []string{"X07"}},
+		{"bare ampersand", "<a>a & b</a>", []string{"X08"}},
+		{"duplicate attribute", "<a x='1' x='2'/>", []string{"X09"}},
+		{"unquoted attribute", "<a x=1 />", []string{"X10"}},
+		{"attribute without value", "<a checked/>", []string{"X11"}},
+		{"less than in attribute", "<a x='<'/>", []string{"X12"}},
+		{"undeclared prefix", "<svg:a/>", []string{"X13"}},
+		{"misplaced declaration", "<a/><?xml version='1.0'?>", []string{"X14"}},
+		{"declaration without version", "<?xml encoding='UTF-8'?><a/>", []string{"X15"}},
+		{"reserved target", "<a><?XML data?></a>", []string{"X16"}},
+		{"unterminated comment", "<a/><!-- open", []string{"X17"}},
+		{"invalid name", "<a><1b/></a>", []string{"X20"}},
+		{"double hyphen in comment", "<a><!-- a -- b --></a>", []string{"X19"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, errors, err := parseString(XmlMode, tc.value)
+
+			codes := []string{}
+			for _, data := range errors {
+				codes = append(codes, data.Code)
+			}
+
+			assert.Nil(document)
+			assert.Equal(codes, tc.expected)
+			assert.Equal(err, &XmlWellFormednessError{Data: errors[0]})
+		})
+	}
+}
+
+type errorObserver struct {
+	infos []errors.ErrorInfo
+}
+
+func (o *errorObserver) OnUpdate(info errors.ErrorInfo) {
+	o.infos = append(o.infos, info)
+}
+
+// Test_buildReportsErrors checks that every diagnostic passes the checks of
+// the error pool when a logger is initialized.
+func Test_buildReportsErrors(t *testing.T) {
+	t.Cleanup(func() {
+		errors.InitLogger(nil)
+	})
+
+	testcases := []struct {
+		mode     ParserMode
+		value    string
+		expected string
+	}{
+		{XmlMode, "<a><b></a></b>", "X01"},
+		{XmlMode, "<a/></b>", "X02"},
+		{XmlMode, "<a><b>", "X03"},
+		{XmlMode, "<a/><b/>", "X04"},
+		{XmlMode, "text<a/>", "X05"},
+		{XmlMode, "<!-- only a comment -->", "X06"},
+		{XmlMode, "<a>&nbsp;</a>", "X07"},
+		{XmlMode, "<a>a & b</a>", "X08"},
+		{XmlMode, "<a x='1' x='2'/>", "X09"},
+		{XmlMode, "<a x=1 />", "X10"},
+		{XmlMode, "<a checked/>", "X11"},
+		{XmlMode, "<a x='<'/>", "X12"},
+		{XmlMode, "<svg:a/>", "X13"},
+		{XmlMode, "<a/><?xml version='1.0'?>", "X14"},
+		{XmlMode, "<?xml encoding='UTF-8'?><a/>", "X15"},
+		{XmlMode, "<a><?XML data?></a>", "X16"},
+		{XmlMode, "<a/><!-- open", "X17"},
+		{XmlMode, "<a 1x=\"y\"/>", "X18"},
+		{XmlMode, "<a><!-- a -- b --></a>", "X19"},
+		{XmlMode, "<a><1b/></a>", "X20"},
+		{HtmlMode, "<a b\x01c=d></a>", "H01"},
+		{HtmlMode, "<a x=1 x=2></a>", "H02"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert := assert.New(t)
+			pool := &errors.ErrorPool{}
+			observer := &errorObserver{}
+			pool.Subscribe(observer)
+			errors.InitLogger(pool)
+
+			assert.NotPanics(func() {
+				parseString(tc.mode, tc.value)
+			})
+
+			codes := []string{}
+			for _, info := range observer.infos {
+				codes = append(codes, info.Code)
+			}
+			assert.Contains(codes, tc.expected)
+		})
+	}
+}
+
+func Test_escapeControls(t *testing.T) {
+	assert := assert.New(t)
+	data := htmlInvalidAttributeNameError.at(1, 2, "a\tb\x01")
+	assert.Equal(data.Message, "Property name 'a\\tb\\x01' is not allowed by the attribute dialect.")
+}
+
+func Test_buildXmlNamespaces(t *testing.T) {
+	assert := assert.New(t)
+	input := `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">` +
+		`<body epub:type="bodymatter" xml:lang="en"><svg:svg xmlns:svg="http://www.w3.org/2000/svg"/></body></html>`
+
+	document, errors, err := parseString(XmlMode, input)
+	assert.Nil(err)
+	assert.Empty(errors)
+
+	html := document.firstChild
+	body := html.firstChild
+	svg := body.firstChild
+
+	assert.Equal(html.Namespace(), "http://www.w3.org/1999/xhtml")
+	assert.Equal(html.Property("xmlns").Namespace(), xmlnsNamespace)
+	assert.Equal(body.Namespace(), "http://www.w3.org/1999/xhtml")
+	assert.Equal(body.Property("epub:type").Namespace(), "http://www.idpf.org/2007/ops")
+	assert.Equal(body.Property("xml:lang").Namespace(), xmlNamespace)
+	assert.Equal(svg.Namespace(), "http://www.w3.org/2000/svg")
+	assert.Equal(svg.Prefix(), "svg")
+	assert.Equal(svg.LocalName(), "svg")
+}
+
+func Test_buildDuplicateAttributes(t *testing.T) {
+	testcases := []struct {
+		name     string
+		policy   DuplicatePolicy
+		expected string
+		err      bool
+	}{
+		{"keep first", KeepFirstAttribute, "a[href=x class=c title=t]", false},
+		{"keep last", KeepLastAttribute, "a[class=c title=t href=z]", false},
+		{"reject", RejectDuplicateAttributes, "", true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			parser := &HtmlParser{}
+			parser.SetDuplicatePolicy(tc.policy)
+			bytes := []byte("<a href=x class=c href=y\n title=t href=z></a>")
+			document, err := parser.ParseBytes(bytes)
+
+			errors := parser.Errors()
+			assert.Len(errors, 2)
+			assert.Equal(errors[0].Code, "H02")
+			assert.Equal([]int{errors[0].Line, errors[0].Column}, []int{1, 19})
+			assert.Equal([]int{errors[1].Line, errors[1].Column}, []int{2, 10})
+
+			if tc.err {
+				assert.Nil(document)
+				assert.Equal(err, &DuplicateAttributeError{Data: errors[0]})
+			} else {
+				assert.Nil(err)
+				assert.Equal(outline(document), tc.expected)
+			}
+		})
+	}
+}
+
+func Test_parsePseudoAttributes(t *testing.T) {
+	testcases := []struct {
+		name            string
+		value           string
+		expected        []*Property
+		expectedProblem bool
+	}{
+		{
+			"version only",
+			"version=\"1.0\"",
+			[]*Property{{name: "version", value: "1.0"}},
+			false,
+		},
+		{
+			"all attributes",
+			"version='1.0' encoding='UTF-8' standalone='yes'",
+			[]*Property{{name: "version", value: "1.0"}, {name: "encoding", value: "UTF-8"}, {name: "standalone", value: "yes"}},
+			false,
+		},
+		{
+			"out of order",
+			"encoding='UTF-8' version='1.0'",
+			[]*Property{{name: "encoding", value: "UTF-8"}},
+			true,
+		},
+		{
+			"unknown attribute",
+			"version='1.0' lang='en'",
+			[]*Property{{name: "version", value: "1.0"}},
+			true,
+		},
+		{
+			"unquoted value",
+			"version=1.0",
+			[]*Property{},
+			true,
+		},
+		{
+			"invalid standalone",
+			"version='1.0' standalone='maybe'",
+			[]*Property{{name: "version", value: "1.0"}},
+			true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, problem := parsePseudoAttributes(tc.value)
+			assert.Equal(result, tc.expected)
+			assert.Equal(problem != "", tc.expectedProblem)
+		})
+	}
+}
+
+func Test_buildValuelessAttributes(t *testing.T) {
+	assert := assert.New(t)
+
+	document, _, _ := parseString(HtmlMode, "<input value disabled><div data-x></div>")
+	input := document.firstChild
+	assert.False(input.Property("value").IsBoolean())
+	assert.Equal(input.Property("value").Value(), "")
+	assert.True(input.Property("disabled").IsBoolean())
+	assert.Equal(input.Property("disabled").Value(), "true")
+	assert.False(input.nextSibling.Property("data-x").IsBoolean())
+	assert.Equal(input.nextSibling.Property("data-x").Value(), "")
+}
@@ -0,0 +1,363 @@
+package parseme
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// StyleOrigin is where a stylesheet comes from. Important declarations
+// reverse the precedence of origins.
+type StyleOrigin int
+
+const (
+	UserAgentOrigin StyleOrigin = iota
+	UserOrigin
+	AuthorOrigin
+)
+
+// ComputedStyle maps property names to the values that apply to an
+// element after the cascade and inheritance. Properties left at their
+// initial value are absent, and shorthands are not expanded.
+type ComputedStyle map[string]string
+
+func (s ComputedStyle) Value(property string) string {
+	return s[normalizeCssProperty(property)]
+}
+
+// All returns the properties sorted by name.
+func (s ComputedStyle) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, property := range slices.Sorted(maps.Keys(s)) {
+			if !yield(property, s[property]) {
+				return
+			}
+		}
+	}
+}
+
+// Cascade computes the style of elements from a set of stylesheets and
+// their style properties. Rules inside @media apply when one of its media
+// queries holds. The conditions of @supports and of the other grouping
+// at-rules are not checked and their rules always apply, while keyframes
+// are skipped and @import is not followed.
+type Cascade struct {
+	rules []*cascadeRule
+	media func(query string) bool
+}
+
+type cascadeRule struct {
+	rule     *CssRule
+	selector *Selector
+	origin   StyleOrigin
+	// media holds the preludes of the enclosing @media rules
+	media []string
+}
+
+// cascadeEntry is a declaration competing for a property of an element.
+type cascadeEntry struct {
+	value       string
+	important   bool
+	origin      StyleOrigin
+	inline      bool
+	specificity Specificity
+	order       int
+}
+
+func NewCascade() *Cascade {
+	return &Cascade{media: matchScreenMedia}
+}
+
+// Cascade returns a cascade of the default HTML stylesheet and of the
+// stylesheets of the document, which are read as Stylesheets does. The
+// cascade is returned with the sheets that could be read even when some
+// could not.
+func (e *Element) Cascade(dir string) (*Cascade, error) {
+	sheets, err := e.Stylesheets(dir)
+
+	cascade := NewCascade()
+	cascade.AddStylesheet(DefaultStylesheet(), UserAgentOrigin)
+	for _, sheet := range sheets {
+		cascade.AddStylesheet(sheet, AuthorOrigin)
+	}
+
+	return cascade, err
+}
+
+// SetMedia selects the media query lists that apply. By default a list
+// applies when one of its queries is for all or screen media, so that
+// feature queries such as (max-width: 600px) are considered true.
+func (c *Cascade) SetMedia(media func(query string) bool) {
+	c.media = media
+}
+
+// AddStylesheet adds the style rules of the sheet. Rules with selectors
+// that cannot be compiled, such as pseudo-elements, are skipped.
+func (c *Cascade) AddStylesheet(sheet *Stylesheet, origin StyleOrigin) {
+	c.addRules(sheet.Rules, origin, nil)
+}
+
+func (c *Cascade) addRules(rules []*CssRule, origin StyleOrigin, media []string) {
+	for _, rule := range rules {
+		switch {
+		case rule.Type == StyleRule:
+			if selector := compileRuleSelector(rule); selector != nil {
+				c.rules = append(c.rules, &cascadeRule{rule: rule, selector: selector, origin: origin, media: media})
+			}
+		case rule.Type == MediaRule:
+			c.addRules(rule.Rules, origin, append(slices.Clip(media), rule.Prelude))
+		case rule.Name != "keyframes" && !strings.HasSuffix(rule.Name, "-keyframes"):
+			c.addRules(rule.Rules, origin, media)
+		}
+	}
+}
+
+// compileRuleSelector compiles the selectors of the rule one by one, so that
+// an unsupported selector does not drop the others.
+func compileRuleSelector(rule *CssRule) *Selector {
+	compiled := &Selector{source: rule.Prelude}
+
+	for _, selector := range rule.Selectors() {
+		if single, err := CompileSelector(selector); err == nil {
+			compiled.list = append(compiled.list, single.list...)
+		}
+	}
+
+	if len(compiled.list) == 0 {
+		return nil
+	}
+
+	return compiled
+}
+
+// Compute returns the style of the element, inheriting from its ancestors.
+func (c *Cascade) Compute(element *Element) ComputedStyle {
+	styles := c.computeAncestry(element)
+	return styles[len(styles)-1]
+}
+
+// ComputeAll returns the style of root, when it is a tag, and of every tag
+// below it.
+func (c *Cascade) ComputeAll(root *Element) map[*Element]ComputedStyle {
+	styles := map[*Element]ComputedStyle{}
+	if root.elementType == TagElement {
+		styles[root] = c.Compute(root)
+	}
+
+	for element := range root.Descendants() {
+		if element.elementType == TagElement {
+			styles[element] = c.compute(element, styles[parentElement(element)])
+		}
+	}
+
+	return styles
+}
+
+// Hidden reports whether the element is not rendered, because it or one
+// of its ancestors has display: none, or is invisible.
+func (c *Cascade) Hidden(element *Element) bool {
+	styles := c.computeAncestry(element)
+	for _, style := range styles {
+		if strings.EqualFold(style.Value("display"), "none") {
+			return true
+		}
+	}
+
+	visibility := styles[len(styles)-1].Value("visibility")
+	return strings.EqualFold(visibility, "hidden") || strings.EqualFold(visibility, "collapse")
+}
+
+// computeAncestry returns the styles of the ancestors of the element from
+// the outermost one, followed by the style of the element.
+func (c *Cascade) computeAncestry(element *Element) []ComputedStyle {
+	ancestry := []*Element{element}
+	for parent := parentElement(element); parent != nil; parent = parentElement(parent) {
+		ancestry = append(ancestry, parent)
+	}
+
+	styles := []ComputedStyle{}
+	var parent ComputedStyle
+	for i := len(ancestry) - 1; i >= 0; i-- {
+		parent = c.compute(ancestry[i], parent)
+		styles = append(styles, parent)
+	}
+
+	return styles
+}
+
+func (c *Cascade) compute(element *Element, parent ComputedStyle) ComputedStyle {
+	winners := map[string]cascadeEntry{}
+	compete := func(property string, entry cascadeEntry) {
+		if current, ok := winners[property]; !ok || entry.wins(current) {
+			winners[property] = entry
+		}
+	}
+
+	if element.elementType == TagElement {
+		for order, rule := range c.rules {
+			if !c.mediaApplies(rule.media) {
+				continue
+			}
+
+			specificity, ok := rule.selector.MatchSpecificity(element)
+			if !ok {
+				continue
+			}
+
+			for _, declaration := range rule.rule.Declarations {
+				compete(declaration.Property, cascadeEntry{
+					value:       declaration.Value,
+					important:   declaration.Important,
+					origin:      rule.origin,
+					specificity: specificity,
+					order:       order,
+				})
+			}
+		}
+
+		if style := element.Property("style"); style != nil && !style.IsBoolean() {
+			for _, declaration := range style.Style().Declarations() {
+				compete(declaration.Property, cascadeEntry{
+					value:     declaration.Value,
+					important: declaration.Important,
+					origin:    AuthorOrigin,
+					inline:    true,
+				})
+			}
+		}
+	}
+
+	style := ComputedStyle{}
+	for property, value := range parent {
+		if isInheritedProperty(property) {
+			style[property] = value
+		}
+	}
+
+	for property, entry := range winners {
+		keyword := strings.ToLower(entry.value)
+		if keyword == "unset" || keyword == "revert" || keyword == "revert-layer" {
+			keyword = "initial"
+			if isInheritedProperty(property) {
+				keyword = "inherit"
+			}
+		}
+
+		switch keyword {
+		case "initial":
+			delete(style, property)
+		case "inherit":
+			if value, ok := parent[property]; ok {
+				style[property] = value
+			} else {
+				delete(style, property)
+			}
+		default:
+			style[property] = entry.value
+		}
+	}
+
+	return style
+}
+
+// wins reports whether the entry takes precedence over other, which
+// appeared before it: by origin and importance, then inline declarations
+// win, then by specificity and last the later declaration wins.
+func (e cascadeEntry) wins(other cascadeEntry) bool {
+	if rank, otherRank := e.rank(), other.rank(); rank != otherRank {
+		return rank > otherRank
+	}
+
+	if e.inline != other.inline {
+		return e.inline
+	}
+
+	if comparison := e.specificity.Compare(other.specificity); comparison != 0 {
+		return comparison > 0
+	}
+
+	return e.order >= other.order
+}
+
+func (e cascadeEntry) rank() int {
+	if e.important {
+		return 5 - int(e.origin)
+	}
+
+	return int(e.origin)
+}
+
+func (c *Cascade) mediaApplies(media []string) bool {
+	for _, query := range media {
+		if !c.media(query) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchScreenMedia(list string) bool {
+	for _, query := range splitCss(strings.ToLower(list), ',') {
+		fields := strings.Fields(query)
+		negated := len(fields) > 0 && fields[0] == "not"
+		if len(fields) > 0 && (fields[0] == "not" || fields[0] == "only") {
+			fields = fields[1:]
+		}
+
+		matched := len(fields) == 0 || strings.HasPrefix(fields[0], "(") || fields[0] == "all" || fields[0] == "screen"
+		if matched != negated {
+			return true
+		}
+	}
+
+	return false
+}
+
+var inheritedProperties = map[string]bool{
+	"border-collapse": true, "border-spacing": true, "caption-side": true, "color": true,
+	"cursor": true, "direction": true, "empty-cells": true, "font": true,
+	"font-family": true, "font-feature-settings": true, "font-kerning": true, "font-size": true,
+	"font-size-adjust": true, "font-stretch": true, "font-style": true, "font-variant": true,
+	"font-weight": true, "hyphens": true, "letter-spacing": true, "line-height": true,
+	"list-style": true, "list-style-image": true, "list-style-position": true, "list-style-type": true,
+	"orphans": true, "overflow-wrap": true, "quotes": true, "tab-size": true,
+	"text-align": true, "text-align-last": true, "text-indent": true, "text-shadow": true,
+	"text-transform": true, "visibility": true, "white-space": true, "widows": true,
+	"word-break": true, "word-spacing": true, "word-wrap": true, "writing-mode": true,
+}
+
+// isInheritedProperty reports whether the property inherits by default.
+// Custom properties always do.
+func isInheritedProperty(property string) bool {
+	return inheritedProperties[property] || strings.HasPrefix(property, "--")
+}
+
+// DefaultStylesheet returns the part of the default HTML stylesheet that
+// decides which elements are displayed.
+func DefaultStylesheet() *Stylesheet {
+	return ParseStylesheet(defaultCss)
+}
+
+const defaultCss = `
+[hidden], area, base, basefont, datalist, head, link, meta, noembed,
+noframes, param, rp, script, style, template, title { display: none; }
+html, body, address, blockquote, center, dialog, div, figure, figcaption,
+footer, form, header, hr, legend, listing, main, p, plaintext, pre, search,
+xmp, article, aside, h1, h2, h3, h4, h5, h6, hgroup, nav, section, dir, dd,
+dl, dt, menu, ol, ul, details, summary, fieldset, optgroup { display: block; }
+li { display: list-item; }
+table { display: table; }
+caption { display: table-caption; }
+colgroup { display: table-column-group; }
+col { display: table-column; }
+thead { display: table-header-group; }
+tbody { display: table-row-group; }
+tfoot { display: table-footer-group; }
+tr { display: table-row; }
+td, th { display: table-cell; }
+input[type=hidden i], embed[hidden] { display: none; }
+ruby { display: ruby; }
+rt { display: ruby-text; }
+`
@@ -0,0 +1,162 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Specificity(t *testing.T) {
+	testcases := []struct {
+		selector string
+		expected Specificity
+	}{
+		{"*", Specificity{0, 0, 0}},
+		{"li", Specificity{0, 0, 1}},
+		{"ul li", Specificity{0, 0, 2}},
+		{"ul ol+li", Specificity{0, 0, 3}},
+		{"h1 + *[rel=up]", Specificity{0, 1, 1}},
+		{"ul ol li.red", Specificity{0, 1, 3}},
+		{"li.red.level", Specificity{0, 2, 1}},
+		{"#x34y", Specificity{1, 0, 0}},
+		{"#s12:not(FOO)", Specificity{1, 0, 1}},
+		{".foo :is(.bar, #baz)", Specificity{1, 1, 0}},
+		{":where(#a, .b) p", Specificity{0, 0, 1}},
+		{"a:has(> img.x)", Specificity{0, 1, 2}},
+		{":nth-child(2n of .item)", Specificity{0, 2, 0}},
+		{"a, #b", Specificity{1, 0, 0}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.selector, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(MustCompileSelector(tc.selector).Specificity(), tc.expected)
+		})
+	}
+}
+
+func Test_MatchSpecificity(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, `<p class=a></p>`)
+	selector := MustCompileSelector("p, #x, p.a")
+
+	specificity, ok := selector.MatchSpecificity(document.firstChild)
+	assert.True(ok)
+	assert.Equal(specificity, Specificity{0, 1, 1})
+
+	_, ok = MustCompileSelector("#x").MatchSpecificity(document.firstChild)
+	assert.False(ok)
+}
+
+func computeStyles(t *testing.T, input string) (*Element, *Cascade) {
+	document, _, err := parseString(HtmlMode, input)
+	assert.Nil(t, err)
+
+	cascade, err := document.Cascade("test_data")
+	assert.Nil(t, err)
+	return document, cascade
+}
+
+func Test_Cascade(t *testing.T) {
+	testcases := []struct {
+		name     string
+		css      string
+		html     string
+		property string
+		expected string
+	}{
+		{"later rule wins", "p { color: red } p { color: blue }", "<p id=t></p>", "color", "blue"},
+		{"specificity", "#t { color: red } p.a { color: blue }", "<p id=t class=a></p>", "color", "red"},
+		{"important", "p { color: red !important } #t { color: blue }", "<p id=t></p>", "color", "red"},
+		{"inline style", "#t { color: red }", "<p id=t style='color: blue'></p>", "color", "blue"},
+		{"important beats inline", "#t { color: red !important }", "<p id=t style='color: blue'></p>", "color", "red"},
+		{"inline important", "#t { color: red !important }", "<p id=t style='color: blue !important'></p>", "color", "blue"},
+		{"author beats user agent", "script { display: block }", "<script id=t></script>", "display", "block"},
+		{"user agent", "", "<template id=t></template>", "display", "none"},
+		{"inherited property", "div { color: red; margin: 1px }", "<div><p id=t></p></div>", "color", "red"},
+		{"property not inherited", "div { margin: 1px }", "<div><p id=t></p></div>", "margin", ""},
+		{"inherit keyword", "div { margin: 1px } p { margin: inherit }", "<div><p id=t></p></div>", "margin", "1px"},
+		{"initial keyword", "div { color: red } p { color: initial }", "<div><p id=t></p></div>", "color", ""},
+		{"unset keyword", "div { color: red } p { color: blue } #t { color: unset }", "<div><p id=t></p></div>", "color", "red"},
+		{"custom properties inherit", "div { --Gap: 4px }", "<div><p id=t></p></div>", "--Gap", "4px"},
+		{"screen media", "@media screen and (min-width: 1px) { p { color: red } }", "<p id=t></p>", "color", "red"},
+		{"print media", "@media print { p { color: red } }", "<p id=t></p>", "color", ""},
+		{"negated media", "@media not print { p { color: red } }", "<p id=t></p>", "color", "red"},
+		{"supports", "@supports (display: grid) { p { display: grid } }", "<p id=t></p>", "display", "grid"},
+		{"unsupported selectors are skipped", "p::before, #t { color: red }", "<p id=t></p>", "color", "red"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, cascade := computeStyles(t, "<style>"+tc.css+"</style>"+tc.html)
+			element, _ := document.QuerySelector("#t")
+			assert.Equal(cascade.Compute(element).Value(tc.property), tc.expected)
+		})
+	}
+}
+
+func Test_CascadeMissingStylesheet(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<link rel=stylesheet href=a.css><style>p { color: red }</style><p id=t>")
+
+	cascade, err := document.Cascade("missing")
+	assert.IsType(err, &StylesheetLoadError{})
+
+	element, _ := document.QuerySelector("#t")
+	assert.Equal(cascade.Compute(element).Value("color"), "red")
+}
+
+func Test_ComputeAll(t *testing.T) {
+	assert := assert.New(t)
+	document, cascade := computeStyles(t, `<style>ul { color: red } .b { color: blue }</style><ul><li class=a></li><li class=b></li></ul>`)
+
+	styles := cascade.ComputeAll(document)
+	items, _ := document.QuerySelectorAll("li")
+	assert.Equal(styles[items[0]], ComputedStyle{"color": "red", "display": "list-item"})
+	assert.Equal(styles[items[1]], ComputedStyle{"color": "blue", "display": "list-item"})
+	assert.Equal(styles[items[1]], cascade.Compute(items[1]))
+	assert.Len(styles, 4)
+
+	properties := []string{}
+	for property := range styles[items[0]].All() {
+		properties = append(properties, property)
+	}
+	assert.Equal(properties, []string{"color", "display"})
+}
+
+func Test_Hidden(t *testing.T) {
+	testcases := []struct {
+		name     string
+		html     string
+		expected bool
+	}{
+		{"visible", "<div><span id=t></span></div>", false},
+		{"display none on ancestor", "<div style='display:none'><span id=t></span></div>", true},
+		{"display none in upper case", "<div style='display:NONE'><span id=t></span></div>", true},
+		{"visibility in upper case", "<span id=t style='visibility: Hidden'></span>", true},
+		{"hidden property", "<div hidden><span id=t></span></div>", true},
+		{"visibility hidden", "<div class=invisible><span id=t></span></div>", true},
+		{"visibility restored", "<div class=invisible><span id=t style='visibility: visible'></span></div>", false},
+		{"hidden input", "<input id=t type=HIDDEN>", true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, cascade := computeStyles(t, "<style>.invisible { visibility: hidden }</style>"+tc.html)
+			element, _ := document.QuerySelector("#t")
+			assert.Equal(cascade.Hidden(element), tc.expected)
+		})
+	}
+}
+
+func Test_SetMedia(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<p></p>")
+	cascade := NewCascade()
+	cascade.SetMedia(func(query string) bool { return query == "print" })
+	cascade.AddStylesheet(ParseStylesheet("@media print { p { color: black } } @media screen { p { color: red } }"), AuthorOrigin)
+
+	assert.Equal(cascade.Compute(document.firstChild).Value("color"), "black")
+}
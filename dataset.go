@@ -0,0 +1,161 @@
+package parseme
+
+import (
+	"iter"
+	"strings"
+)
+
+// Dataset is a view of the data-* properties of an element, keyed by their
+// camel-cased names: data-foo-bar is read and written as fooBar. It has no
+// state of its own, so it always reflects the current properties.
+type Dataset struct {
+	element *Element
+}
+
+func (e *Element) Dataset() *Dataset {
+	return &Dataset{element: e}
+}
+
+// Get returns the value stored under key and whether it is present.
+func (d *Dataset) Get(key string) (string, bool) {
+	if !isValidDatasetKey(key) {
+		return "", false
+	}
+
+	property := d.element.Property(datasetName(key))
+	if property == nil || property.namespace != "" {
+		return "", false
+	}
+
+	return datasetValue(property)
+}
+
+// Set stores value under key, adding the data-* property when the element
+// does not have it yet. Keys whose property name the element does not
+// accept are rejected.
+func (d *Dataset) Set(key string, value string) error {
+	name := datasetName(key)
+	if !isValidDatasetKey(key) || !d.element.attributeDialect()(name) {
+		return &ElementInvalidDatasetKeyError{key: key}
+	}
+
+	if property := d.element.Property(name); property != nil && property.IsBoolean() {
+		property.SetType(Value)
+	}
+
+	return d.element.SetProperty(name, value)
+}
+
+// Delete removes the property stored under key and reports whether it was
+// present.
+func (d *Dataset) Delete(key string) bool {
+	if !isValidDatasetKey(key) {
+		return false
+	}
+
+	return d.element.RemoveProperty(datasetName(key))
+}
+
+// Keys returns the keys in the order of the properties.
+func (d *Dataset) Keys() []string {
+	keys := []string{}
+	for key := range d.All() {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (d *Dataset) Len() int {
+	return len(d.Keys())
+}
+
+func (d *Dataset) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		seen := map[string]bool{}
+
+		for _, property := range d.element.properties {
+			key, ok := datasetKey(property)
+			if !ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			value, present := datasetValue(property)
+			if present && !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// datasetKey converts data-foo-bar to fooBar. Names with uppercase letters
+// have no key because HTML lowercases property names.
+func datasetKey(property *Property) (string, bool) {
+	if property.namespace != "" || !strings.HasPrefix(property.name, "data-") {
+		return "", false
+	}
+
+	name := property.name[len("data-"):]
+	builder := strings.Builder{}
+
+	for i := 0; i < len(name); i++ {
+		current := name[i]
+		if current >= 'A' && current <= 'Z' {
+			return "", false
+		}
+
+		if current == '-' && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z' {
+			builder.WriteByte(name[i+1] - 'a' + 'A')
+			i++
+			continue
+		}
+
+		builder.WriteByte(current)
+	}
+
+	return builder.String(), true
+}
+
+// datasetName converts fooBar to data-foo-bar.
+func datasetName(key string) string {
+	builder := strings.Builder{}
+	builder.WriteString("data-")
+
+	for i := 0; i < len(key); i++ {
+		current := key[i]
+		if current >= 'A' && current <= 'Z' {
+			builder.WriteByte('-')
+			current = current - 'A' + 'a'
+		}
+		builder.WriteByte(current)
+	}
+
+	return builder.String()
+}
+
+// isValidDatasetKey rejects empty keys and keys such as foo-bar, which
+// would not survive the conversion to a property name and back.
+func isValidDatasetKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i := 0; i+1 < len(key); i++ {
+		if key[i] == '-' && key[i+1] >= 'a' && key[i+1] <= 'z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// datasetValue reads boolean properties as their written value, so that
+// <div data-open> is read as an empty string.
+func datasetValue(property *Property) (string, bool) {
+	if property.IsBoolean() {
+		return property.literal, property.value != "false"
+	}
+
+	return property.value, true
+}
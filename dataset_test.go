@@ -0,0 +1,79 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_datasetConversion(t *testing.T) {
+	testcases := []struct {
+		name string
+		key  string
+	}{
+		{"data-foo", "foo"},
+		{"data-foo-bar", "fooBar"},
+		{"data-foo--bar", "foo-Bar"},
+		{"data-foo-1", "foo-1"},
+		{"data-foo-", "foo-"},
+		{"data-", ""},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			key, ok := datasetKey(NewProperty(Value, tc.name, ""))
+			assert.True(ok)
+			assert.Equal(key, tc.key)
+			assert.Equal(datasetName(tc.key), tc.name)
+		})
+	}
+}
+
+func Test_Dataset(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, `<div id=x data-user-id=42 data-open data-id=1 data-user-id=7 data-api-url="/v1"></div>`)
+	dataset := document.firstChild.Dataset()
+
+	value, ok := dataset.Get("userId")
+	assert.True(ok)
+	assert.Equal(value, "42")
+
+	value, ok = dataset.Get("open")
+	assert.True(ok)
+	assert.Equal(value, "")
+
+	_, ok = dataset.Get("missing")
+	assert.False(ok)
+	_, ok = dataset.Get("user-id")
+	assert.False(ok)
+
+	assert.Equal(dataset.Keys(), []string{"userId", "open", "id", "apiUrl"})
+	assert.Equal(dataset.Len(), 4)
+
+	values := map[string]string{}
+	for key, value := range dataset.All() {
+		values[key] = value
+	}
+	assert.Equal(values, map[string]string{"userId": "42", "open": "", "id": "1", "apiUrl": "/v1"})
+
+	// Changes go through the property list
+	assert.Nil(dataset.Set("apiUrl", "/v2"))
+	assert.Nil(dataset.Set("open", "yes"))
+	assert.Nil(dataset.Set("pageSize", "20"))
+	assert.True(dataset.Delete("id"))
+	assert.False(dataset.Delete("id"))
+	assert.IsType(dataset.Set("page-size", "1"), &ElementInvalidDatasetKeyError{})
+	assert.IsType(dataset.Set("", "1"), &ElementInvalidDatasetKeyError{})
+	assert.IsType(dataset.Set("a b", "1"), &ElementInvalidDatasetKeyError{})
+	assert.IsType(dataset.Set("size ", "1"), &ElementInvalidDatasetKeyError{})
+	assert.IsType(dataset.Set("x=y", "1"), &ElementInvalidDatasetKeyError{})
+
+	assert.Equal(document.firstChild.Property("data-page-size").Value(), "20")
+	assert.Equal(renderHtml(t, document), `<div id="x" data-user-id="42" data-open="yes" data-api-url="/v2" data-page-size="20"></div>`)
+
+	// Properties added directly show up in the dataset
+	assert.Nil(document.firstChild.SetProperty("data-theme", "dark"))
+	value, _ = dataset.Get("theme")
+	assert.Equal(value, "dark")
+}
@@ -0,0 +1,301 @@
+package parseme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fueripe-desu/parseme/errors"
+)
+
+// ErrorData describes a problem found in a document. Name, Code and Fix
+// follow the conventions enforced by errors.ErrorPool so that every
+// diagnostic can be forwarded to the logger unchanged.
+type ErrorData struct {
+	Name    string
+	Message string
+	Code    string
+	Fix     string
+	Line    int
+	Column  int
+}
+
+// at fills in the message and position. Names and values taken from the
+// document may hold control characters, which the error pool rejects, so
+// they are written as escapes.
+func (d ErrorData) at(line int, column int, args ...any) ErrorData {
+	for i, arg := range args {
+		if value, ok := arg.(string); ok {
+			args[i] = escapeControls(value)
+		}
+	}
+
+	d.Message = fmt.Sprintf(d.Message, args...)
+	d.Line = line
+	d.Column = column
+	return d
+}
+
+func escapeControls(value string) string {
+	if !strings.ContainsFunc(value, unicode.IsControl) {
+		return value
+	}
+
+	builder := &strings.Builder{}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			builder.WriteString(strings.Trim(strconv.QuoteRune(r), "'"))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+func reportError(module string, data ErrorData) {
+	if !errors.IsLoggerInitialized() {
+		return
+	}
+
+	message := fmt.Sprintf("%v (line %v, column %v)", data.Message, data.Line, data.Column)
+	errors.SetLoggerModule(module)
+	errors.GetLogger().Error(errors.NewErrorData(data.Name, message, data.Code, data.Fix), nil)
+}
+
+func reportWarning(module string, data ErrorData) {
+	if !errors.IsLoggerInitialized() {
+		return
+	}
+
+	message := fmt.Sprintf("%v (line %v, column %v)", data.Message, data.Line, data.Column)
+	errors.SetLoggerModule(module)
+	errors.GetLogger().Warning(errors.NewErrorData(data.Name, message, data.Code, data.Fix), nil)
+}
+
+// XML well-formedness errors
+var (
+	xmlMismatchedTagError = ErrorData{
+		Name:    "Mismatched end tag",
+		Message: "Expected end tag '%v' but found '%v'.",
+		Code:    "X01",
+		Fix:     "Close elements in the reverse order in which they were opened.",
+	}
+	xmlUnexpectedEndTagError = ErrorData{
+		Name:    "Unexpected end tag",
+		Message: "End tag '%v' does not match any open element.",
+		Code:    "X02",
+		Fix:     "Remove the end tag or add the matching start tag.",
+	}
+	xmlUnclosedElementError = ErrorData{
+		Name:    "Unclosed element",
+		Message: "Element '%v' is never closed.",
+		Code:    "X03",
+		Fix:     "Add an end tag or use the self closing syntax.",
+	}
+	xmlMultipleRootsError = ErrorData{
+		Name:    "Multiple root elements",
+		Message: "Element '%v' appears after the root element.",
+		Code:    "X04",
+		Fix:     "Wrap the document in a single root element.",
+	}
+	xmlContentOutsideRootError = ErrorData{
+		Name:    "Content outside root",
+		Message: "Character data is not allowed outside the root element.",
+		Code:    "X05",
+		Fix:     "Move the text inside the root element.",
+	}
+	xmlMissingRootError = ErrorData{
+		Name:    "Missing root element",
+		Message: "Document has no root element.",
+		Code:    "X06",
+		Fix:     "Add a root element to the document.",
+	}
+	xmlUndefinedEntityError = ErrorData{
+		Name:    "Undefined entity",
+		Message: "Entity '&%v;' is not defined.",
+		Code:    "X07",
+		Fix:     "Use a numeric character reference or one of the predefined entities.",
+	}
+	xmlInvalidReferenceError = ErrorData{
+		Name:    "Invalid reference",
+		Message: "Character or entity reference '%v' is malformed.",
+		Code:    "X08",
+		Fix:     "Escape ampersands as '&amp;'.",
+	}
+	xmlDuplicateAttributeError = ErrorData{
+		Name:    "Duplicate attribute",
+		Message: "Attribute '%v' is specified more than once.",
+		Code:    "X09",
+		Fix:     "Remove the repeated attribute.",
+	}
+	xmlUnquotedAttributeError = ErrorData{
+		Name:    "Unquoted attribute",
+		Message: "Value of attribute '%v' must be quoted.",
+		Code:    "X10",
+		Fix:     "Enclose the attribute value in single or double quotes.",
+	}
+	xmlMissingAttributeValueError = ErrorData{
+		Name:    "Missing attribute value",
+		Message: "Attribute '%v' has no value.",
+		Code:    "X11",
+		Fix:     "Give the attribute an explicit value.",
+	}
+	xmlInvalidAttributeValueError = ErrorData{
+		Name:    "Invalid attribute value",
+		Message: "Value of attribute '%v' must not contain '<'.",
+		Code:    "X12",
+		Fix:     "Escape the character as '&lt;'.",
+	}
+	xmlUndeclaredPrefixError = ErrorData{
+		Name:    "Undeclared prefix",
+		Message: "Namespace prefix '%v' is not declared.",
+		Code:    "X13",
+		Fix:     "Declare the prefix with an xmlns attribute.",
+	}
+	xmlMisplacedDeclarationError = ErrorData{
+		Name:    "Misplaced declaration",
+		Message: "The XML declaration must appear at the very beginning of the document.",
+		Code:    "X14",
+		Fix:     "Move the declaration to the start of the document.",
+	}
+	xmlInvalidDeclarationError = ErrorData{
+		Name:    "Invalid declaration",
+		Message: "The XML declaration is malformed: %v.",
+		Code:    "X15",
+		Fix:     "Write the declaration as '<?xml version=\"1.0\"?>'.",
+	}
+	xmlReservedTargetError = ErrorData{
+		Name:    "Reserved instruction target",
+		Message: "Processing instruction target '%v' is reserved.",
+		Code:    "X16",
+		Fix:     "Choose a target that does not start with 'xml'.",
+	}
+	xmlUnterminatedError = ErrorData{
+		Name:    "Unterminated construct",
+		Message: "Unexpected end of input inside %v.",
+		Code:    "X17",
+		Fix:     "Terminate the construct before the end of the document.",
+	}
+	xmlInvalidNameError = ErrorData{
+		Name:    "Invalid name",
+		Message: "Name '%v' is not a valid XML name.",
+		Code:    "X18",
+		Fix:     "Names must start with a letter, underscore or colon.",
+	}
+	xmlInvalidCommentError = ErrorData{
+		Name:    "Invalid comment",
+		Message: "Comments must not contain '--'.",
+		Code:    "X19",
+		Fix:     "Remove the double hyphen from the comment.",
+	}
+	xmlUnescapedLessThanError = ErrorData{
+		Name:    "Unescaped less than",
+		Message: "Character '<' is not followed by a valid markup construct.",
+		Code:    "X20",
+		Fix:     "Escape the character as '&lt;'.",
+	}
+)
+
+// HTML parse errors
+var (
+	htmlInvalidAttributeNameError = ErrorData{
+		Name:    "Invalid attribute name",
+		Message: "Property name '%v' is not allowed by the attribute dialect.",
+		Code:    "H01",
+		Fix:     "Rename the property or select a dialect that allows it.",
+	}
+	htmlDuplicateAttributeError = ErrorData{
+		Name:    "Duplicate attribute",
+		Message: "Attribute '%v' is specified more than once.",
+		Code:    "H02",
+		Fix:     "Remove the repeated attribute.",
+	}
+)
+
+// Security audit warnings
+var (
+	securityJavascriptURLError = ErrorData{
+		Name:    "Javascript url",
+		Message: "Property '%v' of '%v' runs a javascript URL.",
+		Code:    "S01",
+		Fix:     "Attach the behavior with a script instead of a URL.",
+	}
+	securityDataURLError = ErrorData{
+		Name:    "Data url",
+		Message: "Property '%v' of '%v' embeds a data URL.",
+		Code:    "S02",
+		Fix:     "Serve the content from a URL of the same origin.",
+	}
+	securityEventHandlerError = ErrorData{
+		Name:    "Inline event handler",
+		Message: "Property '%v' of '%v' is an inline event handler.",
+		Code:    "S03",
+		Fix:     "Register the handler with addEventListener from a script.",
+	}
+	securityBlankTargetError = ErrorData{
+		Name:    "Unsafe blank target",
+		Message: "Element '%v' opens a new browsing context without 'noopener'.",
+		Code:    "S04",
+		Fix:     "Add 'noopener' to the rel property.",
+	}
+	securityInsecureFormError = ErrorData{
+		Name:    "Insecure form",
+		Message: "Element '%v' posts data over HTTP to '%v'.",
+		Code:    "S05",
+		Fix:     "Submit the form to an HTTPS URL.",
+	}
+	securityUnsandboxedFrameError = ErrorData{
+		Name:    "Unsandboxed frame",
+		Message: "Element 'iframe' has no sandbox property.",
+		Code:    "S06",
+		Fix:     "Add a sandbox property that grants only the permissions the frame needs.",
+	}
+	securitySrcdocError = ErrorData{
+		Name:    "Inline frame document",
+		Message: "Element 'iframe' defines its document with srcdoc.",
+		Code:    "S07",
+		Fix:     "Load the frame from a URL or sanitize the srcdoc markup.",
+	}
+)
+
+// HTML conformance errors
+var (
+	validatorFlowInPhrasingError = ErrorData{
+		Name:    "Invalid content",
+		Message: "Element '%v' is not allowed inside '%v', which only accepts phrasing content.",
+		Code:    "V01",
+		Fix:     "Close the parent before the element or use a phrasing element instead.",
+	}
+	validatorNestedInteractiveError = ErrorData{
+		Name:    "Nested interactive content",
+		Message: "Interactive element '%v' is not allowed inside '%v'.",
+		Code:    "V02",
+		Fix:     "Move the element out of its interactive ancestor.",
+	}
+	validatorMisplacedElementError = ErrorData{
+		Name:    "Misplaced element",
+		Message: "Element '%v' must be a child of %v but is inside '%v'.",
+		Code:    "V03",
+		Fix:     "Move the element into one of the parents its content model requires.",
+	}
+	validatorMissingAttributeError = ErrorData{
+		Name:    "Missing required attribute",
+		Message: "Element '%v' is missing the required property %v.",
+		Code:    "V04",
+		Fix:     "Add the property to the element.",
+	}
+	validatorInvalidValueError = ErrorData{
+		Name:    "Invalid attribute value",
+		Message: "Value '%v' of property '%v' on '%v' is not one of %v.",
+		Code:    "V05",
+		Fix:     "Use one of the keywords the attribute allows.",
+	}
+	validatorObsoleteElementError = ErrorData{
+		Name:    "Obsolete element",
+		Message: "Element '%v' is obsolete.",
+		Code:    "V06",
+		Fix:     "Replace the element with a conforming element or with CSS.",
+	}
+)
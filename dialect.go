@@ -0,0 +1,87 @@
+package parseme
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// AttributeDialect decides which property names are valid. Any function
+// with this signature can be used as a custom dialect.
+type AttributeDialect func(name string) bool
+
+var (
+	// StrictDialect only allows letters, digits, hyphens and underscores,
+	// and no leading digit. It is the default for standalone properties.
+	StrictDialect AttributeDialect = isValidProperty
+
+	// HtmlDialect allows every name the HTML standard allows: anything but
+	// controls, whitespace, quotes, '>', '/', '=' and noncharacters. It is
+	// the default for parsed documents.
+	HtmlDialect AttributeDialect = isHtmlAttributeName
+
+	// VueDialect adds directives such as v-on:submit.prevent, the @, : and #
+	// shorthands and dynamic arguments like :[key].
+	VueDialect AttributeDialect = frameworkDialect(vueAttributePattern)
+
+	// AlpineDialect adds x-* directives with their arguments and modifiers,
+	// and the @ and : shorthands.
+	AlpineDialect AttributeDialect = frameworkDialect(alpineAttributePattern)
+
+	// AngularDialect adds [property], (event), [(model)], *structural and
+	// #reference bindings and their bind-, on- and bindon- forms.
+	AngularDialect AttributeDialect = frameworkDialect(angularAttributePattern)
+
+	// HtmxDialect adds the hx-on:event and hx-on::event attributes.
+	HtmxDialect AttributeDialect = frameworkDialect(htmxAttributePattern)
+
+	// XmlDialect allows XML names. Documents parsed in XmlMode always use it.
+	XmlDialect AttributeDialect = isValidXmlName
+)
+
+var (
+	namespacedAttributePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*(:[a-zA-Z_][a-zA-Z0-9_-]*)?$`)
+	vueAttributePattern        = regexp.MustCompile(`^(v-[a-z0-9-]+(:(\[[^\]\s]+\]|[a-zA-Z0-9_:-]+))?|[@:#](\[[^\]\s]+\]|[a-zA-Z0-9_:-]+)|\.[a-zA-Z0-9_-]+)(\.[a-zA-Z0-9_-]+)*$`)
+	alpineAttributePattern     = regexp.MustCompile(`^(x-[a-z0-9-]+(:[a-zA-Z0-9_:-]+)?|[@:][a-zA-Z0-9_:-]+)(\.[a-zA-Z0-9_-]+)*$`)
+	angularAttributePattern    = regexp.MustCompile(`^(\[\([a-zA-Z0-9_.-]+\)\]|\[[a-zA-Z0-9_.-]+\]|\([a-zA-Z0-9_.:-]+\)|\*[a-zA-Z][a-zA-Z0-9_]*|#[a-zA-Z][a-zA-Z0-9_-]*|(bind|on|bindon)-[a-zA-Z0-9_-]+)$`)
+	htmxAttributePattern       = regexp.MustCompile(`^(data-)?hx-on(:|::)[a-zA-Z0-9_.:-]+$`)
+)
+
+// frameworkDialect accepts plain and namespaced names, such as xlink:href,
+// along with the names matching the framework syntax.
+func frameworkDialect(pattern *regexp.Regexp) AttributeDialect {
+	return func(name string) bool {
+		return namespacedAttributePattern.MatchString(name) || pattern.MatchString(name)
+	}
+}
+
+// CombineDialects returns a dialect that accepts the names accepted by any
+// of the given dialects, for templates that mix frameworks.
+func CombineDialects(dialects ...AttributeDialect) AttributeDialect {
+	return func(name string) bool {
+		for _, dialect := range dialects {
+			if dialect(name) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isHtmlAttributeName(name string) bool {
+	if name == "" || !utf8.ValidString(name) {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r <= 0x1f, r >= 0x7f && r <= 0x9f:
+			return false
+		case r == ' ', r == '"', r == '\'', r == '>', r == '/', r == '=':
+			return false
+		case r >= 0xfdd0 && r <= 0xfdef, r&0xfffe == 0xfffe:
+			return false
+		}
+	}
+
+	return true
+}
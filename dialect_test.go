@@ -0,0 +1,129 @@
+package parseme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dialects(t *testing.T) {
+	testcases := []struct {
+		name    string
+		dialect AttributeDialect
+		valid   []string
+		invalid []string
+	}{
+		{
+			"strict",
+			StrictDialect,
+			[]string{"href", "data-id", "_x"},
+			[]string{"1x", "@click", "xlink:href"},
+		},
+		{
+			"html",
+			HtmlDialect,
+			[]string{"href", "1x", "@click", ":href", "[value]", "(click)", "#ref", "x.y", "é"},
+			[]string{"", "a b", "a=b", "a/b", "a\"b", "a'b", "a>b", "a\x01b", "a﷐"},
+		},
+		{
+			"vue",
+			VueDialect,
+			[]string{"href", "xlink:href", "v-if", "v-on:submit.prevent", "v-bind:[key]", "@click.stop", ":href", "#default", ".prop"},
+			[]string{"[value]", "(click)", "@", "v-on:", "1x"},
+		},
+		{
+			"alpine",
+			AlpineDialect,
+			[]string{"x-data", "x-on:click.outside", "x-bind:class", "@keyup.enter", ":class"},
+			[]string{"#ref", "[value]", "x-on:"},
+		},
+		{
+			"angular",
+			AngularDialect,
+			[]string{"[value]", "(click)", "[(ngModel)]", "*ngIf", "#ref", "[attr.aria-label]", "bind-value", "on-click", "bindon-model"},
+			[]string{"@click", "[value", "(click]", "*1"},
+		},
+		{
+			"htmx",
+			HtmxDialect,
+			[]string{"hx-get", "hx-on:click", "hx-on::after-request", "hx-on:htmx:before-request", "data-hx-on:click"},
+			[]string{"@click", "hx-on:", ":href"},
+		},
+		{
+			"xml",
+			XmlDialect,
+			[]string{"xml:lang", "a.b", "_x"},
+			[]string{"1x", "@click"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			for _, name := range tc.valid {
+				assert.True(tc.dialect(name), name)
+			}
+			for _, name := range tc.invalid {
+				assert.False(tc.dialect(name), name)
+			}
+		})
+	}
+}
+
+func Test_CombineDialects(t *testing.T) {
+	assert := assert.New(t)
+	dialect := CombineDialects(VueDialect, HtmxDialect)
+
+	assert.True(dialect("@click"))
+	assert.True(dialect("hx-on::load"))
+	assert.False(dialect("[value]"))
+
+	// Custom validators are plain functions
+	custom := CombineDialects(StrictDialect, func(name string) bool { return strings.HasPrefix(name, "$") })
+	assert.True(custom("$ref"))
+	assert.False(custom("@ref"))
+}
+
+func Test_SetDialect(t *testing.T) {
+	assert := assert.New(t)
+	property := NewProperty(Value, "href", "/")
+
+	assert.IsType(property.SetName("@click"), &PropertyInvalidNameError{})
+
+	property.SetDialect(VueDialect)
+	assert.Nil(property.SetName("@click"))
+	assert.Equal(property.Name(), "@click")
+}
+
+func Test_parseDialect(t *testing.T) {
+	input := `<form @submit.prevent="save" v-on:reset="clear" [value]="x"><input :value="name"></form>`
+
+	// Parsed documents accept every name HTML allows by default
+	parser := &HtmlParser{}
+	bytes := []byte(input)
+	document, err := parser.ParseBytes(bytes)
+	assert.Nil(t, err)
+	assert.Empty(t, parser.Errors())
+	assert.Equal(t, parser.Dialect()("@x"), true)
+
+	form := document.firstChild
+	assert.Equal(t, form.Property("@submit.prevent").Value(), "save")
+	assert.Nil(t, form.Property("v-on:reset").SetName(":reset"))
+	assert.Nil(t, form.SetProperty("#ref", "form"))
+
+	// A framework dialect reports the names it does not allow
+	parser.SetDialect(VueDialect)
+	bytes = []byte(input)
+	document, err = parser.ParseBytes(bytes)
+	assert.Nil(t, err)
+	assert.Len(t, parser.Errors(), 1)
+	assert.Equal(t, parser.Errors()[0].Code, "H01")
+	assert.Equal(t, parser.Errors()[0].Column, 49)
+
+	assert.IsType(t, document.firstChild.SetProperty("(click)", "go()"), &PropertyInvalidNameError{})
+
+	// Detached elements use the strict dialect
+	element := NewElement(TagElement, "div", "")
+	assert.IsType(t, element.SetProperty("@click", "go()"), &PropertyInvalidNameError{})
+}
@@ -0,0 +1,203 @@
+package parseme
+
+import "strings"
+
+type ElementType int
+
+const (
+	DocumentElement ElementType = iota
+	TagElement
+	TextElement
+	CommentElement
+	DoctypeElement
+	DeclarationElement
+	InstructionElement
+	CDATAElement
+)
+
+type Element struct {
+	elementType ElementType
+	name        string
+	prefix      string
+	namespace   string
+	value       string
+	properties  []*Property
+	// index maps property names to properties. It is rebuilt whenever a
+	// property is added, removed or renamed, so that lookups never write
+	// and parsed trees can be read concurrently.
+	index map[string]*Property
+
+	parent      *Element
+	firstChild  *Element
+	lastChild   *Element
+	prevSibling *Element
+	nextSibling *Element
+
+	line   int
+	column int
+
+	source *elementSource
+
+	// mode and dialect are only set on documents and record how they were
+	// parsed
+	mode    ParserMode
+	dialect AttributeDialect
+}
+
+func (e *Element) Type() ElementType {
+	return e.elementType
+}
+
+// Name returns the qualified name of a tag as written in the source,
+// the target of a processing instruction or the root name of a doctype.
+func (e *Element) Name() string {
+	return e.name
+}
+
+func (e *Element) Prefix() string {
+	return e.prefix
+}
+
+func (e *Element) LocalName() string {
+	if e.prefix == "" {
+		return e.name
+	}
+
+	return e.name[len(e.prefix)+1:]
+}
+
+func (e *Element) Namespace() string {
+	return e.namespace
+}
+
+// Value returns the character data of text, comment, CDATA and processing
+// instruction nodes, and the identifiers that follow the root name of a
+// doctype.
+func (e *Element) Value() string {
+	return e.value
+}
+
+func (e *Element) Properties() []*Property {
+	return e.properties
+}
+
+// Property returns the first property with the given name. Names of HTML
+// elements are matched ignoring ASCII case, as browsers do.
+func (e *Element) Property(name string) *Property {
+	if e.index != nil {
+		if property := e.index[name]; property != nil {
+			return property
+		}
+	}
+
+	html := !e.isXml()
+	for _, property := range e.properties {
+		if property.name == name || (html && strings.EqualFold(property.name, name)) {
+			return property
+		}
+	}
+
+	return nil
+}
+
+func (e *Element) indexProperties() {
+	e.index = make(map[string]*Property, len(e.properties))
+	for _, property := range e.properties {
+		property.owner = e
+		if _, ok := e.index[property.name]; !ok {
+			e.index[property.name] = property
+		}
+	}
+}
+
+func (e *Element) Parent() *Element {
+	return e.parent
+}
+
+func (e *Element) FirstChild() *Element {
+	return e.firstChild
+}
+
+func (e *Element) LastChild() *Element {
+	return e.lastChild
+}
+
+func (e *Element) NextSibling() *Element {
+	return e.nextSibling
+}
+
+func (e *Element) PrevSibling() *Element {
+	return e.prevSibling
+}
+
+// Children returns the tag children of the element, leaving out text,
+// comments and every other kind of node.
+func (e *Element) Children() []*Element {
+	children := []*Element{}
+	for child := e.firstChild; child != nil; child = child.nextSibling {
+		if child.elementType == TagElement {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func (e *Element) ChildNodes() []*Element {
+	nodes := []*Element{}
+	for child := e.firstChild; child != nil; child = child.nextSibling {
+		nodes = append(nodes, child)
+	}
+	return nodes
+}
+
+func (e *Element) Line() int {
+	return e.line
+}
+
+func (e *Element) Column() int {
+	return e.column
+}
+
+func (e *Element) appendChild(child *Element) {
+	child.parent = e
+	child.prevSibling = e.lastChild
+	child.nextSibling = nil
+
+	if e.lastChild != nil {
+		e.lastChild.nextSibling = child
+	} else {
+		e.firstChild = child
+	}
+
+	e.lastChild = child
+}
+
+// isXml reports whether the element belongs to a document parsed in
+// XmlMode, where names are case-sensitive.
+func (e *Element) isXml() bool {
+	root := e
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	return root.elementType == DocumentElement && root.mode == XmlMode
+}
+
+// attributeDialect returns the dialect of the document the element belongs
+// to, or StrictDialect for detached elements.
+func (e *Element) attributeDialect() AttributeDialect {
+	root := e
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	if root.dialect == nil {
+		return StrictDialect
+	}
+
+	return root.dialect
+}
+
+func newDocument() *Element {
+	return &Element{elementType: DocumentElement, line: 1, column: 1}
+}
@@ -0,0 +1,117 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LocalName(t *testing.T) {
+	testcases := []struct {
+		name     string
+		element  *Element
+		expected string
+	}{
+		{
+			"unprefixed name",
+			&Element{elementType: TagElement, name: "svg"},
+			"svg",
+		},
+		{
+			"prefixed name",
+			&Element{elementType: TagElement, name: "svg:rect", prefix: "svg"},
+			"rect",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tc.element.LocalName(), tc.expected)
+		})
+	}
+}
+
+func Test_Property(t *testing.T) {
+	element := &Element{
+		elementType: TagElement,
+		name:        "a",
+		properties:  []*Property{NewProperty(Value, "href", "/"), NewProperty(Boolean, "download", "true")},
+	}
+
+	testcases := []struct {
+		name         string
+		propertyName string
+		expected     *Property
+	}{
+		{"value property", "href", element.properties[0]},
+		{"boolean property", "download", element.properties[1]},
+		{"missing property", "target", nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(element.Property(tc.propertyName), tc.expected)
+		})
+	}
+}
+
+func Test_PropertyIndex(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<a href=/ title=t></a>")
+	element := document.firstChild
+	href := element.Property("href")
+
+	// Renamed, added and removed properties are found under their new names
+	assert.Nil(href.SetName("src"))
+	assert.Nil(element.Property("href"))
+	assert.Equal(element.Property("src"), href)
+
+	assert.Nil(element.SetProperty("rel", "next"))
+	assert.Equal(element.Property("rel").Value(), "next")
+
+	assert.True(element.RemoveProperty("title"))
+	assert.Nil(element.Property("title"))
+
+	assert.Nil(href.SetName("href"))
+	assert.Equal(element.Property("href"), href)
+}
+
+func Test_PropertyConcurrentReads(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<a href=/ title=t></a>")
+	element := document.firstChild
+
+	// Lookups must not write to the element, which go test -race checks
+	results := make(chan *Property)
+	for range 4 {
+		go func() {
+			results <- element.Property("title")
+		}()
+	}
+
+	for range 4 {
+		assert.Equal(<-results, element.properties[1])
+	}
+}
+
+func Test_appendChild(t *testing.T) {
+	assert := assert.New(t)
+	parent := &Element{elementType: TagElement, name: "ul"}
+	first := &Element{elementType: TagElement, name: "li"}
+	second := &Element{elementType: TagElement, name: "li"}
+
+	parent.appendChild(first)
+	parent.appendChild(second)
+
+	assert.Equal(parent.ChildNodes(), []*Element{first, second})
+	assert.Equal(first.Parent(), parent)
+	assert.Equal(second.Parent(), parent)
+	assert.Nil(first.prevSibling)
+	assert.Equal(first.nextSibling, second)
+	assert.Equal(second.prevSibling, first)
+	assert.Nil(second.nextSibling)
+	assert.Equal(parent.firstChild, first)
+	assert.Equal(parent.lastChild, second)
+}
@@ -1,6 +1,9 @@
 package parseme
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type FileError struct {
 	Message  string
@@ -90,7 +93,247 @@ type PropertyInvalidBooleanError struct {
 }
 
 func (e *PropertyInvalidBooleanError) Error() string {
-	msg := fmt.Sprintf("Boolean property value must be either 'true' or 'false'. Instead got: '%v'", e.value)
+	msg := fmt.Sprintf("Boolean property value must be 'true', 'false', empty or the property name. Instead got: '%v'", e.value)
 	err := PropertyError{Message: msg}
 	return err.Error()
 }
+
+type PropertyIntValueError struct {
+	value string
+}
+
+func (e *PropertyIntValueError) Error() string {
+	msg := fmt.Sprintf("Cannot get integer value of '%v'.", e.value)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type PropertyFloatValueError struct {
+	value string
+}
+
+func (e *PropertyFloatValueError) Error() string {
+	msg := fmt.Sprintf("Cannot get floating-point value of '%v'.", e.value)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type PropertyLengthValueError struct {
+	value string
+}
+
+func (e *PropertyLengthValueError) Error() string {
+	msg := fmt.Sprintf("Cannot get length value of '%v'.", e.value)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type PropertyURLValueError struct {
+	value string
+	cause error
+}
+
+func (e *PropertyURLValueError) Error() string {
+	msg := fmt.Sprintf("Cannot get URL value of '%v': %v", e.value, e.cause)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+func (e *PropertyURLValueError) Unwrap() error {
+	return e.cause
+}
+
+type PropertyEnumValueError struct {
+	value   string
+	allowed []string
+}
+
+func (e *PropertyEnumValueError) Error() string {
+	msg := fmt.Sprintf("The value '%v' is not one of '%v'.", e.value, strings.Join(e.allowed, "', '"))
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type PropertyEmptyTokenError struct{}
+
+func (e *PropertyEmptyTokenError) Error() string {
+	err := PropertyError{Message: "Token must not be empty."}
+	return err.Error()
+}
+
+type PropertyInvalidTokenError struct {
+	token string
+}
+
+func (e *PropertyInvalidTokenError) Error() string {
+	msg := fmt.Sprintf("The token '%v' must not contain whitespace.", e.token)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type PropertyInvalidCssPropertyError struct {
+	property string
+}
+
+func (e *PropertyInvalidCssPropertyError) Error() string {
+	msg := fmt.Sprintf("The CSS property name '%v' is invalid.", e.property)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type PropertyInvalidCssValueError struct {
+	property string
+	value    string
+}
+
+func (e *PropertyInvalidCssValueError) Error() string {
+	msg := fmt.Sprintf("The value '%v' of CSS property '%v' is empty or not a single value.", e.value, e.property)
+	err := PropertyError{Message: msg}
+	return err.Error()
+}
+
+type XmlWellFormednessError struct {
+	Data ErrorData
+}
+
+func (e *XmlWellFormednessError) Error() string {
+	return fmt.Sprintf("Document is not well-formed at line %v, column %v: %v", e.Data.Line, e.Data.Column, e.Data.Message)
+}
+
+type DuplicateAttributeError struct {
+	Data ErrorData
+}
+
+func (e *DuplicateAttributeError) Error() string {
+	return fmt.Sprintf("Duplicate attribute at line %v, column %v: %v", e.Data.Line, e.Data.Column, e.Data.Message)
+}
+
+type SelectorSyntaxError struct {
+	selector string
+	position int
+	message  string
+}
+
+func (e *SelectorSyntaxError) Error() string {
+	return fmt.Sprintf("Invalid selector '%v' at position %v: %v.", e.selector, e.position, e.message)
+}
+
+type XPathSyntaxError struct {
+	expression string
+	position   int
+	message    string
+}
+
+func (e *XPathSyntaxError) Error() string {
+	return fmt.Sprintf("Invalid XPath expression '%v' at position %v: %v.", e.expression, e.position, e.message)
+}
+
+type XPathEvaluationError struct {
+	expression string
+	message    string
+}
+
+func (e *XPathEvaluationError) Error() string {
+	return fmt.Sprintf("Cannot evaluate XPath expression '%v': %v.", e.expression, e.message)
+}
+
+// StylesheetLoadError lists the linked stylesheets that could not be read.
+type StylesheetLoadError struct {
+	Errors []error
+}
+
+func (e *StylesheetLoadError) Error() string {
+	messages := []string{}
+	for _, err := range e.Errors {
+		messages = append(messages, strings.TrimSpace(err.Error()))
+	}
+
+	return fmt.Sprintf("Cannot read %v linked stylesheets: %v", len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *StylesheetLoadError) Unwrap() []error {
+	return e.Errors
+}
+
+// Element error
+type ElementError struct {
+	Message string
+}
+
+func (e *ElementError) Error() string {
+	return e.Message
+}
+
+type ElementCycleError struct{}
+
+func (e *ElementCycleError) Error() string {
+	err := ElementError{Message: "Cannot insert an element into itself or into one of its descendants."}
+	return err.Error()
+}
+
+type ElementInUseError struct{}
+
+func (e *ElementInUseError) Error() string {
+	err := ElementError{Message: "Element already has a parent and must be removed from it first."}
+	return err.Error()
+}
+
+type ElementVoidChildError struct {
+	name string
+}
+
+func (e *ElementVoidChildError) Error() string {
+	msg := fmt.Sprintf("The void element '%v' cannot have children.", e.name)
+	err := ElementError{Message: msg}
+	return err.Error()
+}
+
+type ElementNotContainerError struct{}
+
+func (e *ElementNotContainerError) Error() string {
+	err := ElementError{Message: "Only documents and tag elements can have children."}
+	return err.Error()
+}
+
+type ElementDocumentChildError struct{}
+
+func (e *ElementDocumentChildError) Error() string {
+	err := ElementError{Message: "A document cannot be the child of another element."}
+	return err.Error()
+}
+
+type ElementNotChildError struct{}
+
+func (e *ElementNotChildError) Error() string {
+	err := ElementError{Message: "The given element is not a child of this element."}
+	return err.Error()
+}
+
+type ElementDetachedError struct{}
+
+func (e *ElementDetachedError) Error() string {
+	err := ElementError{Message: "Element must have a parent."}
+	return err.Error()
+}
+
+type ElementInvalidDatasetKeyError struct {
+	key string
+}
+
+func (e *ElementInvalidDatasetKeyError) Error() string {
+	msg := fmt.Sprintf("The dataset key '%v' must not be empty, contain a hyphen followed by a lowercase letter or make an invalid property name.", e.key)
+	err := ElementError{Message: msg}
+	return err.Error()
+}
+
+type MarkdownUnsupportedElementError struct {
+	name   string
+	line   int
+	column int
+}
+
+func (e *MarkdownUnsupportedElementError) Error() string {
+	msg := fmt.Sprintf("Element '%v' at line %v, column %v cannot be written as Markdown.", e.name, e.line, e.column)
+	err := ElementError{Message: msg}
+	return err.Error()
+}
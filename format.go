@@ -0,0 +1,342 @@
+package parseme
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type QuoteStyle int
+
+const (
+	DoubleQuotes QuoteStyle = iota
+	SingleQuotes
+)
+
+type AttributeWrap int
+
+const (
+	// WrapAuto puts each attribute on its own line when the start tag would
+	// exceed the maximum line width.
+	WrapAuto AttributeWrap = iota
+	WrapNever
+	WrapAlways
+)
+
+type FormatOptions struct {
+	IndentWidth   int
+	UseTabs       bool
+	MaxLineWidth  int
+	AttributeWrap AttributeWrap
+	QuoteStyle    QuoteStyle
+}
+
+func NewFormatOptions() *FormatOptions {
+	return &FormatOptions{
+		IndentWidth:   2,
+		MaxLineWidth:  80,
+		AttributeWrap: WrapAuto,
+		QuoteStyle:    DoubleQuotes,
+	}
+}
+
+type formatter struct {
+	options *FormatOptions
+	output  *renderer
+}
+
+// Format writes the node indented one block element per line. Runs of
+// inline content and the contents of preformatted elements are written
+// exactly as they are rendered, except for whitespace at the edges of a
+// run. Runs with lines longer than MaxLineWidth are wrapped at their
+// whitespace, outside preformatted elements. Formatting a formatted
+// document does not change it.
+func Format(w io.Writer, node *Element, options *FormatOptions) error {
+	if options == nil {
+		options = NewFormatOptions()
+	}
+
+	f := &formatter{options: options, output: &renderer{writer: w, quote: options.QuoteStyle}}
+
+	if node.elementType == DocumentElement {
+		f.formatChildren(node, 0)
+	} else {
+		f.formatNodes([]*Element{node}, 0)
+	}
+
+	return f.output.err
+}
+
+func (f *formatter) indent(depth int) string {
+	if f.options.UseTabs {
+		return strings.Repeat("\t", depth)
+	}
+
+	return strings.Repeat(" ", depth*f.options.IndentWidth)
+}
+
+func (f *formatter) width(value string) int {
+	if !f.options.UseTabs {
+		return utf8.RuneCountInString(value)
+	}
+
+	tabs := strings.Count(value, "\t")
+	return utf8.RuneCountInString(value) + tabs*(f.options.IndentWidth-1)
+}
+
+func (f *formatter) render(nodes ...*Element) string {
+	buffer := &bytes.Buffer{}
+	r := &renderer{writer: buffer, quote: f.options.QuoteStyle}
+	for _, node := range nodes {
+		r.render(node)
+	}
+	return buffer.String()
+}
+
+func (f *formatter) formatChildren(node *Element, depth int) {
+	f.formatNodes(node.ChildNodes(), depth)
+}
+
+// formatNodes writes every block node on its own line and groups the nodes
+// between them into inline runs.
+func (f *formatter) formatNodes(nodes []*Element, depth int) {
+	run := []*Element{}
+
+	for _, node := range nodes {
+		if !isBlockNode(node) {
+			run = append(run, node)
+			continue
+		}
+
+		f.formatRun(run, depth)
+		run = run[:0]
+		f.formatBlock(node, depth)
+	}
+
+	f.formatRun(run, depth)
+}
+
+func (f *formatter) formatRun(run []*Element, depth int) {
+	content := trimSpaces(f.render(run...))
+	if content == "" {
+		return
+	}
+
+	f.output.write(f.wrap(f.indent(depth), content, "", run, depth), "\n")
+}
+
+// wrap writes the content between prefix and suffix, breaking it at
+// whitespace when a line would exceed the maximum line width. Continuation
+// lines are indented at depth.
+func (f *formatter) wrap(prefix string, content string, suffix string, nodes []*Element, depth int) string {
+	if f.fits(prefix + content + suffix) {
+		return prefix + content + suffix
+	}
+
+	collector := &wordCollector{formatter: f}
+	collector.collect(nodes)
+	collector.flush()
+	words := collector.words
+	if collector.multiline || len(words) == 0 {
+		return prefix + content + suffix
+	}
+
+	builder := &strings.Builder{}
+	builder.WriteString(prefix)
+	start := f.width(prefix[strings.LastIndexByte(prefix, '\n')+1:])
+	column := start
+
+	for i, word := range words {
+		width := f.width(word)
+		if i == len(words)-1 {
+			width += f.width(suffix)
+		}
+
+		switch {
+		case column == start:
+		case column+1+width > f.options.MaxLineWidth:
+			builder.WriteString("\n" + f.indent(depth))
+			start = f.width(f.indent(depth))
+			column = start
+		default:
+			builder.WriteString(" ")
+			column++
+		}
+
+		builder.WriteString(word)
+		column += f.width(word)
+	}
+
+	builder.WriteString(suffix)
+	return builder.String()
+}
+
+func (f *formatter) fits(value string) bool {
+	if f.options.MaxLineWidth <= 0 {
+		return true
+	}
+
+	for _, line := range strings.Split(value, "\n") {
+		if f.width(line) > f.options.MaxLineWidth {
+			return false
+		}
+	}
+
+	return true
+}
+
+// wordCollector splits rendered nodes at the whitespace of their text.
+// Tags stick to the text around them, and preformatted elements, comments
+// and CDATA sections are kept whole. Runs where one of them spans several
+// lines cannot be measured and are not split.
+type wordCollector struct {
+	formatter *formatter
+	words     []string
+	word      strings.Builder
+	multiline bool
+}
+
+func (c *wordCollector) flush() {
+	if c.word.Len() > 0 {
+		c.words = append(c.words, c.word.String())
+		c.word.Reset()
+	}
+}
+
+func (c *wordCollector) collect(nodes []*Element) {
+	for _, node := range nodes {
+		switch {
+		case node.elementType == TextElement:
+			value := node.value
+			if parent := node.parent; parent == nil || parent.elementType != TagElement || !rawTextElements[parent.name] {
+				value = textEscaper.Replace(value)
+			}
+
+			for _, r := range value {
+				if isHtmlSpace(r) {
+					c.flush()
+				} else {
+					c.word.WriteRune(r)
+				}
+			}
+		case node.elementType == TagElement && !node.isVoid() && !preformattedElements[node.name]:
+			buffer := &bytes.Buffer{}
+			r := &renderer{writer: buffer, quote: c.formatter.options.QuoteStyle}
+			r.renderStartTag(node)
+			c.word.WriteString(buffer.String())
+			c.collect(node.ChildNodes())
+			c.word.WriteString("</" + node.name + ">")
+		default:
+			rendered := c.formatter.render(node)
+			c.multiline = c.multiline || strings.Contains(rendered, "\n")
+			c.word.WriteString(rendered)
+		}
+	}
+}
+
+func isHtmlSpace(r rune) bool {
+	return strings.ContainsRune(" \t\r\n\f", r)
+}
+
+func (f *formatter) formatBlock(node *Element, depth int) {
+	if node.elementType != TagElement {
+		f.output.write(f.indent(depth), f.render(node), "\n")
+		return
+	}
+
+	tag := f.startTag(node, depth)
+
+	if node.isVoid() {
+		f.output.write(tag, "\n")
+		return
+	}
+
+	if preformattedElements[node.name] {
+		f.output.write(tag, f.render(node.ChildNodes()...), "</", node.name, ">\n")
+		return
+	}
+
+	if !hasBlockChild(node) {
+		children := node.ChildNodes()
+		content := trimSpaces(f.render(children...))
+		f.output.write(f.wrap(tag, content, "</"+node.name+">", children, depth+1), "\n")
+		return
+	}
+
+	f.output.write(tag, "\n")
+	f.formatChildren(node, depth+1)
+	f.output.write(f.indent(depth), "</", node.name, ">\n")
+}
+
+// startTag returns the indented start tag, with its attributes on lines
+// of their own when they are wrapped.
+func (f *formatter) startTag(node *Element, depth int) string {
+	buffer := &bytes.Buffer{}
+	r := &renderer{writer: buffer, quote: f.options.QuoteStyle}
+	r.renderStartTag(node)
+	tag := f.indent(depth) + buffer.String()
+
+	if !f.shouldWrap(node, tag) {
+		return tag
+	}
+
+	builder := &strings.Builder{}
+	builder.WriteString(f.indent(depth) + "<" + node.name)
+	for _, property := range node.properties {
+		buffer.Reset()
+		r.renderProperty(property)
+		if buffer.Len() > 0 {
+			builder.WriteString("\n" + f.indent(depth+1) + buffer.String()[1:])
+		}
+	}
+	builder.WriteString(">")
+	return builder.String()
+}
+
+func (f *formatter) shouldWrap(node *Element, tag string) bool {
+	if len(node.properties) < 2 {
+		return false
+	}
+
+	switch f.options.AttributeWrap {
+	case WrapAlways:
+		return true
+	case WrapAuto:
+		return f.options.MaxLineWidth > 0 && f.width(tag) > f.options.MaxLineWidth
+	}
+
+	return false
+}
+
+// isBlockNode reports whether a node starts a line of its own when
+// formatted. Inline elements containing block content are laid out as
+// blocks as well.
+func isBlockNode(node *Element) bool {
+	switch node.elementType {
+	case TextElement, CommentElement, CDATAElement:
+		return false
+	case TagElement:
+		return !inlineElements[node.name] || hasBlockChild(node)
+	}
+
+	return true
+}
+
+func hasBlockChild(node *Element) bool {
+	if preformattedElements[node.name] {
+		return false
+	}
+
+	for child := node.firstChild; child != nil; child = child.nextSibling {
+		if isBlockNode(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func trimSpaces(value string) string {
+	return strings.Trim(value, " \t\r\n\f")
+}
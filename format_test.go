@@ -0,0 +1,175 @@
+package parseme
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Format(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		options  *FormatOptions
+		expected string
+	}{
+		{
+			"nested blocks",
+			"<div><div><p>text</p></div></div>",
+			NewFormatOptions(),
+			"<div>\n  <div>\n    <p>text</p>\n  </div>\n</div>\n",
+		},
+		{
+			"indent with tabs",
+			"<ul><li>a</li></ul>",
+			&FormatOptions{UseTabs: true, IndentWidth: 4},
+			"<ul>\n\t<li>a</li>\n</ul>\n",
+		},
+		{
+			"indent width",
+			"<ul><li>a</li></ul>",
+			&FormatOptions{IndentWidth: 4},
+			"<ul>\n    <li>a</li>\n</ul>\n",
+		},
+		{
+			"inline run is untouched",
+			"<div>\n   Hello   <b>big</b>  <i>world</i>!\n</div>",
+			NewFormatOptions(),
+			"<div>Hello   <b>big</b>  <i>world</i>!</div>\n",
+		},
+		{
+			"inline runs between blocks",
+			"<div>a <b>b</b><p>c</p>d</div>",
+			NewFormatOptions(),
+			"<div>\n  a <b>b</b>\n  <p>c</p>\n  d\n</div>\n",
+		},
+		{
+			"inline element with block content",
+			"<a href=x><div>a</div></a>",
+			NewFormatOptions(),
+			"<a href=\"x\">\n  <div>a</div>\n</a>\n",
+		},
+		{
+			"preformatted content is untouched",
+			"<div><pre>\n  a\n    b  </pre></div>",
+			NewFormatOptions(),
+			"<div>\n  <pre>\n  a\n    b  </pre>\n</div>\n",
+		},
+		{
+			"textarea content is untouched",
+			"<div><textarea>  a\n b </textarea></div>",
+			NewFormatOptions(),
+			"<div><textarea>  a\n b </textarea></div>\n",
+		},
+		{
+			"void elements",
+			"<head><meta charset=utf-8><link rel=icon></head>",
+			NewFormatOptions(),
+			"<head>\n  <meta charset=\"utf-8\">\n  <link rel=\"icon\">\n</head>\n",
+		},
+		{
+			"doctype and comments",
+			"<!doctype html>\n<!-- a --><html></html>",
+			NewFormatOptions(),
+			"<!DOCTYPE html>\n<!-- a -->\n<html></html>\n",
+		},
+		{
+			"single quotes",
+			"<a title=\"it's\" href=x>a</a>",
+			&FormatOptions{QuoteStyle: SingleQuotes},
+			"<a title='it&#39;s' href='x'>a</a>\n",
+		},
+		{
+			"wrap attributes beyond line width",
+			"<div id=main class=\"a b c\"></div>",
+			&FormatOptions{IndentWidth: 2, MaxLineWidth: 20},
+			"<div\n  id=\"main\"\n  class=\"a b c\"></div>\n",
+		},
+		{
+			"never wrap attributes",
+			"<div id=main class=\"a b c\"></div>",
+			&FormatOptions{IndentWidth: 2, MaxLineWidth: 20, AttributeWrap: WrapNever},
+			"<div id=\"main\" class=\"a b c\"></div>\n",
+		},
+		{
+			"always wrap attributes",
+			"<form action=x novalidate></form>",
+			&FormatOptions{IndentWidth: 2, AttributeWrap: WrapAlways},
+			"<form\n  action=\"x\"\n  novalidate></form>\n",
+		},
+		{
+			"wrap text beyond line width",
+			"<div><p>one two three four five six</p>seven <b>eight nine</b> ten<pre>a b c d e f g h i j</pre></div>",
+			&FormatOptions{IndentWidth: 2, MaxLineWidth: 16},
+			"<div>\n  <p>one two\n    three four\n    five six</p>\n  seven <b>eight\n  nine</b> ten\n  <pre>a b c d e f g h i j</pre>\n</div>\n",
+		},
+		{
+			"long words are not split",
+			"<p>abcdefghijklmnop q</p>",
+			&FormatOptions{IndentWidth: 2, MaxLineWidth: 8},
+			"<p>abcdefghijklmnop\n  q</p>\n",
+		},
+		{
+			"title content is untouched",
+			"<head><title>  a   b  </title></head>",
+			&FormatOptions{IndentWidth: 2, MaxLineWidth: 8},
+			"<head>\n  <title>  a   b  </title>\n</head>\n",
+		},
+		{
+			"single attribute is never wrapped",
+			"<div class=\"a very long list of classes\"></div>",
+			&FormatOptions{IndentWidth: 2, MaxLineWidth: 10},
+			"<div class=\"a very long list of classes\"></div>\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			buffer := &bytes.Buffer{}
+			err := Format(buffer, document, tc.options)
+			assert.Nil(err)
+			assert.Equal(buffer.String(), tc.expected)
+		})
+	}
+}
+
+func Test_FormatIdempotent(t *testing.T) {
+	testcases := []struct {
+		name    string
+		options *FormatOptions
+	}{
+		{"default options", NewFormatOptions()},
+		{"tabs", &FormatOptions{UseTabs: true, IndentWidth: 8, MaxLineWidth: 40}},
+		{"narrow lines", &FormatOptions{IndentWidth: 4, MaxLineWidth: 20}},
+		{"single quotes", &FormatOptions{IndentWidth: 1, QuoteStyle: SingleQuotes, AttributeWrap: WrapAlways}},
+	}
+
+	input, _ := os.ReadFile("test_data/example4.html")
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, string(input))
+			once := &bytes.Buffer{}
+			Format(once, document, tc.options)
+
+			reparsed, _, _ := parseString(HtmlMode, once.String())
+			twice := &bytes.Buffer{}
+			Format(twice, reparsed, tc.options)
+
+			assert.Equal(twice.String(), once.String())
+		})
+	}
+}
+
+func Test_FormatXmlVoidNames(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(XmlMode, "<r><br>x</br><img/></r>")
+	buffer := &bytes.Buffer{}
+	assert.Nil(Format(buffer, document, NewFormatOptions()))
+	assert.Equal(buffer.String(), "<r><br>x</br><img></img></r>\n")
+}
@@ -0,0 +1,670 @@
+package parseme
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type MarkdownOptions struct {
+	// Strict makes Markdown fail on elements that have no Markdown form
+	// instead of writing them as raw HTML.
+	Strict       bool
+	BulletMarker string
+	CodeFence    string
+}
+
+func NewMarkdownOptions() *MarkdownOptions {
+	return &MarkdownOptions{
+		BulletMarker: "-",
+		CodeFence:    "```",
+	}
+}
+
+type markdownConverter struct {
+	options *MarkdownOptions
+	err     error
+}
+
+// Markdown writes the node as GitHub Flavored Markdown. Elements without a
+// Markdown form are written as raw HTML, or make Markdown fail in strict
+// mode. Scripts, styles, comments and the head are dropped.
+func Markdown(w io.Writer, node *Element, options *MarkdownOptions) error {
+	if options == nil {
+		options = NewMarkdownOptions()
+	}
+
+	c := &markdownConverter{options: options}
+
+	var blocks []string
+	if node.elementType == DocumentElement {
+		blocks = c.blocks(node)
+	} else {
+		blocks = c.blockNodes([]*Element{node})
+	}
+
+	if c.err != nil {
+		return c.err
+	}
+
+	output := strings.Join(blocks, "\n\n")
+	if output != "" {
+		output += "\n"
+	}
+
+	_, err := io.WriteString(w, output)
+	return err
+}
+
+// Block elements whose contents are written as if the element was not
+// there
+var markdownContainers = map[string]bool{
+	"address": true, "article": true, "aside": true, "body": true, "center": true,
+	"div": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"header": true, "hgroup": true, "html": true, "main": true, "nav": true,
+	"search": true, "section": true,
+}
+
+// Inline elements whose contents are written as if the element was not
+// there
+var markdownTransparentInlines = map[string]bool{
+	"abbr": true, "bdi": true, "bdo": true, "big": true, "cite": true, "data": true,
+	"dfn": true, "font": true, "label": true, "q": true, "small": true, "span": true,
+	"time": true,
+}
+
+var markdownSkipped = map[string]bool{
+	"base": true, "head": true, "link": true, "meta": true, "noscript": true,
+	"script": true, "style": true, "template": true, "title": true,
+}
+
+func markdownName(node *Element) string {
+	if node.elementType != TagElement {
+		return ""
+	}
+
+	return textElementName(node)
+}
+
+func isMarkdownBlock(node *Element) bool {
+	name := markdownName(node)
+	return name != "" && (blockElements[name] || markdownContainers[name] || name == "details" || name == "dialog")
+}
+
+func (c *markdownConverter) blocks(parent *Element) []string {
+	return c.blockNodes(parent.ChildNodes())
+}
+
+// blockNodes converts block elements one by one and runs of inline content
+// into paragraphs.
+func (c *markdownConverter) blockNodes(nodes []*Element) []string {
+	blocks := []string{}
+	run := []*Element{}
+
+	flush := func() {
+		if paragraph := c.paragraph(run); paragraph != "" {
+			blocks = append(blocks, paragraph)
+		}
+		run = run[:0]
+	}
+
+	for _, node := range nodes {
+		if !isMarkdownBlock(node) {
+			run = append(run, node)
+			continue
+		}
+
+		flush()
+		blocks = append(blocks, c.block(node)...)
+	}
+	flush()
+
+	return blocks
+}
+
+func (c *markdownConverter) block(node *Element) []string {
+	name := markdownName(node)
+
+	switch {
+	case markdownContainers[name]:
+		return c.blocks(node)
+	case name == "p":
+		return nonEmpty(c.paragraph(node.ChildNodes()))
+	case len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6':
+		text := strings.ReplaceAll(c.inline(node.ChildNodes(), false), "\\\n", " ")
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", int(name[1]-'0')) + " " + text}
+	case name == "blockquote":
+		return nonEmpty(prefixLines(strings.Join(c.blocks(node), "\n\n"), "> ", ">"))
+	case name == "ul" || name == "ol" || name == "menu" || name == "dir":
+		return nonEmpty(c.list(node, name == "ol"))
+	case name == "pre" || name == "listing" || name == "xmp" || name == "plaintext":
+		return []string{c.codeBlock(node)}
+	case name == "hr":
+		return []string{"---"}
+	case name == "table":
+		if table, ok := c.table(node); ok {
+			return []string{table}
+		}
+	}
+
+	return nonEmpty(c.raw(node))
+}
+
+func (c *markdownConverter) paragraph(nodes []*Element) string {
+	return escapeLineStarts(c.inline(nodes, false))
+}
+
+// inline converts inline content, collapsing whitespace and trimming it at
+// the start and end of lines.
+func (c *markdownConverter) inline(nodes []*Element, inTable bool) string {
+	builder := &strings.Builder{}
+	for _, node := range nodes {
+		c.inlineNode(builder, node, inTable)
+	}
+
+	lines := strings.Split(builder.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Trim(multipleSpaces.ReplaceAllString(line, " "), " ")
+	}
+
+	for len(lines) > 0 && (lines[0] == "" || lines[0] == "\\") {
+		lines = lines[1:]
+	}
+
+	// Drop hard breaks at the end, which Markdown would read as text
+	text := strings.Join(lines, "\n")
+	for {
+		trimmed := strings.TrimRight(text, "\n")
+		if trimmed == text || !strings.HasSuffix(trimmed, "\\") {
+			return trimmed
+		}
+		text = trimmed[:len(trimmed)-1]
+	}
+}
+
+// spanned converts inline content without trimming it, so that wrapInline
+// can move its edge spaces outside the delimiters.
+func (c *markdownConverter) spanned(nodes []*Element, inTable bool) string {
+	builder := &strings.Builder{}
+	for _, node := range nodes {
+		c.inlineNode(builder, node, inTable)
+	}
+
+	return builder.String()
+}
+
+var multipleSpaces = regexp.MustCompile(`  +`)
+
+func (c *markdownConverter) inlineNode(builder *strings.Builder, node *Element, inTable bool) {
+	switch node.elementType {
+	case TextElement, CDATAElement:
+		builder.WriteString(escapeMarkdown(collapseSpaces(node.value), inTable))
+		return
+	case TagElement:
+	default:
+		return
+	}
+
+	name := markdownName(node)
+	children := node.ChildNodes()
+
+	switch {
+	case markdownSkipped[name]:
+	case markdownTransparentInlines[name]:
+		for _, child := range children {
+			c.inlineNode(builder, child, inTable)
+		}
+	case name == "em" || name == "i":
+		builder.WriteString(wrapInline(c.spanned(children, inTable), "*", "*"))
+	case name == "strong" || name == "b":
+		builder.WriteString(wrapInline(c.spanned(children, inTable), "**", "**"))
+	case name == "del" || name == "s" || name == "strike":
+		builder.WriteString(wrapInline(c.spanned(children, inTable), "~~", "~~"))
+	case name == "code" || name == "kbd" || name == "samp" || name == "tt":
+		builder.WriteString(codeSpan(collapseSpaces(node.TextContent())))
+	case name == "a":
+		builder.WriteString(c.link(node, inTable))
+	case name == "img":
+		builder.WriteString(c.image(node))
+	case name == "br":
+		if inTable {
+			builder.WriteString("<br>")
+		} else {
+			builder.WriteString("\\\n")
+		}
+	case name == "input" && isCheckbox(node):
+	default:
+		builder.WriteString(c.raw(node))
+	}
+}
+
+func (c *markdownConverter) link(node *Element, inTable bool) string {
+	text := c.inline(node.ChildNodes(), inTable)
+	href := node.Property("href")
+	if href == nil || href.IsBoolean() {
+		return text
+	}
+
+	if text == "" {
+		text = escapeMarkdown(href.value, inTable)
+	}
+
+	return "[" + text + "](" + markdownDestination(href.value) + markdownTitle(node) + ")"
+}
+
+func (c *markdownConverter) image(node *Element) string {
+	alt, src := "", ""
+	if property := node.Property("alt"); property != nil && !property.IsBoolean() {
+		alt = escapeMarkdown(collapseSpaces(property.value), false)
+	}
+	if property := node.Property("src"); property != nil && !property.IsBoolean() {
+		src = property.value
+	}
+
+	return "![" + alt + "](" + markdownDestination(src) + markdownTitle(node) + ")"
+}
+
+func markdownDestination(url string) string {
+	url = strings.Trim(url, cssSpaces)
+	url = strings.NewReplacer(" ", "%20", "(", "\\(", ")", "\\)", "<", "%3C", ">", "%3E").Replace(url)
+	return url
+}
+
+func markdownTitle(node *Element) string {
+	title := node.Property("title")
+	if title == nil || title.IsBoolean() || title.value == "" {
+		return ""
+	}
+
+	return " \"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(title.value) + "\""
+}
+
+// codeSpan uses a run of backticks longer than any inside the code.
+func codeSpan(code string) string {
+	if code == "" {
+		return ""
+	}
+
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") || (strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") && strings.Trim(code, " ") != "") {
+		code = " " + code + " "
+	}
+
+	return fence + code + fence
+}
+
+// codeBlock writes a fenced code block. The language comes from a
+// language-x or lang-x class of the pre element or of its code child.
+func (c *markdownConverter) codeBlock(node *Element) string {
+	language := codeLanguage(node)
+	if code := onlyChildTag(node, "code"); code != nil && language == "" {
+		language = codeLanguage(code)
+	}
+
+	// A line feed right after the start tag is not part of the code
+	content := strings.TrimPrefix(node.TextContent(), "\n")
+	content = strings.TrimSuffix(content, "\n")
+
+	fence := c.options.CodeFence
+	if fence == "" {
+		fence = "```"
+	}
+	for strings.Contains(content, fence) {
+		fence += fence[:1]
+	}
+
+	return fence + language + "\n" + content + "\n" + fence
+}
+
+func codeLanguage(node *Element) string {
+	class := node.Property("class")
+	if class == nil {
+		return ""
+	}
+
+	for _, token := range class.TokenList().Values() {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(token, prefix) && len(token) > len(prefix) {
+				return token[len(prefix):]
+			}
+		}
+	}
+
+	return ""
+}
+
+// list writes the items with their nested blocks indented under the
+// marker. Items whose first child is a checkbox become task list items.
+func (c *markdownConverter) list(node *Element, ordered bool) string {
+	number := 1
+	if start := node.Property("start"); ordered && start != nil {
+		if value, err := start.Int(); err == nil {
+			number = value
+		}
+	}
+
+	items := []string{}
+	for child := node.firstChild; child != nil; child = child.nextSibling {
+		if child.elementType != TagElement {
+			if child.elementType == TextElement && strings.Trim(child.value, cssSpaces) != "" {
+				items = append(items, c.listItem(c.blockNodes([]*Element{child}), c.bullet()))
+			}
+			continue
+		}
+
+		if markdownName(child) != "li" {
+			items = append(items, c.listItem(c.blockNodes([]*Element{child}), c.bullet()))
+			continue
+		}
+
+		marker := c.bullet()
+		if ordered {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+
+		blocks := c.blocks(child)
+		if checkbox := firstTag(child); checkbox != nil && markdownName(checkbox) == "input" && isCheckbox(checkbox) {
+			state := "[ ] "
+			if checked := checkbox.Property("checked"); checked != nil {
+				if value, _ := checked.BooleanValue(); value || !checked.IsBoolean() {
+					state = "[x] "
+				}
+			}
+			if len(blocks) == 0 {
+				blocks = []string{""}
+			}
+			blocks[0] = state + blocks[0]
+		}
+
+		items = append(items, c.listItem(blocks, marker))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func (c *markdownConverter) bullet() string {
+	if c.options.BulletMarker == "" {
+		return "-"
+	}
+
+	return c.options.BulletMarker
+}
+
+// listItem joins the blocks of an item. A nested list directly follows the
+// text before it so that the list stays tight.
+func (c *markdownConverter) listItem(blocks []string, marker string) string {
+	content := ""
+	for i, block := range blocks {
+		switch {
+		case i == 0:
+			content = block
+		case isMarkdownList(block):
+			content += "\n" + block
+		default:
+			content += "\n\n" + block
+		}
+	}
+
+	indent := strings.Repeat(" ", len(marker)+1)
+	lines := strings.Split(content, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+
+	return strings.TrimRight(marker+" "+strings.Join(lines, "\n"), " ")
+}
+
+var markdownListStart = regexp.MustCompile(`^([-+*]|\d+[.)]) `)
+
+func isMarkdownList(block string) bool {
+	return markdownListStart.MatchString(block)
+}
+
+// table writes a GFM table. Tables with merged cells or block content in
+// their cells have no GFM form.
+func (c *markdownConverter) table(node *Element) (string, bool) {
+	rows := [][]*Element{}
+	hasHeader := false
+
+	var collectRows func(parent *Element)
+	collectRows = func(parent *Element) {
+		for child := parent.firstChild; child != nil; child = child.nextSibling {
+			switch markdownName(child) {
+			case "thead", "tbody", "tfoot":
+				collectRows(child)
+			case "tr":
+				cells := []*Element{}
+				for cell := child.firstChild; cell != nil; cell = cell.nextSibling {
+					if name := markdownName(cell); name == "td" || name == "th" {
+						cells = append(cells, cell)
+					}
+				}
+				if len(rows) == 0 && (markdownName(parent) == "thead" || (len(cells) > 0 && markdownName(cells[0]) == "th")) {
+					hasHeader = true
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collectRows(node)
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+		for _, cell := range row {
+			if !isSimpleCell(cell) {
+				return "", false
+			}
+		}
+	}
+
+	if columns == 0 {
+		return "", false
+	}
+
+	lines := []string{}
+	alignments := rows[0]
+	if !hasHeader {
+		rows = append([][]*Element{{}}, rows...)
+	}
+
+	for i, row := range rows {
+		cells := []string{}
+		for column := 0; column < columns; column++ {
+			text := ""
+			if column < len(row) {
+				text = c.inline(row[column].ChildNodes(), true)
+			}
+			cells = append(cells, text)
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+
+		if i == 0 {
+			separators := []string{}
+			for column := 0; column < columns; column++ {
+				separators = append(separators, alignmentRow(alignments, column))
+			}
+			lines = append(lines, "| "+strings.Join(separators, " | ")+" |")
+		}
+	}
+
+	return strings.Join(lines, "\n"), true
+}
+
+func isSimpleCell(cell *Element) bool {
+	for _, name := range []string{"colspan", "rowspan"} {
+		if span := cell.Property(name); span != nil {
+			if value, err := span.Int(); err == nil && value > 1 {
+				return false
+			}
+		}
+	}
+
+	for node := range cell.Descendants() {
+		if isMarkdownBlock(node) && markdownName(node) != "p" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func alignmentRow(row []*Element, column int) string {
+	align := ""
+	if column < len(row) {
+		if property := row[column].Property("align"); property != nil {
+			align = strings.ToLower(property.value)
+		}
+		if style := row[column].Property("style"); style != nil && !style.IsBoolean() {
+			if value := style.Style().Value("text-align"); value != "" {
+				align = strings.ToLower(value)
+			}
+		}
+	}
+
+	switch align {
+	case "left":
+		return ":---"
+	case "center":
+		return ":---:"
+	case "right":
+		return "---:"
+	}
+
+	return "---"
+}
+
+// raw writes the element as HTML, or records an error in strict mode.
+func (c *markdownConverter) raw(node *Element) string {
+	if markdownSkipped[markdownName(node)] {
+		return ""
+	}
+
+	if c.options.Strict {
+		if c.err == nil {
+			c.err = &MarkdownUnsupportedElementError{name: node.name, line: node.line, column: node.column}
+		}
+		return ""
+	}
+
+	builder := &strings.Builder{}
+	Render(builder, node)
+	return builder.String()
+}
+
+var (
+	markdownEscaper      = strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "<", "\\<", "~", "\\~")
+	markdownTableEscaper = strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "<", "\\<", "~", "\\~", "|", "\\|")
+	markdownEntity       = regexp.MustCompile(`&(#?[A-Za-z0-9]+;)`)
+	markdownLineStart    = regexp.MustCompile(`(?m)^([#>+=-]|(\d+)([.)]))`)
+)
+
+// escapeMarkdown escapes the characters that would otherwise be read as
+// inline markup.
+func escapeMarkdown(text string, inTable bool) string {
+	if inTable {
+		text = markdownTableEscaper.Replace(text)
+	} else {
+		text = markdownEscaper.Replace(text)
+	}
+
+	return markdownEntity.ReplaceAllString(text, "\\&$1")
+}
+
+// escapeLineStarts escapes the characters that would start a heading,
+// quote, list or thematic break at the start of a line of a paragraph.
+func escapeLineStarts(text string) string {
+	return markdownLineStart.ReplaceAllStringFunc(text, func(match string) string {
+		if len(match) == 1 {
+			return "\\" + match
+		}
+		return match[:len(match)-1] + "\\" + match[len(match)-1:]
+	})
+}
+
+// wrapInline puts delimiters around the text, leaving the surrounding
+// spaces outside so that the delimiters stay valid.
+func wrapInline(text string, open string, close string) string {
+	trimmed := strings.Trim(text, " ")
+	if trimmed == "" {
+		return text
+	}
+
+	start := len(text) - len(strings.TrimLeft(text, " "))
+	return text[:start] + open + trimmed + close + text[start+len(trimmed):]
+}
+
+func prefixLines(text string, prefix string, empty string) string {
+	if text == "" {
+		return ""
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = empty
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func longestRun(text string, character byte) int {
+	longest, current := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] == character {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+
+	return longest
+}
+
+func nonEmpty(block string) []string {
+	if block == "" {
+		return nil
+	}
+
+	return []string{block}
+}
+
+func firstTag(node *Element) *Element {
+	for child := node.firstChild; child != nil; child = child.nextSibling {
+		if child.elementType == TagElement {
+			return child
+		}
+		if child.elementType == TextElement && strings.Trim(child.value, cssSpaces) != "" {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// onlyChildTag returns the only child of node when it is a tag with the
+// given name, ignoring whitespace.
+func onlyChildTag(node *Element, name string) *Element {
+	child := firstTag(node)
+	if child == nil || markdownName(child) != name || nextElementSibling(child) != nil {
+		return nil
+	}
+
+	return child
+}
+
+func isCheckbox(node *Element) bool {
+	inputType := node.Property("type")
+	return inputType != nil && strings.EqualFold(inputType.value, "checkbox")
+}
@@ -0,0 +1,89 @@
+package parseme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Markdown(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"headings", "<h1>Title</h1><h3>Sub <em>title</em></h3>", "# Title\n\n### Sub *title*\n"},
+		{"paragraphs", "<p>One\n  two</p><p>Three</p>", "One two\n\nThree\n"},
+		{"emphasis", "<p><b>bold</b>, <i>italic</i> and <del>gone</del></p>", "**bold**, *italic* and ~~gone~~\n"},
+		{"spaces outside emphasis", "<p>a<em> b </em>c</p>", "a *b* c\n"},
+		{"links", "<p><a href='/a b' title='T \"q\"'>link</a> <a href='x(1)'></a></p>", "[link](/a%20b \"T \\\"q\\\"\") [x(1)](x\\(1\\))\n"},
+		{"images", "<p><img src='a.png' alt='An *image*'></p>", "![An \\*image\\*](a.png)\n"},
+		{"code span", "<p>Use <code>a`b</code> and <code>`x`</code></p>", "Use ``a`b`` and `` `x` ``\n"},
+		{"hard break", "<p>a<br>b</p>", "a\\\nb\n"},
+		{"escaping", "<p>*a* _b_ [c] &lt;d&gt; &amp;amp; 1 &amp; 2</p>", "\\*a\\* \\_b\\_ \\[c\\] \\<d> \\&amp; 1 & 2\n"},
+		{"line start escaping", "<p># no<br>- item<br>1. one<br>+ plus</p>", "\\# no\\\n\\- item\\\n1\\. one\\\n\\+ plus\n"},
+		{"blockquote", "<blockquote><p>a</p><p>b</p></blockquote>", "> a\n>\n> b\n"},
+		{"unordered list", "<ul><li>one</li><li>two</li></ul>", "- one\n- two\n"},
+		{"ordered list start", "<ol start='3'><li>c</li><li>d</li></ol>", "3. c\n4. d\n"},
+		{"nested lists", "<ul><li>a<ol><li>b</li><li>c<ul><li>d</li></ul></li></ol></li><li>e</li></ul>", "- a\n  1. b\n  2. c\n     - d\n- e\n"},
+		{"list with paragraphs", "<ul><li><p>a</p><p>b</p></li></ul>", "- a\n\n  b\n"},
+		{"task list", "<ul><li><input type=checkbox checked> done</li><li><input type=checkbox> todo</li></ul>", "- [x] done\n- [ ] todo\n"},
+		{"code block", "<pre><code class='language-go'>func main() {\n\tx := `a`\n}\n</code></pre>", "```go\nfunc main() {\n\tx := `a`\n}\n```\n"},
+		{"leading line feed in code block", "<pre><code class=\"language-go\">\nx := 1\n</code></pre>", "```go\nx := 1\n```\n"},
+		{"only one leading line feed is dropped", "<pre>\n\nx</pre>", "```\n\nx\n```\n"},
+		{"code block with fence", "<pre>```\ncode\n```</pre>", "````\n```\ncode\n```\n````\n"},
+		{"thematic break", "<p>a</p><hr><p>b</p>", "a\n\n---\n\nb\n"},
+		{"table", "<table><thead><tr><th>A</th><th align=right>B</th></tr></thead><tbody><tr><td>1 | 2</td><td>x<br>y</td></tr></tbody></table>", "| A | B |\n| --- | ---: |\n| 1 \\| 2 | x<br>y |\n"},
+		{"table without header", "<table><tr><td>a</td><td style='text-align: center'>b</td></tr></table>", "|  |  |\n| --- | :---: |\n| a | b |\n"},
+		{"table with merged cells", "<table><tr><td colspan=2>a</td></tr></table>", "<table><tr><td colspan=\"2\">a</td></tr></table>\n"},
+		{"unsupported elements", "<p>a <sup>2</sup></p><details><summary>s</summary>d</details>", "a <sup>2</sup>\n\n<details><summary>s</summary>d</details>\n"},
+		{"skipped elements", "<html><head><title>T</title></head><body><div>a<script>x()</script><!-- c --></div><section><p>b</p></section></body></html>", "a\n\nb\n"},
+		{"inline and block content", "text <b>bold</b><div>block</div>tail", "text **bold**\n\nblock\n\ntail\n"},
+		{"empty document", "  <!-- c -->  ", ""},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			builder := &strings.Builder{}
+			err := Markdown(builder, document, nil)
+			assert.Nil(err)
+			assert.Equal(builder.String(), tc.expected)
+		})
+	}
+}
+
+func Test_MarkdownOptions(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<ul><li>a</li></ul><pre>b</pre>")
+
+	options := NewMarkdownOptions()
+	options.BulletMarker = "*"
+	options.CodeFence = "~~~"
+	builder := &strings.Builder{}
+	assert.Nil(Markdown(builder, document, options))
+	assert.Equal(builder.String(), "* a\n\n~~~\nb\n~~~\n")
+
+	builder.Reset()
+	assert.Nil(Markdown(builder, document.lastChild, nil))
+	assert.Equal(builder.String(), "```\nb\n```\n")
+}
+
+func Test_MarkdownStrict(t *testing.T) {
+	assert := assert.New(t)
+	options := NewMarkdownOptions()
+	options.Strict = true
+
+	document, _, _ := parseString(HtmlMode, "<p>a <b>b</b></p>\n<p>x<sup>2</sup></p>")
+	builder := &strings.Builder{}
+	err := Markdown(builder, document, options)
+	assert.Equal(err, &MarkdownUnsupportedElementError{name: "sup", line: 2, column: 5})
+	assert.EqualError(err, "Element 'sup' at line 2, column 5 cannot be written as Markdown.")
+	assert.Equal(builder.String(), "")
+
+	document, _, _ = parseString(HtmlMode, "<p>a <b>b</b></p><script>x()</script>")
+	assert.Nil(Markdown(builder, document, options))
+	assert.Equal(builder.String(), "a **b**\n")
+}
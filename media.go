@@ -0,0 +1,224 @@
+package parseme
+
+import (
+	"strings"
+)
+
+// mediaCondition tells whether a media condition holds for a viewport
+// width in CSS pixels.
+type mediaCondition func(viewport float64) bool
+
+// parseMediaCondition parses the media conditions of sizes properties.
+// Only the width features can be evaluated; other features and unknown
+// expressions in parentheses are valid but never hold.
+func parseMediaCondition(input string) (mediaCondition, bool) {
+	input = strings.Trim(input, cssSpaces)
+
+	if rest, ok := cutKeyword(input, "not"); ok {
+		term, ok := parseMediaInParens(rest)
+		if !ok {
+			return nil, false
+		}
+		return func(viewport float64) bool { return !term(viewport) }, true
+	}
+
+	terms := []mediaCondition{}
+	operator := ""
+
+	for {
+		end := matchingParen(input)
+		if end < 0 {
+			return nil, false
+		}
+
+		term, ok := parseMediaInParens(input[:end+1])
+		if !ok {
+			return nil, false
+		}
+		terms = append(terms, term)
+
+		input = strings.Trim(input[end+1:], cssSpaces)
+		if input == "" {
+			break
+		}
+
+		next := ""
+		for _, keyword := range []string{"and", "or"} {
+			if rest, ok := cutKeyword(input, keyword); ok {
+				next, input = keyword, rest
+			}
+		}
+
+		// and and or cannot be mixed without parentheses
+		if next == "" || (operator != "" && next != operator) {
+			return nil, false
+		}
+		operator = next
+	}
+
+	return func(viewport float64) bool {
+		for _, term := range terms {
+			if term(viewport) == (operator == "or") {
+				return operator == "or"
+			}
+		}
+		return operator != "or"
+	}, true
+}
+
+// parseMediaInParens parses a condition or a feature in parentheses.
+func parseMediaInParens(input string) (mediaCondition, bool) {
+	input = strings.Trim(input, cssSpaces)
+	if !strings.HasPrefix(input, "(") || matchingParen(input) != len(input)-1 {
+		return nil, false
+	}
+
+	inner := strings.Trim(input[1:len(input)-1], cssSpaces)
+	if _, ok := cutKeyword(inner, "not"); ok || strings.HasPrefix(inner, "(") {
+		return parseMediaCondition(inner)
+	}
+
+	return parseMediaFeature(inner), true
+}
+
+func parseMediaFeature(feature string) mediaCondition {
+	never := func(viewport float64) bool { return false }
+
+	if name, value, ok := strings.Cut(feature, ":"); ok {
+		name = strings.ToLower(strings.Trim(name, cssSpaces))
+		width, ok := resolveCssLength(strings.Trim(value, cssSpaces), 0)
+		if !ok {
+			return never
+		}
+
+		switch name {
+		case "width":
+			return func(viewport float64) bool { return viewport == width }
+		case "min-width":
+			return func(viewport float64) bool { return viewport >= width }
+		case "max-width":
+			return func(viewport float64) bool { return viewport <= width }
+		}
+		return never
+	}
+
+	// Range syntax such as width >= 600px or 400px < width <= 700px
+	parts, operators := splitMediaRange(feature)
+	if len(operators) == 0 {
+		if strings.EqualFold(feature, "width") {
+			return func(viewport float64) bool { return viewport > 0 }
+		}
+		return never
+	}
+
+	checks := []mediaCondition{}
+	for i, operator := range operators {
+		left, right := parts[i], parts[i+1]
+
+		switch {
+		case strings.EqualFold(left, "width"):
+			width, ok := resolveCssLength(right, 0)
+			if !ok {
+				return never
+			}
+			checks = append(checks, compareWidth(operator, width, false))
+		case strings.EqualFold(right, "width"):
+			width, ok := resolveCssLength(left, 0)
+			if !ok {
+				return never
+			}
+			checks = append(checks, compareWidth(operator, width, true))
+		default:
+			return never
+		}
+	}
+
+	return func(viewport float64) bool {
+		for _, check := range checks {
+			if !check(viewport) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// compareWidth compares the viewport to width, or width to the viewport
+// when the width comes first.
+func compareWidth(operator string, width float64, reversed bool) mediaCondition {
+	return func(viewport float64) bool {
+		left, right := viewport, width
+		if reversed {
+			left, right = width, viewport
+		}
+
+		switch operator {
+		case "<":
+			return left < right
+		case "<=":
+			return left <= right
+		case ">":
+			return left > right
+		case ">=":
+			return left >= right
+		}
+		return left == right
+	}
+}
+
+func splitMediaRange(feature string) ([]string, []string) {
+	parts, operators := []string{}, []string{}
+	start := 0
+
+	for i := 0; i < len(feature); i++ {
+		if !strings.ContainsRune("<>=", rune(feature[i])) {
+			continue
+		}
+
+		operator := feature[i : i+1]
+		if i+1 < len(feature) && feature[i+1] == '=' && feature[i] != '=' {
+			operator = feature[i : i+2]
+		}
+
+		parts = append(parts, strings.Trim(feature[start:i], cssSpaces))
+		operators = append(operators, operator)
+		i += len(operator) - 1
+		start = i + 1
+	}
+
+	return append(parts, strings.Trim(feature[start:], cssSpaces)), operators
+}
+
+// cutKeyword removes a leading keyword followed by whitespace or a
+// parenthesis.
+func cutKeyword(input string, keyword string) (string, bool) {
+	if len(input) <= len(keyword) || !strings.EqualFold(input[:len(keyword)], keyword) {
+		return input, false
+	}
+
+	next := input[len(keyword)]
+	if !isSpace(next) && next != '(' {
+		return input, false
+	}
+
+	return strings.Trim(input[len(keyword):], cssSpaces), true
+}
+
+// matchingParen returns the offset of the parenthesis that closes the one
+// input starts with, or -1.
+func matchingParen(input string) int {
+	if !strings.HasPrefix(input, "(") {
+		return -1
+	}
+
+	result := -1
+	scanCss(input, func(index int, depth int) bool {
+		if index > 0 && depth == 1 && input[index] == ')' {
+			result = index
+			return false
+		}
+		return true
+	})
+
+	return result
+}
@@ -0,0 +1,288 @@
+package parseme
+
+import (
+	"io"
+	"strings"
+)
+
+type MinifyOptions struct {
+	CollapseWhitespace       bool
+	OmitOptionalTags         bool
+	OmitAttributeQuotes      bool
+	RemoveComments           bool
+	ShortenBooleanAttributes bool
+}
+
+func NewMinifyOptions() *MinifyOptions {
+	return &MinifyOptions{
+		CollapseWhitespace:       true,
+		OmitOptionalTags:         true,
+		OmitAttributeQuotes:      true,
+		RemoveComments:           true,
+		ShortenBooleanAttributes: true,
+	}
+}
+
+type minifier struct {
+	options *MinifyOptions
+	output  *renderer
+}
+
+// minifiedNode is a child that survives minification. Adjacent text is
+// merged into a single node whose collapsed value is kept in text.
+type minifiedNode struct {
+	node *Element
+	text string
+}
+
+// Minify writes the node with every enabled transformation applied. Only
+// end tags the parser can infer again are omitted, so the output parses to
+// the same tree apart from whitespace and from shortened boolean
+// properties, which come back as boolean properties.
+func Minify(w io.Writer, node *Element, options *MinifyOptions) error {
+	if options == nil {
+		options = NewMinifyOptions()
+	}
+
+	m := &minifier{options: options, output: &renderer{writer: w}}
+
+	if node.elementType == DocumentElement {
+		m.minifyChildren(node, false)
+	} else {
+		m.minifyNode(minifiedNode{node: node}, nil, false)
+	}
+
+	return m.output.err
+}
+
+func (m *minifier) minifyChildren(parent *Element, preserve bool) {
+	children := m.significantChildren(parent, preserve)
+
+	for i, child := range children {
+		var next *minifiedNode
+		if i+1 < len(children) {
+			next = &children[i+1]
+		}
+
+		m.minifyNode(child, next, preserve)
+	}
+}
+
+func (m *minifier) significantChildren(parent *Element, preserve bool) []minifiedNode {
+	children := []minifiedNode{}
+
+	for child := parent.firstChild; child != nil; child = child.nextSibling {
+		if child.elementType == CommentElement && m.options.RemoveComments && !isConditionalComment(child.value) {
+			continue
+		}
+
+		if child.elementType != TextElement {
+			children = append(children, minifiedNode{node: child})
+			continue
+		}
+
+		last := len(children) - 1
+		if last >= 0 && children[last].node.elementType == TextElement {
+			children[last].text += child.value
+			continue
+		}
+
+		children = append(children, minifiedNode{node: child, text: child.value})
+	}
+
+	if preserve || !m.options.CollapseWhitespace {
+		return children
+	}
+
+	blockParent := parent.elementType == DocumentElement || isMinifyBlock(parent)
+	collapsed := []minifiedNode{}
+
+	for i, child := range children {
+		if child.node.elementType != TextElement {
+			collapsed = append(collapsed, child)
+			continue
+		}
+
+		text := collapseSpaces(child.text)
+		if (i == 0 && blockParent) || (i > 0 && isMinifyBlock(children[i-1].node)) {
+			text = strings.TrimLeft(text, " ")
+		}
+
+		isLast := i == len(children)-1
+		if (isLast && blockParent) || (!isLast && isMinifyBlock(children[i+1].node)) {
+			text = strings.TrimRight(text, " ")
+		}
+
+		if text != "" {
+			collapsed = append(collapsed, minifiedNode{node: child.node, text: text})
+		}
+	}
+
+	return collapsed
+}
+
+// isMinifyBlock reports whether whitespace next to the node can be trimmed
+// without changing the rendered text. Only known block elements qualify:
+// unknown, custom and unrendered elements such as script may sit between
+// words, so the whitespace around them is kept.
+func isMinifyBlock(node *Element) bool {
+	if node.elementType != TagElement || !isHtmlNamespace(node.namespace) {
+		return false
+	}
+
+	return blockElements[node.name] || minifyBlockElements[node.name]
+}
+
+// Elements that are not block elements but around which whitespace is
+// never rendered.
+var minifyBlockElements = map[string]bool{
+	"caption":  true,
+	"col":      true,
+	"colgroup": true,
+	"head":     true,
+	"tbody":    true,
+	"td":       true,
+	"tfoot":    true,
+	"th":       true,
+	"thead":    true,
+	"tr":       true,
+}
+
+func (m *minifier) minifyNode(child minifiedNode, next *minifiedNode, preserve bool) {
+	node := child.node
+
+	switch node.elementType {
+	case TextElement:
+		m.minifyText(node, child.text)
+	case TagElement:
+		m.minifyTag(node, next, preserve)
+	default:
+		m.output.render(node)
+	}
+}
+
+func (m *minifier) minifyText(node *Element, text string) {
+	if parent := node.parent; parent != nil && parent.elementType == TagElement && rawTextElements[parent.name] {
+		m.output.write(text)
+		return
+	}
+
+	m.output.write(textEscaper.Replace(text))
+}
+
+func (m *minifier) minifyTag(node *Element, next *minifiedNode, preserve bool) {
+	m.output.write("<", node.name)
+	for _, property := range node.properties {
+		m.minifyProperty(property)
+	}
+	m.output.write(">")
+
+	if node.isVoid() {
+		return
+	}
+
+	m.minifyChildren(node, preserve || preformattedElements[node.name])
+
+	if m.options.OmitOptionalTags && canOmitEndTag(node, next) {
+		return
+	}
+
+	m.output.write("</", node.name, ">")
+}
+
+func (m *minifier) minifyProperty(property *Property) {
+	if property.IsBoolean() {
+		if m.options.ShortenBooleanAttributes && property.value != "false" {
+			m.output.write(" ", property.name)
+			return
+		}
+		m.output.renderProperty(property)
+		return
+	}
+
+	if m.options.ShortenBooleanAttributes && booleanAttributes[property.name] {
+		if property.value == "" || strings.EqualFold(property.value, property.name) {
+			m.output.write(" ", property.name)
+			return
+		}
+	}
+
+	if m.options.OmitAttributeQuotes && canOmitQuotes(property.value) {
+		m.output.write(" ", property.name, "=", attributeEscaper.Replace(property.value))
+		return
+	}
+
+	m.output.renderProperty(property)
+}
+
+// canOmitEndTag follows the optional tags section of the HTML standard for
+// the end tags the tree builder closes implicitly.
+func canOmitEndTag(node *Element, next *minifiedNode) bool {
+	followers, ok := optionalEndTags[node.name]
+	if !ok || !isHtmlNamespace(node.namespace) {
+		return false
+	}
+
+	if next == nil {
+		parent := node.parent
+		if node.name == "p" && parent != nil && transparentParents[parent.name] {
+			return false
+		}
+
+		return omitAsLastChild[node.name]
+	}
+
+	return next.node.elementType == TagElement && followers[next.node.name]
+}
+
+// Elements whose end tag may be omitted when nothing follows them in their
+// parent. The end tags of dt and thead are only implied by a following
+// sibling.
+var omitAsLastChild = map[string]bool{
+	"body":     true,
+	"dd":       true,
+	"html":     true,
+	"li":       true,
+	"optgroup": true,
+	"option":   true,
+	"p":        true,
+	"rp":       true,
+	"rt":       true,
+	"tbody":    true,
+	"td":       true,
+	"tfoot":    true,
+	"th":       true,
+	"tr":       true,
+}
+
+func canOmitQuotes(value string) bool {
+	return value != "" && !strings.ContainsAny(value, " \t\n\f\r\"'=<>`")
+}
+
+func isConditionalComment(value string) bool {
+	return strings.HasPrefix(value, "[if ") || strings.HasPrefix(value, "<![endif]")
+}
+
+func collapseSpaces(value string) string {
+	builder := strings.Builder{}
+	space := false
+
+	for i := 0; i < len(value); i++ {
+		if isSpace(value[i]) {
+			space = true
+			continue
+		}
+
+		if space {
+			builder.WriteByte(' ')
+			space = false
+		}
+		builder.WriteByte(value[i])
+	}
+
+	if space {
+		builder.WriteByte(' ')
+	}
+
+	return builder.String()
+}
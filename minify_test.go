@@ -0,0 +1,218 @@
+package parseme
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// normalizeSpaces drops whitespace-only text and collapses the remaining
+// text so that trees can be compared modulo whitespace.
+func normalizeSpaces(element *Element) {
+	for child := element.firstChild; child != nil; {
+		next := child.nextSibling
+
+		if child.elementType == TextElement && !preformattedElements[element.name] {
+			child.value = strings.TrimSpace(collapseSpaces(child.value))
+			if child.value == "" {
+				if child.prevSibling != nil {
+					child.prevSibling.nextSibling = child.nextSibling
+				} else {
+					element.firstChild = child.nextSibling
+				}
+				if child.nextSibling != nil {
+					child.nextSibling.prevSibling = child.prevSibling
+				} else {
+					element.lastChild = child.prevSibling
+				}
+			}
+		}
+
+		normalizeSpaces(child)
+		child = next
+	}
+}
+
+func Test_Minify(t *testing.T) {
+	all := NewMinifyOptions()
+
+	testcases := []struct {
+		name     string
+		value    string
+		options  *MinifyOptions
+		expected string
+	}{
+		{
+			"collapse whitespace between blocks",
+			"<div>\n  <p>  a   b  </p>\n  <p>c</p>\n</div>",
+			&MinifyOptions{CollapseWhitespace: true},
+			"<div><p>a b</p><p>c</p></div>",
+		},
+		{
+			"keep single space between inline elements",
+			"<p><b>a</b>   <i>b</i></p>",
+			&MinifyOptions{CollapseWhitespace: true},
+			"<p><b>a</b> <i>b</i></p>",
+		},
+		{
+			"keep spaces around custom elements",
+			"<p>Hello <my-el>x</my-el> world</p>",
+			&MinifyOptions{CollapseWhitespace: true},
+			"<p>Hello <my-el>x</my-el> world</p>",
+		},
+		{
+			"keep spaces around scripts",
+			"<p>a <script>x()</script> b</p>",
+			&MinifyOptions{CollapseWhitespace: true},
+			"<p>a <script>x()</script> b</p>",
+		},
+		{
+			"trim whitespace between table parts",
+			"<table>\n  <tr>\n    <td>a</td>\n  </tr>\n</table>",
+			&MinifyOptions{CollapseWhitespace: true},
+			"<table><tr><td>a</td></tr></table>",
+		},
+		{
+			"preformatted content keeps whitespace",
+			"<div> <pre>  a\n  <b> b </b></pre> <textarea> x  y </textarea></div>",
+			&MinifyOptions{CollapseWhitespace: true},
+			"<div><pre>  a\n  <b> b </b></pre><textarea> x  y </textarea></div>",
+		},
+		{
+			"whitespace is kept when disabled",
+			"<div>\n  <p>a</p>\n</div>",
+			&MinifyOptions{},
+			"<div>\n  <p>a</p>\n</div>",
+		},
+		{
+			"omit list item end tags",
+			"<ul><li>a</li><li>b</li></ul>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<ul><li>a<li>b</ul>",
+		},
+		{
+			"omit paragraph end tag before block",
+			"<div><p>a</p><div>b</div><p>c</p></div>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<div><p>a<div>b</div><p>c</div>",
+		},
+		{
+			"keep paragraph end tag before text",
+			"<div><p>a</p>b</div>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<div><p>a</p>b</div>",
+		},
+		{
+			"keep paragraph end tag in transparent parent",
+			"<a href=x><p>a</p></a>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<a href=\"x\"><p>a</p></a>",
+		},
+		{
+			"omit table end tags",
+			"<table><tbody><tr><td>a</td><th>b</th></tr><tr><td>c</td></tr></tbody></table>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<table><tbody><tr><td>a<th>b<tr><td>c</table>",
+		},
+		{
+			"keep table head end tag as last child",
+			"<table><thead><tr><td>x</td></tr></thead></table>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<table><thead><tr><td>x</thead></table>",
+		},
+		{
+			"keep term end tag as last child",
+			"<dl><dd>a</dd><dt>b</dt></dl>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<dl><dd>a<dt>b</dt></dl>",
+		},
+		{
+			"omit html and body end tags",
+			"<html><head></head><body><p>a</p></body></html>",
+			&MinifyOptions{OmitOptionalTags: true},
+			"<html><head></head><body><p>a",
+		},
+		{
+			"omit attribute quotes",
+			"<a href=\"/a/b\" title=\"two words\" class=\"\" data-x=\"a=b\"></a>",
+			&MinifyOptions{OmitAttributeQuotes: true},
+			"<a href=/a/b title=\"two words\" class=\"\" data-x=\"a=b\"></a>",
+		},
+		{
+			"remove comments",
+			"<!-- note --><p>a<!-- inline --></p>",
+			&MinifyOptions{RemoveComments: true},
+			"<p>a</p>",
+		},
+		{
+			"keep conditional comments",
+			"<!--[if IE]><p>old</p><![endif]--><!-- note -->",
+			&MinifyOptions{RemoveComments: true},
+			"<!--[if IE]><p>old</p><![endif]-->",
+		},
+		{
+			"shorten boolean attributes",
+			"<input disabled=\"disabled\" checked=\"\" required=\"yes\" value=\"\">",
+			&MinifyOptions{ShortenBooleanAttributes: true},
+			"<input disabled checked required value=\"\">",
+		},
+		{
+			"all transformations",
+			"<!-- header -->\n<ul class=\"menu\">\n  <li><a href=\"/\">Home</a></li>\n  <li><a href=\"/about\">About</a></li>\n</ul>",
+			all,
+			"<ul class=menu><li><a href=/>Home</a><li><a href=/about>About</a></ul>",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			buffer := &bytes.Buffer{}
+			err := Minify(buffer, document, tc.options)
+			assert.Nil(err)
+			assert.Equal(buffer.String(), tc.expected)
+		})
+	}
+}
+
+func Test_MinifyRoundTrip(t *testing.T) {
+	testcases := []struct {
+		name    string
+		options *MinifyOptions
+	}{
+		{"all transformations", NewMinifyOptions()},
+		{"optional tags only", &MinifyOptions{OmitOptionalTags: true}},
+		{"optional tags with whitespace", &MinifyOptions{OmitOptionalTags: true, CollapseWhitespace: true}},
+	}
+
+	input, _ := os.ReadFile("test_data/example4.html")
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			options := *tc.options
+			options.RemoveComments = false
+
+			document, _, _ := parseString(HtmlMode, string(input))
+			buffer := &bytes.Buffer{}
+			Minify(buffer, document, &options)
+			minified, _, _ := parseString(HtmlMode, buffer.String())
+
+			normalizeSpaces(document)
+			normalizeSpaces(minified)
+			assert.Equal(outline(minified), outline(document))
+		})
+	}
+}
+
+func Test_MinifyXmlVoidNames(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(XmlMode, "<r><br>x</br><img/></r>")
+	buffer := &bytes.Buffer{}
+	assert.Nil(Minify(buffer, document, NewMinifyOptions()))
+	assert.Equal(buffer.String(), "<r><br>x</br><img></img></r>")
+}
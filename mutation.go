@@ -0,0 +1,253 @@
+package parseme
+
+import "strings"
+
+// NewElement creates a detached node. The name is used by tags and
+// processing instructions and the value by every other kind of node.
+func NewElement(elementType ElementType, name string, value string) *Element {
+	if elementType == DocumentElement {
+		return newDocument()
+	}
+
+	return &Element{elementType: elementType, name: name, value: value}
+}
+
+func (e *Element) AppendChild(child *Element) error {
+	if err := e.checkChild(child); err != nil {
+		return err
+	}
+
+	e.appendChild(child)
+	return nil
+}
+
+// InsertBefore inserts child before reference, which must be a child of the
+// element. A nil reference appends the child.
+func (e *Element) InsertBefore(child *Element, reference *Element) error {
+	if reference == nil {
+		return e.AppendChild(child)
+	}
+
+	if reference.parent != e {
+		return &ElementNotChildError{}
+	}
+
+	if err := e.checkChild(child); err != nil {
+		return err
+	}
+
+	e.insertBefore(child, reference)
+	return nil
+}
+
+func (e *Element) RemoveChild(child *Element) error {
+	if child == nil || child.parent != e {
+		return &ElementNotChildError{}
+	}
+
+	child.detach()
+	return nil
+}
+
+func (e *Element) ReplaceChild(newChild *Element, oldChild *Element) error {
+	if oldChild == nil || oldChild.parent != e {
+		return &ElementNotChildError{}
+	}
+
+	if err := e.checkChild(newChild); err != nil {
+		return err
+	}
+
+	e.insertBefore(newChild, oldChild)
+	oldChild.detach()
+	return nil
+}
+
+// SetProperty sets the value of the property with the given name, adding
+// it at the end when the element does not have it yet. New properties of
+// HTML elements are boolean when their name is a boolean attribute, and
+// their names are lowercased like the ones the parser reads.
+func (e *Element) SetProperty(name string, value string) error {
+	if property := e.Property(name); property != nil {
+		return property.SetValue(value)
+	}
+
+	if !e.isXml() && isHtmlNamespace(e.namespace) {
+		name = strings.ToLower(name)
+	}
+
+	property := &Property{dialect: e.attributeDialect()}
+	if err := property.SetName(name); err != nil {
+		return err
+	}
+
+	if IsBooleanAttribute(property.name) && !e.isXml() {
+		property.SetType(Boolean)
+	}
+
+	if err := property.SetValue(value); err != nil {
+		return err
+	}
+
+	e.properties = append(e.properties, property)
+	e.indexProperties()
+	return nil
+}
+
+// RemoveProperty removes the property with the given name and reports
+// whether the element had it. Names are matched as Property does.
+func (e *Element) RemoveProperty(name string) bool {
+	property := e.Property(name)
+	if property == nil {
+		return false
+	}
+
+	for i, candidate := range e.properties {
+		if candidate == property {
+			e.properties = append(e.properties[:i], e.properties[i+1:]...)
+			property.owner = nil
+			e.indexProperties()
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetText replaces the children of a document or tag with a single text
+// node, and the value of any other node.
+func (e *Element) SetText(text string) error {
+	if e.elementType != DocumentElement && e.elementType != TagElement {
+		e.value = text
+		return nil
+	}
+
+	if text != "" && e.isVoid() {
+		return &ElementVoidChildError{name: e.name}
+	}
+
+	for e.firstChild != nil {
+		e.firstChild.detach()
+	}
+
+	if text != "" {
+		e.appendChild(NewElement(TextElement, "", text))
+	}
+
+	return nil
+}
+
+// Wrap puts wrapper in the place of the element and moves the element
+// inside it, after any children wrapper already has.
+func (e *Element) Wrap(wrapper *Element) error {
+	if wrapper == nil || wrapper.parent != nil {
+		return &ElementInUseError{}
+	}
+
+	if err := wrapper.checkChild(e); err != nil {
+		if _, ok := err.(*ElementInUseError); !ok {
+			return err
+		}
+	}
+
+	if parent := e.parent; parent != nil {
+		if err := parent.checkChild(wrapper); err != nil {
+			return err
+		}
+
+		parent.insertBefore(wrapper, e)
+		e.detach()
+	}
+
+	wrapper.appendChild(e)
+	return nil
+}
+
+// Unwrap replaces the element with its children.
+func (e *Element) Unwrap() error {
+	parent := e.parent
+	if parent == nil {
+		return &ElementDetachedError{}
+	}
+
+	for e.firstChild != nil {
+		child := e.firstChild
+		child.detach()
+		parent.insertBefore(child, e)
+	}
+
+	e.detach()
+	return nil
+}
+
+// checkChild returns the error that inserting child into the element would
+// cause.
+func (e *Element) checkChild(child *Element) error {
+	if e.elementType != DocumentElement && e.elementType != TagElement {
+		return &ElementNotContainerError{}
+	}
+
+	if e.isVoid() {
+		return &ElementVoidChildError{name: e.name}
+	}
+
+	if child.elementType == DocumentElement {
+		return &ElementDocumentChildError{}
+	}
+
+	for ancestor := e; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == child {
+			return &ElementCycleError{}
+		}
+	}
+
+	if child.parent != nil {
+		return &ElementInUseError{}
+	}
+
+	return nil
+}
+
+// Void elements only exist in HTML, XML documents may give any element
+// children.
+func (e *Element) isVoid() bool {
+	return e.elementType == TagElement && isVoidElement(strings.ToLower(e.name)) &&
+		isHtmlNamespace(e.namespace) && !e.isXml()
+}
+
+func (e *Element) insertBefore(child *Element, reference *Element) {
+	child.parent = e
+	child.prevSibling = reference.prevSibling
+	child.nextSibling = reference
+
+	if reference.prevSibling != nil {
+		reference.prevSibling.nextSibling = child
+	} else {
+		e.firstChild = child
+	}
+
+	reference.prevSibling = child
+}
+
+func (e *Element) detach() {
+	parent := e.parent
+	if parent == nil {
+		return
+	}
+
+	if e.prevSibling != nil {
+		e.prevSibling.nextSibling = e.nextSibling
+	} else {
+		parent.firstChild = e.nextSibling
+	}
+
+	if e.nextSibling != nil {
+		e.nextSibling.prevSibling = e.prevSibling
+	} else {
+		parent.lastChild = e.prevSibling
+	}
+
+	e.parent = nil
+	e.prevSibling = nil
+	e.nextSibling = nil
+}
@@ -0,0 +1,197 @@
+package parseme
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func renderHtml(t *testing.T, node *Element) string {
+	buffer := bytes.Buffer{}
+	assert.Nil(t, Render(&buffer, node))
+	return buffer.String()
+}
+
+// assertLinks checks that the sibling and parent links of every node agree
+// with each other.
+func assertLinks(t *testing.T, parent *Element) {
+	var previous *Element
+	for child := parent.firstChild; child != nil; child = child.nextSibling {
+		assert.Equal(t, child.parent, parent)
+		assert.Equal(t, child.prevSibling, previous)
+		assertLinks(t, child)
+		previous = child
+	}
+	assert.Equal(t, parent.lastChild, previous)
+}
+
+func Test_AppendChild(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, "<head><title>x</title></head>")
+	head, _ := document.QuerySelector("head")
+
+	script := NewElement(TagElement, "script", "")
+	assert.Nil(t, script.SetProperty("src", "/analytics.js"))
+	assert.Nil(t, head.AppendChild(script))
+
+	assert.Equal(t, renderHtml(t, document), `<head><title>x</title><script src="/analytics.js"></script></head>`)
+	assertLinks(t, document)
+}
+
+func Test_InsertBefore(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, "<ul><li>b</li></ul>")
+	ul, _ := document.QuerySelector("ul")
+
+	first := NewElement(TagElement, "li", "")
+	first.SetText("a")
+	assert.Nil(t, ul.InsertBefore(first, ul.FirstChild()))
+
+	last := NewElement(TagElement, "li", "")
+	last.SetText("c")
+	assert.Nil(t, ul.InsertBefore(last, nil))
+
+	assert.Equal(t, renderHtml(t, document), "<ul><li>a</li><li>b</li><li>c</li></ul>")
+	assertLinks(t, document)
+}
+
+func Test_RemoveChild(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, `<body><img src="/pixel.gif"><p>text</p><script src="/tracker.js"></script></body>`)
+
+	for _, selector := range []string{"img", "script"} {
+		node, _ := document.QuerySelector(selector)
+		assert.Nil(t, node.Parent().RemoveChild(node))
+		assert.Nil(t, node.Parent())
+		assert.Nil(t, node.NextSibling())
+	}
+
+	assert.Equal(t, renderHtml(t, document), "<body><p>text</p></body>")
+	assertLinks(t, document)
+
+	p, _ := document.QuerySelector("p")
+	assert.IsType(t, document.RemoveChild(p), &ElementNotChildError{})
+}
+
+func Test_ReplaceChild(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, "<p>a <b>bold</b> c</p>")
+	p, _ := document.QuerySelector("p")
+	b, _ := document.QuerySelector("b")
+
+	strong := NewElement(TagElement, "strong", "")
+	strong.AppendChild(NewElement(TextElement, "", "bold"))
+	assert.Nil(t, p.ReplaceChild(strong, b))
+
+	assert.Equal(t, renderHtml(t, document), "<p>a <strong>bold</strong> c</p>")
+	assert.Nil(t, b.Parent())
+	assertLinks(t, document)
+}
+
+func Test_mutationErrors(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, "<div><p>text</p><br></div>")
+	div, _ := document.QuerySelector("div")
+	p, _ := document.QuerySelector("p")
+	br, _ := document.QuerySelector("br")
+	text := p.FirstChild()
+
+	testcases := []struct {
+		name     string
+		action   func() error
+		expected error
+	}{
+		{"element into itself", func() error { return div.AppendChild(div) }, &ElementCycleError{}},
+		{"ancestor into descendant", func() error { return p.AppendChild(div) }, &ElementCycleError{}},
+		{"node already in a tree", func() error { return document.AppendChild(p) }, &ElementInUseError{}},
+		{"child on void element", func() error { return br.AppendChild(NewElement(TextElement, "", "x")) }, &ElementVoidChildError{}},
+		{"text on void element", func() error { return br.SetText("x") }, &ElementVoidChildError{}},
+		{"child on text node", func() error { return text.AppendChild(NewElement(TagElement, "b", "")) }, &ElementNotContainerError{}},
+		{"document as child", func() error { return div.AppendChild(NewElement(DocumentElement, "", "")) }, &ElementDocumentChildError{}},
+		{"foreign reference", func() error { return div.InsertBefore(NewElement(TagElement, "i", ""), text) }, &ElementNotChildError{}},
+		{"foreign old child", func() error { return div.ReplaceChild(NewElement(TagElement, "i", ""), text) }, &ElementNotChildError{}},
+		{"void wrapper", func() error { return p.Wrap(NewElement(TagElement, "img", "")) }, &ElementVoidChildError{}},
+		{"wrapper in use", func() error { return p.Wrap(br) }, &ElementInUseError{}},
+		{"unwrap detached element", func() error { return NewElement(TagElement, "span", "").Unwrap() }, &ElementDetachedError{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.action()
+			assert.IsType(t, err, tc.expected)
+			assert.Equal(t, renderHtml(t, document), "<div><p>text</p><br></div>")
+		})
+	}
+
+	// Void elements are only special in HTML
+	xml, _, _ := parseString(XmlMode, "<root><br/></root>")
+	xmlBr, _ := xml.QuerySelector("br")
+	assert.Nil(t, xmlBr.AppendChild(NewElement(TextElement, "", "x")))
+}
+
+func Test_Properties(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, `<a href="/old" target="_blank">link</a>`)
+	a, _ := document.QuerySelector("a")
+
+	assert.Nil(t, a.SetProperty("href", "/new"))
+	assert.Nil(t, a.SetProperty("rel", "noopener"))
+	assert.True(t, a.RemoveProperty("target"))
+	assert.False(t, a.RemoveProperty("target"))
+	assert.IsType(t, a.SetProperty(" ", "x"), &PropertyEmptyNameError{})
+
+	assert.Equal(t, renderHtml(t, document), `<a href="/new" rel="noopener">link</a>`)
+}
+
+func Test_PropertiesIgnoreCase(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, `<div class="a"></div>`)
+	div := document.firstChild
+
+	assert.Equal(div.Property("CLASS"), div.properties[0])
+	assert.Nil(div.SetProperty("Class", "b"))
+	assert.Nil(div.SetProperty("Title", "t"))
+	assert.Equal(renderHtml(t, document), `<div class="b" title="t"></div>`)
+	assert.True(div.RemoveProperty("TITLE"))
+	assert.Equal(renderHtml(t, document), `<div class="b"></div>`)
+
+	// XML names are case-sensitive
+	xml, _, _ := parseString(XmlMode, `<r a="1"/>`)
+	root := xml.firstChild
+	assert.Nil(root.Property("A"))
+	assert.Nil(root.SetProperty("A", "2"))
+	assert.False(root.RemoveProperty("B"))
+	assert.Len(root.properties, 2)
+}
+
+func Test_SetText(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, "<p>a <b>b</b><!--c--></p>")
+	p, _ := document.QuerySelector("p")
+	comment := p.LastChild()
+
+	assert.Nil(t, p.SetText("1 < 2"))
+	assert.Equal(t, renderHtml(t, document), "<p>1 &lt; 2</p>")
+	assert.Nil(t, comment.Parent())
+
+	assert.Nil(t, p.SetText(""))
+	assert.Nil(t, p.FirstChild())
+
+	assert.Nil(t, comment.SetText(" note "))
+	assert.Equal(t, comment.Value(), " note ")
+}
+
+func Test_Wrap(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, `<p>a<img src="x.png">b</p>`)
+	img, _ := document.QuerySelector("img")
+
+	figure := NewElement(TagElement, "figure", "")
+	assert.Nil(t, img.Wrap(figure))
+	assert.Equal(t, renderHtml(t, document), `<p>a<figure><img src="x.png"></figure>b</p>`)
+	assertLinks(t, document)
+
+	assert.Nil(t, figure.Unwrap())
+	assert.Equal(t, renderHtml(t, document), `<p>a<img src="x.png">b</p>`)
+	assert.Nil(t, figure.Parent())
+	assertLinks(t, document)
+
+	// Detached elements can be wrapped too
+	span := NewElement(TagElement, "span", "")
+	div := NewElement(TagElement, "div", "")
+	assert.Nil(t, span.Wrap(div))
+	assert.Equal(t, span.Parent(), div)
+}
@@ -1,13 +1,93 @@
 package parseme
 
+type ParserMode int
+
+const (
+	HtmlMode ParserMode = iota
+	XmlMode
+)
+
+// DuplicatePolicy decides what happens to a property that appears more than
+// once in the same tag. Duplicates are always reported as diagnostics.
+type DuplicatePolicy int
+
+const (
+	// KeepFirstAttribute keeps the first occurrence, as browsers do.
+	KeepFirstAttribute DuplicatePolicy = iota
+	// KeepLastAttribute keeps the last occurrence.
+	KeepLastAttribute
+	// RejectDuplicateAttributes makes ParseDocument fail on the first duplicate.
+	RejectDuplicateAttributes
+)
+
 type HtmlParser struct {
-	filepath string
+	filepath    string
+	mode        ParserMode
+	lossless    bool
+	dialect     AttributeDialect
+	duplicates  DuplicatePolicy
+	diagnostics stack[ErrorData]
 }
 
 func NewHtmlParser(filepath string) *HtmlParser {
 	return &HtmlParser{filepath: filepath}
 }
 
+func (p *HtmlParser) Mode() ParserMode {
+	return p.mode
+}
+
+// SetMode selects how documents are parsed. HtmlMode applies the recovery
+// rules browsers use for broken markup, while XmlMode is case-sensitive,
+// resolves namespaces and rejects documents that are not well-formed.
+func (p *HtmlParser) SetMode(mode ParserMode) {
+	p.mode = mode
+}
+
+func (p *HtmlParser) IsLossless() bool {
+	return p.lossless
+}
+
+// SetLossless makes the parser keep the original markup of every node:
+// quote style, property order, whitespace inside tags, letter case and
+// character references. Rendering an unmodified lossless tree reproduces
+// the input exactly, and edited nodes only change where they were edited.
+func (p *HtmlParser) SetLossless(lossless bool) {
+	p.lossless = lossless
+}
+
+func (p *HtmlParser) Dialect() AttributeDialect {
+	if p.dialect == nil {
+		return HtmlDialect
+	}
+
+	return p.dialect
+}
+
+// SetDialect selects the property names HtmlMode accepts. Names the dialect
+// rejects are kept in the tree and reported as diagnostics. Documents
+// parsed in XmlMode always use XmlDialect.
+func (p *HtmlParser) SetDialect(dialect AttributeDialect) {
+	p.dialect = dialect
+}
+
+func (p *HtmlParser) DuplicatePolicy() DuplicatePolicy {
+	return p.duplicates
+}
+
+// SetDuplicatePolicy selects which occurrence of a repeated property is kept
+// in HtmlMode. Repeated properties make documents parsed in XmlMode fail
+// whatever the policy is.
+func (p *HtmlParser) SetDuplicatePolicy(policy DuplicatePolicy) {
+	p.duplicates = policy
+}
+
+// Errors returns the problems found by the last call to ParseDocument,
+// ParseBytes or ParseString.
+func (p *HtmlParser) Errors() []ErrorData {
+	return p.diagnostics.values
+}
+
 func (p *HtmlParser) Parse() (*[]preToken, error) {
 	bytes, readErr := fetchFileContents(p.filepath)
 
@@ -19,3 +99,58 @@ func (p *HtmlParser) Parse() (*[]preToken, error) {
 
 	return preTokens, nil
 }
+
+// ParseDocument reads the file and builds its element tree, following the
+// mode, dialect and duplicate policy of the parser.
+func (p *HtmlParser) ParseDocument() (*Element, error) {
+	bytes, readErr := fetchFileContents(p.filepath)
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return p.build(bytes)
+}
+
+// ParseBytes builds the element tree of the given markup like
+// ParseDocument does for the file of the parser.
+func (p *HtmlParser) ParseBytes(input []byte) (*Element, error) {
+	return p.build(&input)
+}
+
+// ParseString builds the element tree of the given markup like
+// ParseDocument does for the file of the parser.
+func (p *HtmlParser) ParseString(input string) (*Element, error) {
+	return p.ParseBytes([]byte(input))
+}
+
+func (p *HtmlParser) build(bytes *[]byte) (*Element, error) {
+	p.diagnostics.Clear()
+	document := newTreeBuilder(bytes, p.mode, p.lossless, p.dialect, p.duplicates, &p.diagnostics).build()
+
+	for _, data := range p.diagnostics.values {
+		reportError(p.module(), data)
+	}
+
+	if p.mode == XmlMode && !p.diagnostics.IsEmpty() {
+		return nil, &XmlWellFormednessError{Data: p.diagnostics.values[0]}
+	}
+
+	if p.duplicates == RejectDuplicateAttributes {
+		for _, data := range p.diagnostics.values {
+			if data.Code == htmlDuplicateAttributeError.Code {
+				return nil, &DuplicateAttributeError{Data: data}
+			}
+		}
+	}
+
+	return document, nil
+}
+
+func (p *HtmlParser) module() string {
+	if p.mode == XmlMode {
+		return "Xml Parser"
+	}
+
+	return "Html Parser"
+}
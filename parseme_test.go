@@ -0,0 +1,82 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseDocument(t *testing.T) {
+	testcases := []struct {
+		name     string
+		filepath string
+		mode     ParserMode
+		expected string
+	}{
+		{
+			"html file",
+			"test_data/example1.html",
+			HtmlMode,
+			"html('\n  ' head('\n    ' meta[charset=UTF-8] '\n    ' title('Example 1') '\n  ') '\n  ' body('\n    ' p('This is a paragraph.') '\n  ') '\n') '\n'",
+		},
+		{
+			"unicode file",
+			"test_data/example2.html",
+			HtmlMode,
+			"html('\n  ' head('\n    ' meta[charset=UTF-8] '\n    ' title('Example 2') '\n  ') '\n  ' body('\n    ' p('This is a unicode character: ♥') '\n  ') '\n') '\n'",
+		},
+		{
+			"empty file",
+			"test_data/example3.html",
+			HtmlMode,
+			"",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			parser := NewHtmlParser(tc.filepath)
+			parser.SetMode(tc.mode)
+			document, err := parser.ParseDocument()
+			assert.Nil(err)
+			assert.Equal(outline(document), tc.expected)
+		})
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	assert := assert.New(t)
+	preTokens, err := NewHtmlParser("test_data/example1.html").Parse()
+	assert.Nil(err)
+	assert.Equal((*preTokens)[0], preToken{tokenType: tagStart, value: "<"})
+}
+
+func Test_ParseXmlMode(t *testing.T) {
+	assert := assert.New(t)
+	parser := NewHtmlParser("test_data/example1.html")
+	parser.SetMode(XmlMode)
+
+	document, err := parser.ParseDocument()
+
+	// <meta charset="UTF-8"> is never closed in example1.html
+	assert.Nil(document)
+	assert.Error(err)
+	assert.Equal(len(parser.Errors()) > 0, true)
+	assert.Equal(parser.Errors()[0].Code, "X01")
+}
+
+func Test_ParseString(t *testing.T) {
+	assert := assert.New(t)
+	parser := NewHtmlParser("")
+
+	document, err := parser.ParseString("<p class=a>x")
+	assert.Nil(err)
+	assert.Equal(outline(document), "p[class=a]('x')")
+
+	parser.SetMode(XmlMode)
+	document, err = parser.ParseBytes([]byte("<a><b></a>"))
+	assert.Nil(document)
+	assert.IsType(err, &XmlWellFormednessError{})
+	assert.Equal(parser.Errors()[0].Code, "X01")
+}
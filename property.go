@@ -12,7 +12,15 @@ const (
 type Property struct {
 	propertyType PropertyType
 	name         string
+	namespace    string
 	value        string
+	// literal is how a true boolean property is written, empty when it is
+	// written without a value
+	literal string
+	dialect AttributeDialect
+	source  *propertySource
+	// owner is the element whose index lists the property
+	owner *Element
 }
 
 func (p *Property) IsBoolean() bool {
@@ -23,6 +31,10 @@ func (p *Property) Name() string {
 	return p.name
 }
 
+func (p *Property) Namespace() string {
+	return p.namespace
+}
+
 func (p *Property) Value() string {
 	return p.value
 }
@@ -41,30 +53,61 @@ func (p *Property) BooleanValue() (bool, error) {
 
 func (p *Property) SetType(propertyType PropertyType) {
 	if p.propertyType == Value && propertyType == Boolean {
-		if p.value != "true" && p.value != "false" {
+		if strings.EqualFold(p.value, p.name) && p.value != "" {
+			p.literal = p.value
+		}
+
+		if p.value != "false" {
 			p.value = "true"
 		}
 	}
+
+	if propertyType == Value {
+		p.literal = ""
+	}
 	p.propertyType = propertyType
 }
 
+// SetDialect selects the names SetName accepts. Properties of parsed
+// documents use the dialect of their parser, other properties default to
+// StrictDialect.
+func (p *Property) SetDialect(dialect AttributeDialect) {
+	p.dialect = dialect
+}
+
 func (p *Property) SetName(name string) error {
 	trimmed := strings.Trim(name, " ")
 	if len(trimmed) == 0 {
 		return &PropertyEmptyNameError{}
 	}
 
-	if !isValidProperty(trimmed) {
+	dialect := p.dialect
+	if dialect == nil {
+		dialect = StrictDialect
+	}
+
+	if !dialect(trimmed) {
 		return &PropertyInvalidNameError{name: trimmed}
 	}
 
 	p.name = trimmed
+	if p.owner != nil {
+		p.owner.indexProperties()
+	}
 	return nil
 }
 
+// SetValue sets the value of the property. Boolean properties accept "true"
+// and "false", and the two forms HTML uses for a present boolean property:
+// an empty value and the name of the property.
 func (p *Property) SetValue(value string) error {
 	if p.propertyType == Boolean {
-		if value != "true" && value != "false" {
+		switch {
+		case value == "true", value == "false":
+		case value == "", strings.EqualFold(value, p.name):
+			p.literal = value
+			value = "true"
+		default:
 			return &PropertyInvalidBooleanError{value: value}
 		}
 	}
@@ -73,6 +116,12 @@ func (p *Property) SetValue(value string) error {
 	return nil
 }
 
+// IsBooleanAttribute reports whether the HTML standard defines name as a
+// boolean attribute, whose presence alone means true.
+func IsBooleanAttribute(name string) bool {
+	return booleanAttributes[strings.ToLower(name)]
+}
+
 func NewProperty(propertyType PropertyType, name string, value string) *Property {
 	property := &Property{}
 	property.SetType(propertyType)
@@ -288,6 +288,20 @@ func Test_SetValue(t *testing.T) {
 			nil,
 			&PropertyInvalidBooleanError{value: "invalid"},
 		},
+		{
+			"assign empty value to boolean property",
+			"",
+			NewProperty(Boolean, "disabled", "false"),
+			&Property{propertyType: Boolean, name: "disabled", value: "true"},
+			nil,
+		},
+		{
+			"assign property name to boolean property",
+			"Disabled",
+			NewProperty(Boolean, "disabled", "false"),
+			&Property{propertyType: Boolean, name: "disabled", value: "true", literal: "Disabled"},
+			nil,
+		},
 		{
 			"name with leading and trailing spaces",
 			"     country      ",
@@ -322,3 +336,66 @@ func Test_SetValue(t *testing.T) {
 		})
 	}
 }
+
+func Test_IsBooleanAttribute(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(IsBooleanAttribute("disabled"))
+	assert.True(IsBooleanAttribute("CHECKED"))
+	assert.True(IsBooleanAttribute("hidden"))
+	assert.False(IsBooleanAttribute("href"))
+}
+
+func Test_booleanAttributes(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+		boolean  bool
+	}{
+		{"without value", "<input disabled>", "<input disabled>", true},
+		{"empty value", "<input disabled=\"\">", "<input disabled>", true},
+		{"name as value", "<input disabled=\"disabled\">", "<input disabled=\"disabled\">", true},
+		{"any other value", "<input disabled=\"false\">", "<input disabled=\"false\">", true},
+		{"hidden", "<div hidden=\"hidden\">", "<div hidden=\"hidden\"></div>", true},
+		{"hidden until found", "<div hidden=\"until-found\">", "<div hidden=\"until-found\"></div>", true},
+		{"unregistered name", "<input data-on=\"false\">", "<input data-on=\"false\">", false},
+		{"unregistered name without value", "<input value>", "<input value=\"\">", false},
+		{"data property without value", "<div data-x></div>", "<div data-x=\"\"></div>", false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			property := document.firstChild.properties[0]
+
+			value, err := property.BooleanValue()
+			if tc.boolean {
+				assert.Nil(err)
+				assert.True(value)
+			} else {
+				assert.IsType(err, &PropertyBooleanValueError{})
+				assert.NotEqual(property.Value(), "true")
+			}
+			assert.Equal(renderHtml(t, document), tc.expected)
+		})
+	}
+}
+
+func Test_SetBooleanProperty(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<input checked><button>")
+	input := document.firstChild
+	button := input.nextSibling
+
+	assert.Nil(button.SetProperty("disabled", "disabled"))
+	assert.True(button.Property("disabled").IsBoolean())
+	assert.Nil(input.SetProperty("checked", "false"))
+	assert.Nil(input.SetProperty("required", ""))
+	assert.Equal(renderHtml(t, document), "<input required><button disabled=\"disabled\"></button>")
+
+	// XML elements have no boolean attributes
+	xml, _, _ := parseString(XmlMode, "<option/>")
+	assert.Nil(xml.firstChild.SetProperty("selected", "yes"))
+	assert.False(xml.firstChild.Property("selected").IsBoolean())
+}
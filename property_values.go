@@ -0,0 +1,200 @@
+package parseme
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type LengthUnit int
+
+const (
+	Pixels LengthUnit = iota
+	Percentage
+)
+
+// Length is a dimension value such as the width of an image or a table
+// cell.
+type Length struct {
+	Value float64
+	Unit  LengthUnit
+}
+
+// Int follows the HTML rules for parsing integers: leading whitespace and a
+// sign are allowed and anything after the digits is ignored, so "12px"
+// gives 12.
+func (p *Property) Int() (int, error) {
+	value := trimLeadingSpaces(p.value)
+	sign := 1
+
+	if value != "" && (value[0] == '-' || value[0] == '+') {
+		if value[0] == '-' {
+			sign = -1
+		}
+		value = value[1:]
+	}
+
+	digits := leadingDigits(value)
+	if digits == "" {
+		return 0, &PropertyIntValueError{value: p.value}
+	}
+
+	number, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, &PropertyIntValueError{value: p.value}
+	}
+
+	return sign * number, nil
+}
+
+// Float follows the HTML rules for parsing floating-point numbers. Like Int
+// it ignores anything after the number.
+func (p *Property) Float() (float64, error) {
+	number, _, ok := parseHtmlFloat(p.value)
+	if !ok {
+		return 0, &PropertyFloatValueError{value: p.value}
+	}
+
+	return number, nil
+}
+
+// Length follows the HTML rules for parsing dimension values. A number
+// followed by '%' is a percentage and any other number is in pixels, so
+// "100px" is 100 pixels. Only digits with an optional fraction are
+// accepted, without a sign or an exponent.
+func (p *Property) Length() (Length, error) {
+	value := trimLeadingSpaces(p.value)
+	integer := leadingDigits(value)
+	if integer == "" {
+		return Length{}, &PropertyLengthValueError{value: p.value}
+	}
+
+	rest := value[len(integer):]
+	number := integer
+
+	// A dot without digits ends the number, so "5.%" is 5 pixels
+	if strings.HasPrefix(rest, ".") {
+		fraction := leadingDigits(rest[1:])
+		if fraction == "" {
+			rest = ""
+		} else {
+			number += "." + fraction
+			rest = rest[1+len(fraction):]
+		}
+	}
+
+	parsed, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(parsed, 0) {
+		return Length{}, &PropertyLengthValueError{value: p.value}
+	}
+
+	if strings.HasPrefix(rest, "%") {
+		return Length{Value: parsed, Unit: Percentage}, nil
+	}
+
+	return Length{Value: parsed, Unit: Pixels}, nil
+}
+
+// URL parses the value, without its surrounding whitespace, as a URL
+// relative to base. An empty base leaves the URL unresolved.
+func (p *Property) URL(base string) (*url.URL, error) {
+	reference, err := url.Parse(strings.Trim(p.value, " \t\n\r\f"))
+	if err != nil {
+		return nil, &PropertyURLValueError{value: p.value, cause: err}
+	}
+
+	if base == "" {
+		return reference, nil
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, &PropertyURLValueError{value: base, cause: err}
+	}
+
+	return baseURL.ResolveReference(reference), nil
+}
+
+// Enum matches the value against the keywords of an enumerated attribute,
+// ignoring ASCII case, and returns the keyword as it was given.
+func (p *Property) Enum(allowed ...string) (string, error) {
+	for _, keyword := range allowed {
+		if strings.EqualFold(p.value, keyword) {
+			return keyword, nil
+		}
+	}
+
+	return "", &PropertyEnumValueError{value: p.value, allowed: allowed}
+}
+
+// CommaList splits the value on commas and trims the whitespace around each
+// item, as done for accept and sizes.
+func (p *Property) CommaList() []string {
+	items := []string{}
+	if strings.Trim(p.value, " \t\n\r\f") == "" {
+		return items
+	}
+
+	for _, item := range strings.Split(p.value, ",") {
+		items = append(items, strings.Trim(item, " \t\n\r\f"))
+	}
+
+	return items
+}
+
+// parseHtmlFloat reads a number with an optional sign, fraction and exponent
+// at the start of the value and returns the text that follows it.
+func parseHtmlFloat(value string) (float64, string, bool) {
+	value = trimLeadingSpaces(value)
+	start := value
+
+	if value != "" && (value[0] == '-' || value[0] == '+') {
+		value = value[1:]
+	}
+
+	integer := leadingDigits(value)
+	value = value[len(integer):]
+
+	fraction := ""
+	if strings.HasPrefix(value, ".") {
+		fraction = leadingDigits(value[1:])
+		if fraction != "" {
+			value = value[1+len(fraction):]
+		}
+	}
+
+	if integer == "" && fraction == "" {
+		return 0, "", false
+	}
+
+	if value != "" && (value[0] == 'e' || value[0] == 'E') {
+		exponent := value[1:]
+		if exponent != "" && (exponent[0] == '-' || exponent[0] == '+') {
+			exponent = exponent[1:]
+		}
+
+		if digits := leadingDigits(exponent); digits != "" {
+			value = exponent[len(digits):]
+		}
+	}
+
+	number, err := strconv.ParseFloat(start[:len(start)-len(value)], 64)
+	if err != nil || math.IsInf(number, 0) {
+		return 0, "", false
+	}
+
+	return number, value, true
+}
+
+func trimLeadingSpaces(value string) string {
+	return strings.TrimLeft(value, " \t\n\r\f")
+}
+
+func leadingDigits(value string) string {
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	return value[:end]
+}
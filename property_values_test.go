@@ -0,0 +1,201 @@
+package parseme
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Int(t *testing.T) {
+	testcases := []struct {
+		name        string
+		value       string
+		expected    int
+		expectedErr error
+	}{
+		{"plain number", "42", 42, nil},
+		{"leading whitespace", " \t\n12", 12, nil},
+		{"negative number", "-7", -7, nil},
+		{"positive sign", "+3", 3, nil},
+		{"trailing characters", "12px", 12, nil},
+		{"fraction is ignored", "3.9", 3, nil},
+		{"empty value", "", 0, &PropertyIntValueError{value: ""}},
+		{"no digits", "px", 0, &PropertyIntValueError{value: "px"}},
+		{"sign without digits", "- 1", 0, &PropertyIntValueError{value: "- 1"}},
+		{"too large", "99999999999999999999", 0, &PropertyIntValueError{value: "99999999999999999999"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, err := NewProperty(Value, "tabindex", tc.value).Int()
+
+			if tc.expectedErr != nil {
+				assert.Equal(err, tc.expectedErr)
+				assert.EqualError(err, tc.expectedErr.Error())
+			} else {
+				assert.Nil(err)
+				assert.Equal(result, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_Float(t *testing.T) {
+	testcases := []struct {
+		name        string
+		value       string
+		expected    float64
+		expectedErr error
+	}{
+		{"integer", "2", 2, nil},
+		{"fraction", "-1.25", -1.25, nil},
+		{"leading dot", ".5", 0.5, nil},
+		{"trailing dot", "5.", 5, nil},
+		{"exponent", "1e3", 1000, nil},
+		{"negative exponent", "25E-1", 2.5, nil},
+		{"incomplete exponent", "2e", 2, nil},
+		{"trailing characters", " 0.75em", 0.75, nil},
+		{"no digits", ".e1", 0, &PropertyFloatValueError{value: ".e1"}},
+		{"infinite", "1e999", 0, &PropertyFloatValueError{value: "1e999"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, err := NewProperty(Value, "step", tc.value).Float()
+
+			if tc.expectedErr != nil {
+				assert.Equal(err, tc.expectedErr)
+				assert.EqualError(err, tc.expectedErr.Error())
+			} else {
+				assert.Nil(err)
+				assert.Equal(result, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_Length(t *testing.T) {
+	testcases := []struct {
+		name        string
+		value       string
+		expected    Length
+		expectedErr error
+	}{
+		{"pixels", "100", Length{Value: 100, Unit: Pixels}, nil},
+		{"pixels with unit", "100px", Length{Value: 100, Unit: Pixels}, nil},
+		{"percentage", "50%", Length{Value: 50, Unit: Percentage}, nil},
+		{"fractional percentage", " 12.5% ", Length{Value: 12.5, Unit: Percentage}, nil},
+		{"space before percent sign", "50 %", Length{Value: 50, Unit: Pixels}, nil},
+		{"negative", "-1", Length{}, &PropertyLengthValueError{value: "-1"}},
+		{"no digits", "auto", Length{}, &PropertyLengthValueError{value: "auto"}},
+		{"plus sign", "+5", Length{}, &PropertyLengthValueError{value: "+5"}},
+		{"leading dot", ".5", Length{}, &PropertyLengthValueError{value: ".5"}},
+		{"exponent is ignored", "1e2", Length{Value: 1, Unit: Pixels}, nil},
+		{"exponent percentage", "1e2%", Length{Value: 1, Unit: Pixels}, nil},
+		{"dot without fraction", "5.%", Length{Value: 5, Unit: Pixels}, nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, err := NewProperty(Value, "width", tc.value).Length()
+
+			if tc.expectedErr != nil {
+				assert.Equal(err, tc.expectedErr)
+				assert.EqualError(err, tc.expectedErr.Error())
+			} else {
+				assert.Nil(err)
+				assert.Equal(result, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_URL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		base     string
+		expected string
+	}{
+		{"absolute", "https://example.com/a", "", "https://example.com/a"},
+		{"relative without base", "../b", "", "../b"},
+		{"relative with base", "../b?q=1", "https://example.com/x/y/z", "https://example.com/x/b?q=1"},
+		{"surrounding whitespace", "  /c \n", "https://example.com/x/", "https://example.com/c"},
+		{"absolute ignores base", "mailto:a@b.c", "https://example.com/", "mailto:a@b.c"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, err := NewProperty(Value, "href", tc.value).URL(tc.base)
+			assert.Nil(err)
+			assert.Equal(result.String(), tc.expected)
+		})
+	}
+}
+
+func Test_URLError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewProperty(Value, "href", "http://[::1").URL("")
+	assert.IsType(err, &PropertyURLValueError{})
+	var urlErr *url.Error
+	assert.True(errors.As(err, &urlErr))
+
+	_, err = NewProperty(Value, "href", "/a").URL("%zz")
+	assert.IsType(err, &PropertyURLValueError{})
+}
+
+func Test_Enum(t *testing.T) {
+	testcases := []struct {
+		name        string
+		value       string
+		expected    string
+		expectedErr error
+	}{
+		{"exact match", "get", "get", nil},
+		{"case-insensitive match", "POST", "post", nil},
+		{"unknown keyword", "put", "", &PropertyEnumValueError{value: "put", allowed: []string{"get", "post", "dialog"}}},
+		{"surrounding whitespace", " get", "", &PropertyEnumValueError{value: " get", allowed: []string{"get", "post", "dialog"}}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, err := NewProperty(Value, "method", tc.value).Enum("get", "post", "dialog")
+
+			if tc.expectedErr != nil {
+				assert.Equal(err, tc.expectedErr)
+				assert.EqualError(err, tc.expectedErr.Error())
+			} else {
+				assert.Nil(err)
+				assert.Equal(result, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_CommaList(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"empty value", " ", []string{}},
+		{"single item", "image/png", []string{"image/png"}},
+		{"several items", " image/*, .pdf ,\taudio/* ", []string{"image/*", ".pdf", "audio/*"}},
+		{"empty items are kept", "a,,b", []string{"a", "", "b"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(NewProperty(Value, "accept", tc.value).CommaList(), tc.expected)
+		})
+	}
+}
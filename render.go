@@ -0,0 +1,245 @@
+package parseme
+
+import (
+	"io"
+	"strings"
+)
+
+var textEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"\u00a0", "&nbsp;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+var attributeEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"\u00a0", "&nbsp;",
+	"\"", "&quot;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+var singleQuotedAttributeEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"\u00a0", "&nbsp;",
+	"'", "&#39;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+type renderer struct {
+	writer   io.Writer
+	quote    QuoteStyle
+	lossless bool
+	err      error
+}
+
+// Render writes the node and its descendants to w following the HTML
+// fragment serialization algorithm. Documents render their children, every
+// other node renders itself. Nodes of a lossless tree are written as they
+// appeared in the source unless they were edited.
+func Render(w io.Writer, node *Element) error {
+	r := &renderer{writer: w, lossless: true}
+	r.render(node)
+	return r.err
+}
+
+func (r *renderer) write(values ...string) {
+	for _, value := range values {
+		if r.err != nil {
+			return
+		}
+
+		_, r.err = io.WriteString(r.writer, value)
+	}
+}
+
+func (r *renderer) render(node *Element) {
+	if r.lossless && node.source != nil {
+		r.renderSource(node)
+		return
+	}
+
+	switch node.elementType {
+	case DocumentElement:
+		r.renderChildren(node)
+	case TagElement:
+		r.renderTag(node)
+	case TextElement:
+		r.renderText(node)
+	case CommentElement:
+		r.write("<!--", node.value, "-->")
+	case CDATAElement:
+		r.write("<![CDATA[", node.value, "]]>")
+	case DoctypeElement:
+		r.renderDoctype(node)
+	case DeclarationElement:
+		r.write("<?xml")
+		for _, property := range node.properties {
+			r.write(" ", property.name, "=\"", property.value, "\"")
+		}
+		r.write("?>")
+	case InstructionElement:
+		r.renderInstruction(node)
+	}
+}
+
+func (r *renderer) renderChildren(node *Element) {
+	for child := node.firstChild; child != nil; child = child.nextSibling {
+		r.render(child)
+	}
+}
+
+func (r *renderer) renderTag(node *Element) {
+	r.renderStartTag(node)
+
+	if node.isVoid() {
+		return
+	}
+
+	r.renderChildren(node)
+	r.write("</", node.name, ">")
+}
+
+func (r *renderer) renderStartTag(node *Element) {
+	r.write("<", node.name)
+	for _, property := range node.properties {
+		r.renderProperty(property)
+	}
+	r.write(">")
+}
+
+func (r *renderer) renderProperty(property *Property) {
+	value := property.value
+	if property.IsBoolean() {
+		// A boolean property is true by its presence alone
+		if property.value == "false" {
+			return
+		}
+
+		if property.literal == "" {
+			r.write(" ", property.name)
+			return
+		}
+		value = property.literal
+	}
+
+	if r.quote == SingleQuotes {
+		r.write(" ", property.name, "='", singleQuotedAttributeEscaper.Replace(value), "'")
+		return
+	}
+
+	r.write(" ", property.name, "=\"", attributeEscaper.Replace(value), "\"")
+}
+
+func (r *renderer) renderText(node *Element) {
+	if parent := node.parent; parent != nil && parent.elementType == TagElement && rawTextElements[parent.name] {
+		r.write(node.value)
+		return
+	}
+
+	r.write(textEscaper.Replace(node.value))
+}
+
+func (r *renderer) renderDoctype(node *Element) {
+	r.write("<!DOCTYPE ", node.name)
+	if node.value != "" {
+		r.write(" ", node.value)
+	}
+	r.write(">")
+}
+
+func (r *renderer) renderInstruction(node *Element) {
+	r.write("<?", node.name)
+	if node.value != "" {
+		r.write(" ", node.value)
+	}
+	r.write("?>")
+}
+
+func (r *renderer) renderSource(node *Element) {
+	source := node.source
+
+	if node.elementType == DocumentElement {
+		r.write(source.head)
+		r.renderChildren(node)
+		return
+	}
+
+	if node.elementType != TagElement {
+		if node.name == source.name && node.value == source.value {
+			r.write(source.head)
+		} else {
+			r.lossless = false
+			r.render(node)
+			r.lossless = true
+		}
+		return
+	}
+
+	renamed := node.name != source.name
+	if renamed {
+		r.write("<", node.name)
+	} else {
+		r.write(source.head)
+	}
+
+	for _, property := range node.properties {
+		r.renderPropertySource(property)
+	}
+	r.write(source.closing)
+
+	r.renderChildren(node)
+
+	// The end tag was implied in the source
+	if source.end == "" {
+		return
+	}
+
+	if renamed {
+		r.write("</", node.name, ">")
+	} else {
+		r.write(source.end)
+	}
+}
+
+func (r *renderer) renderPropertySource(property *Property) {
+	source := property.source
+	if source == nil {
+		r.renderProperty(property)
+		return
+	}
+
+	if source.matches(property) {
+		r.write(source.dropped, source.prefix, source.raw)
+		return
+	}
+
+	name := property.name
+	if name == source.name {
+		name = source.rawName
+	}
+
+	r.write(source.prefix)
+
+	value := property.value
+	if property.IsBoolean() {
+		if property.value == "false" {
+			return
+		}
+
+		if property.literal == "" {
+			r.write(name)
+			return
+		}
+		value = property.literal
+	}
+
+	switch source.quote {
+	case '\'':
+		r.write(name, "='", singleQuotedAttributeEscaper.Replace(value), "'")
+	default:
+		r.write(name, "=\"", attributeEscaper.Replace(value), "\"")
+	}
+}
@@ -0,0 +1,163 @@
+package parseme
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func Test_Render(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			"nested tags",
+			"<html><p>This is a paragraph</p></html>",
+			"<html><p>This is a paragraph</p></html>",
+		},
+		{
+			"implied end tags are written",
+			"<ul><li>a<li>b</ul>",
+			"<ul><li>a</li><li>b</li></ul>",
+		},
+		{
+			"void elements have no end tag",
+			"<p>a<br>b<img src=x></p>",
+			"<p>a<br>b<img src=\"x\"></p>",
+		},
+		{
+			"text is escaped",
+			"<p>a &lt; b &amp;&amp; c &gt; d&nbsp;e</p>",
+			"<p>a &lt; b &amp;&amp; c &gt; d&nbsp;e</p>",
+		},
+		{
+			"property values are escaped",
+			"<a title='say \"hi\" & <bye>'></a>",
+			"<a title=\"say &quot;hi&quot; &amp; &lt;bye&gt;\"></a>",
+		},
+		{
+			"boolean property",
+			"<input type=checkbox checked>",
+			"<input type=\"checkbox\" checked>",
+		},
+		{
+			"raw text is not escaped",
+			"<script>if (a < b && c) {}</script><style>a > b {}</style>",
+			"<script>if (a < b && c) {}</script><style>a > b {}</style>",
+		},
+		{
+			"escapable raw text is escaped",
+			"<textarea>a < b</textarea>",
+			"<textarea>a &lt; b</textarea>",
+		},
+		{
+			"comments and doctype",
+			"<!doctype html><!-- note --><p></p>",
+			"<!DOCTYPE html><!-- note --><p></p>",
+		},
+		{
+			"bogus comment",
+			"<?php echo 1 ?>",
+			"<!--?php echo 1 ?-->",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			buffer := &bytes.Buffer{}
+			err := Render(buffer, document)
+			assert.Nil(err)
+			assert.Equal(buffer.String(), tc.expected)
+		})
+	}
+}
+
+func Test_RenderXml(t *testing.T) {
+	assert := assert.New(t)
+	input := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><html xmlns=\"http://www.w3.org/1999/xhtml\">" +
+		"<?pi data?><body><br/><![CDATA[a<b]]></body></html>"
+	document, _, _ := parseString(XmlMode, input)
+
+	buffer := &bytes.Buffer{}
+	err := Render(buffer, document)
+
+	assert.Nil(err)
+	assert.Equal(buffer.String(), "<?xml version=\"1.0\" encoding=\"UTF-8\"?><html xmlns=\"http://www.w3.org/1999/xhtml\">"+
+		"<?pi data?><body><br></br><![CDATA[a<b]]></body></html>")
+}
+
+func Test_RenderXmlVoidNames(t *testing.T) {
+	assert := assert.New(t)
+	input := "<r><img></img><br>x</br><input/></r>"
+	document, _, err := parseString(XmlMode, input)
+	assert.Nil(err)
+
+	// XML has no void elements, so the end tags and children are kept
+	buffer := &bytes.Buffer{}
+	assert.Nil(Render(buffer, document))
+	assert.Equal(buffer.String(), "<r><img></img><br>x</br><input></input></r>")
+
+	reparsed, _, err := parseString(XmlMode, buffer.String())
+	assert.Nil(err)
+	assert.Equal(outline(reparsed), outline(document))
+}
+
+func Test_RenderNode(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<div><p class=a>text</p></div>")
+
+	buffer := &bytes.Buffer{}
+	err := Render(buffer, document.firstChild.firstChild)
+
+	assert.Nil(err)
+	assert.Equal(buffer.String(), "<p class=\"a\">text</p>")
+}
+
+func Test_RenderWriterError(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<p>text</p>")
+
+	err := Render(&failingWriter{}, document)
+
+	assert.EqualError(err, "write failed")
+}
+
+func Test_RenderRoundTrip(t *testing.T) {
+	testcases := []struct {
+		name     string
+		filepath string
+	}{
+		{"html file", "test_data/example1.html"},
+		{"unicode file", "test_data/example2.html"},
+		{"empty file", "test_data/example3.html"},
+		{"file with recovered markup", "test_data/example4.html"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, err := NewHtmlParser(tc.filepath).ParseDocument()
+			assert.Nil(err)
+
+			buffer := &bytes.Buffer{}
+			Render(buffer, document)
+			rendered := buffer.Bytes()
+			reparsed, err := NewHtmlParser("").ParseBytes(rendered)
+
+			assert.Nil(err)
+			assert.Equal(outline(reparsed), outline(document))
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package parseme
+
+import (
+	"net/url"
+	"strings"
+)
+
+type ResourceKind int
+
+const (
+	HyperlinkResource ResourceKind = iota
+	StylesheetResource
+	ScriptResource
+	ImageResource
+	MediaResource
+	FrameResource
+	ObjectResource
+	FormResource
+	CitationResource
+	RefreshResource
+	IconResource
+	// LinkResource covers the link elements that are not stylesheets or
+	// icons, such as preloads and alternate versions.
+	LinkResource
+	// CssResource is a url() in a style property or element.
+	CssResource
+)
+
+// Resource is a URL found in a document.
+type Resource struct {
+	Element *Element
+	// Property holds the URL, nil for URLs in the content of a style
+	// element.
+	Property *Property
+	Kind     ResourceKind
+	// Raw is the URL as written.
+	Raw string
+	// URL is Raw resolved against the base URL, nil when it cannot be
+	// parsed.
+	URL *url.URL
+}
+
+// Resources returns the URLs of the document in document order. They are
+// resolved against the first base element with an href, which is itself
+// resolved against documentURL. documentURL may be nil, in which case
+// relative URLs stay relative.
+func (e *Element) Resources(documentURL *url.URL) []*Resource {
+	collector := &resourceCollector{base: documentURL}
+
+	if base, _ := e.QuerySelector("base[href]"); base != nil && !base.Property("href").IsBoolean() {
+		if resolved := collector.resolve(base.Property("href").value); resolved != nil {
+			collector.base = resolved
+		}
+	}
+
+	for element := range e.Descendants() {
+		if element.elementType == TagElement {
+			collector.collect(element)
+		}
+	}
+
+	return collector.resources
+}
+
+type resourceCollector struct {
+	// base is nil when there is neither a document URL nor a base element
+	base      *url.URL
+	resources []*Resource
+}
+
+func (c *resourceCollector) resolve(raw string) *url.URL {
+	reference, err := url.Parse(strings.Trim(raw, cssSpaces))
+	if err != nil || c.base == nil {
+		return reference
+	}
+
+	return c.base.ResolveReference(reference)
+}
+
+func (c *resourceCollector) add(element *Element, property *Property, kind ResourceKind, raw string) {
+	c.resources = append(c.resources, &Resource{
+		Element:  element,
+		Property: property,
+		Kind:     kind,
+		Raw:      raw,
+		URL:      c.resolve(raw),
+	})
+}
+
+func (c *resourceCollector) collect(element *Element) {
+	name := strings.ToLower(element.LocalName())
+
+	for _, property := range element.properties {
+		if property.IsBoolean() {
+			continue
+		}
+
+		switch strings.ToLower(property.name) {
+		case "href", "xlink:href":
+			if name != "base" {
+				c.add(element, property, hrefKind(element, name), property.value)
+			}
+		case "src":
+			c.add(element, property, srcKind(element, name), property.value)
+		case "srcset":
+			for _, candidate := range property.Srcset() {
+				c.add(element, property, ImageResource, candidate.URL)
+			}
+		case "poster":
+			c.add(element, property, ImageResource, property.value)
+		case "data":
+			if name == "object" {
+				c.add(element, property, ObjectResource, property.value)
+			}
+		case "action", "formaction":
+			c.add(element, property, FormResource, property.value)
+		case "cite":
+			c.add(element, property, CitationResource, property.value)
+		case "content":
+			if raw, ok := refreshURL(element, name, property); ok {
+				c.add(element, property, RefreshResource, raw)
+			}
+		case "style":
+			for _, declaration := range property.Style().Declarations() {
+				for _, raw := range cssURLs(declaration.Value) {
+					c.add(element, property, CssResource, raw)
+				}
+			}
+		}
+	}
+
+	if name == "style" {
+		for _, rule := range parseStyleElement(element).All() {
+			if rule.Type == ImportRule {
+				c.add(element, nil, StylesheetResource, rule.Href)
+			}
+			for _, declaration := range rule.Declarations {
+				for _, raw := range cssURLs(declaration.Value) {
+					c.add(element, nil, CssResource, raw)
+				}
+			}
+		}
+	}
+}
+
+func hrefKind(element *Element, name string) ResourceKind {
+	switch name {
+	case "link":
+		if rel := element.Property("rel"); rel != nil {
+			for _, token := range rel.TokenList().Values() {
+				switch strings.ToLower(token) {
+				case "stylesheet":
+					return StylesheetResource
+				case "icon", "apple-touch-icon":
+					return IconResource
+				}
+			}
+		}
+		return LinkResource
+	case "image", "feimage":
+		return ImageResource
+	case "script":
+		return ScriptResource
+	}
+
+	return HyperlinkResource
+}
+
+func srcKind(element *Element, name string) ResourceKind {
+	switch name {
+	case "script":
+		return ScriptResource
+	case "iframe", "frame":
+		return FrameResource
+	case "embed":
+		return ObjectResource
+	case "audio", "video", "track":
+		return MediaResource
+	case "source":
+		if parent := parentElement(element); parent != nil && (strings.EqualFold(parent.name, "audio") || strings.EqualFold(parent.name, "video")) {
+			return MediaResource
+		}
+	}
+
+	return ImageResource
+}
+
+// refreshURL reads the URL of a meta refresh from its content property, as
+// in content="5; url=/next".
+func refreshURL(element *Element, name string, content *Property) (string, bool) {
+	equiv := element.Property("http-equiv")
+	if name != "meta" || content == nil || equiv == nil || !strings.EqualFold(strings.Trim(equiv.value, cssSpaces), "refresh") {
+		return "", false
+	}
+
+	value := strings.TrimLeft(content.value, cssSpaces)
+	value = strings.TrimLeft(value, "0123456789.")
+	value = strings.TrimLeft(value, cssSpaces)
+	if value == "" || (value[0] != ';' && value[0] != ',') {
+		return "", false
+	}
+
+	value = strings.TrimLeft(value[1:], cssSpaces)
+	if len(value) >= 3 && strings.EqualFold(value[:3], "url") {
+		rest := strings.TrimLeft(value[3:], cssSpaces)
+		if strings.HasPrefix(rest, "=") {
+			value = strings.TrimLeft(rest[1:], cssSpaces)
+		}
+	}
+
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			value = value[1 : end+1]
+		} else {
+			value = value[1:]
+		}
+	}
+
+	value = strings.Trim(value, cssSpaces)
+	return value, value != ""
+}
+
+// cssURLs returns the arguments of the url() functions of a CSS value.
+func cssURLs(value string) []string {
+	urls := []string{}
+
+	scanCss(value, func(index int, depth int) bool {
+		if index+4 > len(value) || !strings.EqualFold(value[index:index+4], "url(") {
+			return true
+		}
+		if index > 0 && isCssNameByte(value[index-1]) {
+			return true
+		}
+
+		end := indexCss(value[index+4:], ')')
+		if end < 0 {
+			end = len(value) - index - 4
+		}
+
+		argument := strings.Trim(value[index+4:index+4+end], cssSpaces)
+		if len(argument) >= 2 && (argument[0] == '"' || argument[0] == '\'') && argument[len(argument)-1] == argument[0] {
+			argument = argument[1 : len(argument)-1]
+		}
+		if argument != "" {
+			urls = append(urls, argument)
+		}
+		return true
+	})
+
+	return urls
+}
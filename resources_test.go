@@ -0,0 +1,131 @@
+package parseme
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// describeResources lists resources as element, property, kind and URL.
+func describeResources(resources []*Resource) [][]any {
+	result := [][]any{}
+	for _, resource := range resources {
+		property, resolved := "", "<nil>"
+		if resource.Property != nil {
+			property = resource.Property.Name()
+		}
+		if resource.URL != nil {
+			resolved = resource.URL.String()
+		}
+		result = append(result, []any{resource.Element.Name(), property, resource.Kind, resolved})
+	}
+
+	return result
+}
+
+func Test_Resources(t *testing.T) {
+	assert := assert.New(t)
+	input := `<html><head>
+<base href="/docs/">
+<meta http-equiv="Refresh" content="5; URL='next.html'">
+<link rel="stylesheet" href="main.css"><link rel="icon" href="/favicon.ico"><link rel=preload href=font.woff2>
+<style>@import "print.css"; body { background: url(bg.png) } .a { mask: url( "m.svg#x" ) }</style>
+<script src="https://cdn.example.com/app.js"></script>
+</head><body>
+<a href=" guide.html#intro ">Guide</a><a href>empty</a>
+<img src="a.png" srcset="a-2x.png 2x, b,c.png 3x,d.png">
+<video src=movie.mp4 poster=poster.jpg><source src=movie.webm><track src=subs.vtt></video>
+<picture><source srcset="wide.webp"></picture>
+<form action="/search"><button formaction="save">Save</button></form>
+<blockquote cite="http://example.org/quote"></blockquote>
+<object data="movie.swf"></object><iframe src="frame.html"></iframe>
+<div style="background-image: url('hero.jpg'), url(data:image/png;base64,AA==)" data="ignored"></div>
+<svg><use xlink:href="#icon"></use></svg>
+</body></html>`
+	document, _, _ := parseString(HtmlMode, input)
+	documentURL, _ := url.Parse("https://example.com/en/index.html")
+
+	resources := document.Resources(documentURL)
+	assert.Equal(describeResources(resources), [][]any{
+		{"meta", "content", RefreshResource, "https://example.com/docs/next.html"},
+		{"link", "href", StylesheetResource, "https://example.com/docs/main.css"},
+		{"link", "href", IconResource, "https://example.com/favicon.ico"},
+		{"link", "href", LinkResource, "https://example.com/docs/font.woff2"},
+		{"style", "", StylesheetResource, "https://example.com/docs/print.css"},
+		{"style", "", CssResource, "https://example.com/docs/bg.png"},
+		{"style", "", CssResource, "https://example.com/docs/m.svg#x"},
+		{"script", "src", ScriptResource, "https://cdn.example.com/app.js"},
+		{"a", "href", HyperlinkResource, "https://example.com/docs/guide.html#intro"},
+		{"a", "href", HyperlinkResource, "https://example.com/docs/"},
+		{"img", "src", ImageResource, "https://example.com/docs/a.png"},
+		{"img", "srcset", ImageResource, "https://example.com/docs/a-2x.png"},
+		{"img", "srcset", ImageResource, "https://example.com/docs/b,c.png"},
+		{"img", "srcset", ImageResource, "https://example.com/docs/d.png"},
+		{"video", "src", MediaResource, "https://example.com/docs/movie.mp4"},
+		{"video", "poster", ImageResource, "https://example.com/docs/poster.jpg"},
+		{"source", "src", MediaResource, "https://example.com/docs/movie.webm"},
+		{"track", "src", MediaResource, "https://example.com/docs/subs.vtt"},
+		{"source", "srcset", ImageResource, "https://example.com/docs/wide.webp"},
+		{"form", "action", FormResource, "https://example.com/search"},
+		{"button", "formaction", FormResource, "https://example.com/docs/save"},
+		{"blockquote", "cite", CitationResource, "http://example.org/quote"},
+		{"object", "data", ObjectResource, "https://example.com/docs/movie.swf"},
+		{"iframe", "src", FrameResource, "https://example.com/docs/frame.html"},
+		{"div", "style", CssResource, "https://example.com/docs/hero.jpg"},
+		{"div", "style", CssResource, "data:image/png;base64,AA=="},
+		{"use", "xlink:href", HyperlinkResource, "https://example.com/docs/#icon"},
+	})
+	assert.Equal(resources[8].Raw, " guide.html#intro ")
+}
+
+func Test_ResourcesWithoutBase(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, `<a href="../a b.html"></a><img src="http://[::1">`)
+
+	resources := document.Resources(nil)
+	assert.Equal(describeResources(resources), [][]any{
+		{"a", "href", HyperlinkResource, "../a%20b.html"},
+		{"img", "src", ImageResource, "<nil>"},
+	})
+}
+
+func Test_refreshURL(t *testing.T) {
+	testcases := []struct {
+		content  string
+		expected string
+	}{
+		{"0; url=/a", "/a"},
+		{"5,/b", "/b"},
+		{"1.5 ;  URL = \"/c\" ", "/c"},
+		{"3; 'd", "d"},
+		{"10", ""},
+		{"x; url=/e", ""},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.content, func(t *testing.T) {
+			assert := assert.New(t)
+			element := NewElement(TagElement, "meta", "")
+			element.SetProperty("http-equiv", "refresh")
+			element.SetProperty("content", tc.content)
+
+			raw, _ := refreshURL(element, "meta", element.Property("content"))
+			assert.Equal(raw, tc.expected)
+		})
+	}
+}
+
+func Test_ResourcesRefreshCase(t *testing.T) {
+	assert := assert.New(t)
+	document, _, err := parseString(XmlMode, "<html><meta http-equiv=\"refresh\" Content=\"0;url=/x\"/></html>")
+	assert.Nil(err)
+
+	var resources []*Resource
+	assert.NotPanics(func() {
+		resources = document.Resources(nil)
+	})
+	assert.Len(resources, 1)
+	assert.Equal(resources[0].Raw, "/x")
+	assert.Equal(resources[0].Kind, RefreshResource)
+}
@@ -0,0 +1,466 @@
+package parseme
+
+import (
+	"strings"
+)
+
+// DisallowedAction decides what a SanitizePolicy does with elements it does
+// not allow.
+type DisallowedAction int
+
+const (
+	// UnwrapDisallowedElements removes the tags and keeps their sanitized
+	// contents. The contents of script, style and the other raw text
+	// elements are removed with them.
+	UnwrapDisallowedElements DisallowedAction = iota
+	// DropDisallowedElements removes the elements with their contents.
+	DropDisallowedElements
+	// EscapeDisallowedElements turns the tags into text, so that they are
+	// shown instead of interpreted.
+	EscapeDisallowedElements
+)
+
+// SanitizePolicy is an allowlist of the elements, properties, URL schemes
+// and style properties that may appear in sanitized HTML. Everything it
+// does not allow is removed. The zero policy, from NewSanitizePolicy,
+// allows nothing but text.
+type SanitizePolicy struct {
+	elements     map[string]bool
+	attributes   map[string]map[string]bool
+	global       map[string]bool
+	schemes      map[string]bool
+	relativeURLs bool
+	rel          []string
+	styles       map[string]bool
+	comments     bool
+	disallowed   DisallowedAction
+}
+
+func NewSanitizePolicy() *SanitizePolicy {
+	return &SanitizePolicy{
+		elements:   map[string]bool{},
+		attributes: map[string]map[string]bool{},
+		global:     map[string]bool{},
+		schemes:    map[string]bool{},
+		styles:     map[string]bool{},
+	}
+}
+
+// AllowElements allows the elements with the given names. Script, style and
+// the other raw text elements are never allowed, since their contents
+// cannot be checked.
+func (p *SanitizePolicy) AllowElements(names ...string) *SanitizePolicy {
+	for _, name := range names {
+		p.elements[strings.ToLower(name)] = true
+	}
+
+	return p
+}
+
+// AllowAttributes allows properties with the given names on an element.
+// The element itself must be allowed with AllowElements.
+func (p *SanitizePolicy) AllowAttributes(element string, names ...string) *SanitizePolicy {
+	element = strings.ToLower(element)
+	if p.attributes[element] == nil {
+		p.attributes[element] = map[string]bool{}
+	}
+
+	for _, name := range names {
+		p.attributes[element][strings.ToLower(name)] = true
+	}
+
+	return p
+}
+
+// AllowGlobalAttributes allows properties with the given names on every
+// allowed element.
+func (p *SanitizePolicy) AllowGlobalAttributes(names ...string) *SanitizePolicy {
+	for _, name := range names {
+		p.global[strings.ToLower(name)] = true
+	}
+
+	return p
+}
+
+// AllowURLSchemes allows URLs with the given schemes, such as "https" or
+// "mailto", in href, src and the other properties holding URLs. Properties
+// whose URL has any other scheme are removed.
+func (p *SanitizePolicy) AllowURLSchemes(schemes ...string) *SanitizePolicy {
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(strings.TrimSuffix(scheme, ":"))] = true
+	}
+
+	return p
+}
+
+// AllowRelativeURLs allows URLs without a scheme.
+func (p *SanitizePolicy) AllowRelativeURLs(allow bool) *SanitizePolicy {
+	p.relativeURLs = allow
+	return p
+}
+
+// RequireRel adds the given tokens, such as "nofollow", to the rel of every
+// link that keeps its href.
+func (p *SanitizePolicy) RequireRel(tokens ...string) *SanitizePolicy {
+	for _, token := range tokens {
+		for _, field := range strings.Fields(token) {
+			if !containsToken(p.rel, field) {
+				p.rel = append(p.rel, field)
+			}
+		}
+	}
+
+	return p
+}
+
+// AllowStyleProperties allows the given CSS properties in style properties,
+// which must be allowed themselves. Other declarations, and declarations
+// whose value loads resources or contains escapes, are removed.
+func (p *SanitizePolicy) AllowStyleProperties(names ...string) *SanitizePolicy {
+	for _, name := range names {
+		p.styles[normalizeCssProperty(name)] = true
+	}
+
+	return p
+}
+
+// AllowComments keeps comments, which are removed by default.
+func (p *SanitizePolicy) AllowComments(allow bool) *SanitizePolicy {
+	p.comments = allow
+	return p
+}
+
+func (p *SanitizePolicy) SetDisallowedAction(action DisallowedAction) *SanitizePolicy {
+	p.disallowed = action
+	return p
+}
+
+// StrictTextPolicy allows no markup at all and keeps only the text.
+func StrictTextPolicy() *SanitizePolicy {
+	return NewSanitizePolicy()
+}
+
+// UGCPolicy allows the formatting expected in user generated content such
+// as comments: text level markup, lists, quotes, code, simple tables and
+// images. Links are marked with rel="nofollow noopener".
+func UGCPolicy() *SanitizePolicy {
+	return NewSanitizePolicy().
+		AllowElements(
+			"a", "abbr", "b", "blockquote", "br", "caption", "cite", "code", "dd", "del", "details",
+			"dfn", "dl", "dt", "em", "figcaption", "figure", "h1", "h2", "h3", "h4", "h5", "h6", "hr",
+			"i", "img", "ins", "kbd", "li", "mark", "ol", "p", "pre", "q", "rp", "rt", "ruby", "s",
+			"samp", "small", "span", "strike", "strong", "sub", "summary", "sup", "table", "tbody",
+			"td", "tfoot", "th", "thead", "time", "tr", "u", "ul", "var", "wbr",
+		).
+		AllowGlobalAttributes("dir", "lang", "title").
+		AllowAttributes("a", "href").
+		AllowAttributes("img", "src", "alt", "width", "height").
+		AllowAttributes("blockquote", "cite").
+		AllowAttributes("q", "cite").
+		AllowAttributes("del", "cite", "datetime").
+		AllowAttributes("ins", "cite", "datetime").
+		AllowAttributes("time", "datetime").
+		AllowAttributes("ol", "start", "reversed", "type").
+		AllowAttributes("li", "value").
+		AllowAttributes("code", "class").
+		AllowAttributes("td", "colspan", "rowspan", "align").
+		AllowAttributes("th", "colspan", "rowspan", "align", "scope").
+		AllowAttributes("details", "open").
+		AllowURLSchemes("http", "https", "mailto").
+		AllowRelativeURLs(true).
+		RequireRel("nofollow", "noopener")
+}
+
+// EmailPolicy allows the presentational markup and inline styles HTML
+// email relies on, including images embedded with cid URLs.
+func EmailPolicy() *SanitizePolicy {
+	return UGCPolicy().
+		AllowElements("center", "col", "colgroup", "div", "font", "section", "header", "footer", "main", "article").
+		AllowGlobalAttributes("align", "style").
+		AllowAttributes("a", "name", "target").
+		AllowAttributes("img", "border", "hspace", "vspace").
+		AllowAttributes("font", "color", "face", "size").
+		AllowAttributes("table", "width", "border", "cellpadding", "cellspacing", "bgcolor", "role").
+		AllowAttributes("tr", "bgcolor", "valign").
+		AllowAttributes("td", "width", "height", "valign", "bgcolor", "nowrap").
+		AllowAttributes("th", "width", "height", "valign", "bgcolor", "nowrap").
+		AllowAttributes("col", "span", "width").
+		AllowAttributes("colgroup", "span", "width").
+		AllowURLSchemes("cid", "tel").
+		AllowStyleProperties(
+			"background-color", "border", "border-bottom", "border-collapse", "border-color",
+			"border-left", "border-radius", "border-right", "border-spacing", "border-style",
+			"border-top", "border-width", "color", "display", "font", "font-family", "font-size",
+			"font-style", "font-weight", "height", "letter-spacing", "line-height", "margin",
+			"margin-bottom", "margin-left", "margin-right", "margin-top", "max-width", "min-width",
+			"padding", "padding-bottom", "padding-left", "padding-right", "padding-top",
+			"text-align", "text-decoration", "text-transform", "vertical-align", "white-space",
+			"width",
+		)
+}
+
+// Elements whose contents are never kept when the elements are removed, as
+// they are not text meant to be shown.
+var sanitizeDroppedContents = map[string]bool{
+	"noscript":  true,
+	"plaintext": true,
+	"template":  true,
+	"title":     true,
+}
+
+// Properties holding a single URL.
+var sanitizeURLAttributes = map[string]bool{
+	"action":     true,
+	"background": true,
+	"cite":       true,
+	"codebase":   true,
+	"data":       true,
+	"formaction": true,
+	"href":       true,
+	"longdesc":   true,
+	"poster":     true,
+	"src":        true,
+	"xlink:href": true,
+}
+
+// CSS functions that cannot load resources or run code.
+var sanitizeCssFunctions = map[string]bool{
+	"calc": true,
+	"hsl":  true,
+	"hsla": true,
+	"rgb":  true,
+	"rgba": true,
+}
+
+// Sanitize removes everything the policy does not allow from the
+// descendants of node. The tree should be the one recovered by HtmlMode
+// parsing: sanitizing the tree rather than the markup means that broken
+// markup cannot hide anything from the policy. Sanitized nodes lose their
+// lossless source, so they are rendered from the tree.
+func (p *SanitizePolicy) Sanitize(node *Element) {
+	node.source = nil
+	p.sanitizeChildren(node)
+}
+
+// SanitizeHtml parses the input as an HTML fragment, sanitizes it and
+// renders the result.
+func (p *SanitizePolicy) SanitizeHtml(input string) string {
+	bytes := []byte(input)
+	document := newTreeBuilder(&bytes, HtmlMode, false, nil, KeepFirstAttribute, &stack[ErrorData]{}).build()
+	p.Sanitize(document)
+
+	builder := &strings.Builder{}
+	Render(builder, document)
+	return builder.String()
+}
+
+func (p *SanitizePolicy) sanitizeChildren(parent *Element) {
+	child := parent.firstChild
+	for child != nil {
+		next := child.nextSibling
+		p.sanitizeNode(child)
+		child = next
+	}
+}
+
+func (p *SanitizePolicy) sanitizeNode(node *Element) {
+	node.source = nil
+
+	switch node.elementType {
+	case TextElement:
+		return
+	case CommentElement:
+		if p.comments && isSafeComment(node.value) {
+			return
+		}
+	case TagElement:
+		name := strings.ToLower(node.name)
+		if p.elements[name] && !isRawTextElement(name) {
+			p.sanitizeChildren(node)
+			p.sanitizeProperties(node, name)
+			return
+		}
+
+		p.removeElement(node, name)
+		return
+	}
+
+	node.detach()
+}
+
+func (p *SanitizePolicy) removeElement(node *Element, name string) {
+	switch p.disallowed {
+	case DropDisallowedElements:
+		node.detach()
+	case EscapeDisallowedElements:
+		node.parent.insertBefore(NewElement(TextElement, "", startTagText(node)), node)
+
+		p.sanitizeChildren(node)
+		moveChildrenBefore(node)
+
+		if !isVoidElement(name) {
+			node.parent.insertBefore(NewElement(TextElement, "", "</"+node.name+">"), node)
+		}
+		node.detach()
+	default:
+		if rawTextElements[name] || sanitizeDroppedContents[name] {
+			node.detach()
+			return
+		}
+
+		p.sanitizeChildren(node)
+		moveChildrenBefore(node)
+		node.detach()
+	}
+}
+
+// startTagText writes the start tag of an escaped element as it will read
+// once rendered. The text is escaped when the document is rendered, so
+// the values are written as they are.
+func startTagText(node *Element) string {
+	builder := &strings.Builder{}
+	builder.WriteString("<" + node.name)
+
+	for _, property := range node.properties {
+		value := property.value
+		if property.IsBoolean() {
+			if property.value == "false" {
+				continue
+			}
+
+			if property.literal == "" {
+				builder.WriteString(" " + property.name)
+				continue
+			}
+			value = property.literal
+		}
+
+		quote := "\""
+		if strings.Contains(value, quote) && !strings.Contains(value, "'") {
+			quote = "'"
+		}
+		builder.WriteString(" " + property.name + "=" + quote + value + quote)
+	}
+
+	builder.WriteString(">")
+	return builder.String()
+}
+
+// isSafeComment reports whether the comment is written back as a single
+// comment. Values that could end it early, such as "--!>", are dropped
+// along with the comment.
+func isSafeComment(value string) bool {
+	return !strings.Contains(value, "--") && !strings.HasPrefix(value, ">") && !strings.HasPrefix(value, "->") &&
+		!strings.HasSuffix(value, "-") && !strings.Contains(value, "<!--")
+}
+
+func moveChildrenBefore(node *Element) {
+	for node.firstChild != nil {
+		child := node.firstChild
+		child.detach()
+		node.parent.insertBefore(child, node)
+	}
+}
+
+func (p *SanitizePolicy) sanitizeProperties(node *Element, name string) {
+	for _, property := range append([]*Property{}, node.properties...) {
+		property.source = nil
+		attribute := strings.ToLower(property.name)
+
+		allowed := p.global[attribute] || p.attributes[name][attribute]
+		switch {
+		case !allowed:
+		case sanitizeURLAttributes[attribute]:
+			allowed = p.allowsURL(property.value)
+		case attribute == "srcset":
+			for _, candidate := range property.Srcset() {
+				allowed = allowed && p.allowsURL(candidate.URL)
+			}
+		case attribute == "style":
+			allowed = p.sanitizeStyle(property)
+		}
+
+		if !allowed {
+			node.RemoveProperty(property.name)
+		}
+	}
+
+	if len(p.rel) > 0 && (name == "a" || name == "area") && node.Property("href") != nil {
+		if node.Property("rel") == nil {
+			node.SetProperty("rel", "")
+		}
+		node.Property("rel").TokenList().Add(p.rel...)
+	}
+}
+
+func (p *SanitizePolicy) allowsURL(value string) bool {
+	scheme, ok := urlScheme(value)
+	if !ok {
+		return p.relativeURLs
+	}
+
+	return p.schemes[scheme]
+}
+
+// urlScheme finds the lowercase scheme of a URL the way browsers do, after
+// removing the tabs and newlines they ignore and the leading control
+// characters. It reports false for relative URLs.
+func urlScheme(value string) (string, bool) {
+	value = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.TrimLeft(value, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x0b\x0c\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f ")
+
+	end := strings.IndexAny(value, ":/?#")
+	if end == -1 || value[end] != ':' {
+		return "", false
+	}
+
+	return strings.ToLower(value[:end]), true
+}
+
+// sanitizeStyle keeps the allowed declarations and reports whether any
+// are left.
+func (p *SanitizePolicy) sanitizeStyle(property *Property) bool {
+	kept := []Declaration{}
+	for _, declaration := range property.Style().Declarations() {
+		if p.styles[declaration.Property] && isSafeCssValue(declaration.Value) {
+			kept = append(kept, declaration)
+		}
+	}
+
+	if len(kept) == 0 {
+		return false
+	}
+
+	property.SetValue(serializeDeclarations(kept))
+	return true
+}
+
+// isSafeCssValue rejects escapes, which could spell anything, and functions
+// other than the ones listed in sanitizeCssFunctions.
+func isSafeCssValue(value string) bool {
+	if strings.ContainsAny(value, "\\<>") {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '(' {
+			continue
+		}
+
+		start := i
+		for start > 0 && isCssNameByte(value[start-1]) {
+			start--
+		}
+
+		if !sanitizeCssFunctions[strings.ToLower(value[start:i])] {
+			return false
+		}
+	}
+
+	return true
+}
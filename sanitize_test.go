@@ -0,0 +1,133 @@
+package parseme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SanitizeUGC(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"allowed markup", "<p>Hello <b>world</b></p>", "<p>Hello <b>world</b></p>"},
+		{"script", "<p>a<script>alert(1)</script>b</p>", "<p>ab</p>"},
+		{"unknown elements are unwrapped", "<div><font color=red>x</font></div>", "x"},
+		{"event handlers", "<img src='a.png' onerror='alert(1)'>", "<img src=\"a.png\">"},
+		{"javascript links", "<a href='javascript:alert(1)'>x</a>", "<a>x</a>"},
+		{"obfuscated scheme", "<a href=' java&#x09;script&colon;alert(1)'>x</a>", "<a>x</a>"},
+		{"allowed links", "<a href='https://example.com' rel='author'>x</a>", "<a href=\"https://example.com\" rel=\"nofollow noopener\">x</a>"},
+		{"relative links", "<a href='/page?a=1#b'>x</a>", "<a href=\"/page?a=1#b\" rel=\"nofollow noopener\">x</a>"},
+		{"data images", "<img src='data:image/png;base64,AAA' alt=x>", "<img alt=\"x\">"},
+		{"comments", "a<!-- <script>x</script> -->b", "ab"},
+		{"styles", "<p style='color: red'>x</p>", "<p>x</p>"},
+		{"malformed markup", "<p><b>a<i>b</p>c<svg><script>alert(1)</script></svg>", "<p><b>a<i>b</i></b></p>c"},
+		{"unclosed attribute", "<img src=x alt=\"<script>alert(1)</script>", ""},
+		{"broken tags", "<<script>script>alert(1)<</script>/script>", "&lt;/script&gt;"},
+		{"raw text", "<textarea><script>x</script></textarea><title><b>t</b></title>", "&lt;script&gt;x&lt;/script&gt;"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(UGCPolicy().SanitizeHtml(tc.value), tc.expected)
+		})
+	}
+}
+
+func Test_SanitizeStrictText(t *testing.T) {
+	assert := assert.New(t)
+	result := StrictTextPolicy().SanitizeHtml("<h1>Title</h1><p>a &lt; <a href='/'>b</a></p><style>p{}</style>")
+	assert.Equal(result, "Titlea &lt; b")
+}
+
+func Test_SanitizeEmail(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"inline images", "<img src='cid:logo@example' width=10>", "<img src=\"cid:logo@example\" width=\"10\">"},
+		{"tables", "<table bgcolor=#fff onclick=x><tr><td valign=top>a</td></tr></table>", "<table bgcolor=\"#fff\"><tr><td valign=\"top\">a</td></tr></table>"},
+		{"allowed styles", "<p style='color: red; position: fixed; font-size: 12px !important'>x</p>", "<p style=\"color: red; font-size: 12px !important;\">x</p>"},
+		{"style urls", "<div style='background-color: red; width: calc(100% - 2px); color: url(x); margin: e\\78pression(1)'>x</div>", "<div style=\"background-color: red; width: calc(100% - 2px);\">x</div>"},
+		{"no style left", "<p style='behavior: url(x.htc)'>x</p>", "<p>x</p>"},
+		{"style element", "<style>p { color: red }</style><p>x</p>", "<p>x</p>"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(EmailPolicy().SanitizeHtml(tc.value), tc.expected)
+		})
+	}
+}
+
+func Test_SanitizeDisallowedAction(t *testing.T) {
+	testcases := []struct {
+		name     string
+		action   DisallowedAction
+		expected string
+	}{
+		{"unwrap", UnwrapDisallowedElements, "<p>a b c</p>"},
+		{"drop", DropDisallowedElements, "<p>a  c</p>"},
+		{"escape", EscapeDisallowedElements, "<p>a &lt;span class=\"x\"&gt;b&lt;/span&gt; c&lt;script&gt;d()&lt;/script&gt;&lt;br&gt;</p>"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			policy := NewSanitizePolicy().AllowElements("p").SetDisallowedAction(tc.action)
+			assert.Equal(policy.SanitizeHtml("<p>a <span class=x>b</span> c<script>d()</script><br></p>"), tc.expected)
+		})
+	}
+}
+
+func Test_SanitizePolicy(t *testing.T) {
+	assert := assert.New(t)
+	policy := NewSanitizePolicy().
+		AllowElements("A", "img", "script").
+		AllowAttributes("a", "HREF").
+		AllowAttributes("img", "srcset").
+		AllowURLSchemes("https:").
+		AllowComments(true)
+
+	assert.Equal(policy.SanitizeHtml("<a href='https://x' title=t>a</a><a href='http://x'>b</a>"), "<a href=\"https://x\">a</a><a>b</a>")
+	assert.Equal(policy.SanitizeHtml("<a href='/x'>a</a><!-- c -->"), "<a>a</a><!-- c -->")
+	assert.Equal(policy.SanitizeHtml("<!-- a -- b --><!--->x-->"), "<!---->x--&gt;")
+	assert.Equal(policy.SanitizeHtml("<img srcset='https://a 1x, https://b 2x'><img srcset='https://a 1x, javascript:b 2x'>"), "<img srcset=\"https://a 1x, https://b 2x\"><img>")
+	assert.Equal(policy.SanitizeHtml("<script>x()</script>"), "")
+
+	policy.AllowRelativeURLs(true).RequireRel("noreferrer")
+	assert.Equal(policy.SanitizeHtml("<a href='/x'>a</a>"), "<a href=\"/x\" rel=\"noreferrer\">a</a>")
+}
+
+func Test_SanitizeLosslessTree(t *testing.T) {
+	assert := assert.New(t)
+	input := []byte("<p title=a title=\"<b>\" onclick=x>text</p>")
+	document := newTreeBuilder(&input, HtmlMode, true, nil, KeepFirstAttribute, &stack[ErrorData]{}).build()
+
+	NewSanitizePolicy().AllowElements("p").AllowGlobalAttributes("title").Sanitize(document)
+	builder := &strings.Builder{}
+	Render(builder, document)
+	assert.Equal(builder.String(), "<p title=\"a\">text</p>")
+}
+
+func Test_SanitizeEscapeAttributes(t *testing.T) {
+	assert := assert.New(t)
+	policy := NewSanitizePolicy().AllowElements("p").SetDisallowedAction(EscapeDisallowedElements)
+	result := policy.SanitizeHtml("<p><custom x='\"' y=\"a&amp;b\" hidden>c</custom></p>")
+	assert.Equal(result, "<p>&lt;custom x='\"' y=\"a&amp;b\" hidden&gt;c&lt;/custom&gt;</p>")
+
+	document, _, _ := parseString(HtmlMode, result)
+	assert.Equal(document.InnerText(), "<custom x='\"' y=\"a&b\" hidden>c</custom>")
+}
+
+func Test_SanitizeCommentBreakout(t *testing.T) {
+	assert := assert.New(t)
+	policy := UGCPolicy().AllowComments(true)
+	assert.Equal(policy.SanitizeHtml("<p>a<!-- x --!><img src=x onerror=alert(1)> --></p>"), "<p>a<!-- x --><img src=\"x\"> --&gt;</p>")
+}
@@ -0,0 +1,184 @@
+package parseme
+
+import (
+	"strings"
+)
+
+// SecurityRule selects one of the checks run by AuditSecurity.
+type SecurityRule int
+
+const (
+	// JavascriptURLRule reports URLs with the javascript or vbscript scheme.
+	JavascriptURLRule SecurityRule = iota
+	// DataURLRule reports URLs with the data scheme.
+	DataURLRule
+	// EventHandlerRule reports inline event handlers such as onclick.
+	EventHandlerRule
+	// BlankTargetRule reports links and forms targeting _blank without
+	// noopener or noreferrer.
+	BlankTargetRule
+	// InsecureFormRule reports forms that post to an http URL.
+	InsecureFormRule
+	// UnsandboxedFrameRule reports iframes without a sandbox.
+	UnsandboxedFrameRule
+	// SrcdocRule reports iframes with a srcdoc.
+	SrcdocRule
+)
+
+// SecurityRules returns every rule, in the order of their codes.
+func SecurityRules() []SecurityRule {
+	return []SecurityRule{
+		JavascriptURLRule, DataURLRule, EventHandlerRule, BlankTargetRule, InsecureFormRule,
+		UnsandboxedFrameRule, SrcdocRule,
+	}
+}
+
+// AuditSecurity checks the element and its descendants for markup that is
+// commonly abused, using the given rules or every rule when none is given.
+// Each finding is returned and reported as a warning, with a stable code
+// and a fix, through the error pool of the logger. The tree is not changed.
+func (e *Element) AuditSecurity(rules ...SecurityRule) []ErrorData {
+	if len(rules) == 0 {
+		rules = SecurityRules()
+	}
+
+	auditor := &securityAuditor{rules: map[SecurityRule]bool{}}
+	for _, rule := range rules {
+		auditor.rules[rule] = true
+	}
+
+	e.Walk(func(node *Element) WalkAction {
+		if node.elementType == TagElement {
+			auditor.audit(node)
+		}
+		return WalkContinue
+	})
+
+	for _, data := range auditor.findings {
+		reportWarning("Security Audit", data)
+	}
+
+	return auditor.findings
+}
+
+type securityAuditor struct {
+	rules    map[SecurityRule]bool
+	findings []ErrorData
+}
+
+func (a *securityAuditor) report(rule SecurityRule, node *Element, data ErrorData, args ...any) {
+	if a.rules[rule] {
+		a.findings = append(a.findings, data.at(node.line, node.column, args...))
+	}
+}
+
+func (a *securityAuditor) audit(node *Element) {
+	name := strings.ToLower(node.name)
+
+	for _, property := range node.properties {
+		attribute := strings.ToLower(property.name)
+
+		switch {
+		case sanitizeURLAttributes[attribute]:
+			a.auditURL(node, property.name, property.value)
+		case attribute == "srcset":
+			for _, candidate := range property.Srcset() {
+				a.auditURL(node, property.name, candidate.URL)
+			}
+		case attribute == "content" && name == "meta":
+			if url, ok := refreshURL(node, name, property); ok {
+				a.auditURL(node, property.name, url)
+			}
+		case eventHandlerAttributes[attribute]:
+			a.report(EventHandlerRule, node, securityEventHandlerError, property.name, node.name)
+		}
+	}
+
+	if target := node.Property("target"); target != nil && isBlankTarget(node, name, target) {
+		a.report(BlankTargetRule, node, securityBlankTargetError, node.name)
+	}
+
+	if url, ok := insecureFormAction(node, name); ok {
+		a.report(InsecureFormRule, node, securityInsecureFormError, node.name, url)
+	}
+
+	if name == "iframe" {
+		if node.Property("sandbox") == nil {
+			a.report(UnsandboxedFrameRule, node, securityUnsandboxedFrameError)
+		}
+
+		if node.Property("srcdoc") != nil {
+			a.report(SrcdocRule, node, securitySrcdocError)
+		}
+	}
+}
+
+func (a *securityAuditor) auditURL(node *Element, property string, value string) {
+	scheme, _ := urlScheme(value)
+
+	switch scheme {
+	case "javascript", "vbscript":
+		a.report(JavascriptURLRule, node, securityJavascriptURLError, property, node.name)
+	case "data":
+		a.report(DataURLRule, node, securityDataURLError, property, node.name)
+	}
+}
+
+// isBlankTarget reports whether a link or form opens a new browsing context
+// that can reach its opener. Links without an href go nowhere.
+func isBlankTarget(node *Element, name string, target *Property) bool {
+	if !strings.EqualFold(strings.Trim(target.value, cssSpaces), "_blank") {
+		return false
+	}
+
+	switch name {
+	case "a", "area":
+		if node.Property("href") == nil {
+			return false
+		}
+	case "form":
+	default:
+		return false
+	}
+
+	if rel := node.Property("rel"); rel != nil {
+		for _, token := range rel.TokenList().Values() {
+			if strings.EqualFold(token, "noopener") || strings.EqualFold(token, "noreferrer") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// insecureFormAction returns the http URL a form, or a submit button
+// overriding the action of its form, posts to.
+func insecureFormAction(node *Element, name string) (string, bool) {
+	var action, method *Property
+
+	switch name {
+	case "form":
+		action, method = node.Property("action"), node.Property("method")
+	case "button", "input":
+		action, method = node.Property("formaction"), node.Property("formmethod")
+		if method == nil {
+			for ancestor := range node.Ancestors() {
+				if strings.EqualFold(ancestor.name, "form") {
+					method = ancestor.Property("method")
+					break
+				}
+			}
+		}
+	}
+
+	if action == nil || method == nil || !strings.EqualFold(strings.Trim(method.value, cssSpaces), "post") {
+		return "", false
+	}
+
+	if scheme, _ := urlScheme(action.value); scheme != "http" {
+		return "", false
+	}
+
+	return strings.Trim(action.value, cssSpaces), true
+}
@@ -0,0 +1,108 @@
+package parseme
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fueripe-desu/parseme/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AuditSecurity(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"safe markup", "<a href='https://x' target=_blank rel=noopener>x</a><img src=a.png><form method=post action=https://x></form><iframe sandbox src=/x></iframe>", []string{}},
+		{"javascript urls", "<a href=' JavaScript:alert(1)'>x</a><form action='java\tscript:x'></form><a href='vbscript:x'>y</a>", []string{"S01 1:1", "S01 1:37", "S01 1:73"}},
+		{"data urls", "<img src='data:image/png;base64,AA'><img srcset='a.png 1x, data:x 2x'>", []string{"S02 1:1", "S02 1:37"}},
+		{"refresh", "<meta http-equiv=refresh content='0; url=javascript:x'>", []string{"S01 1:1"}},
+		{"event handlers", "<body onload=x()>\n  <p ONCLICK=y() one=1>a</p></body>", []string{"S03 1:1", "S03 2:3"}},
+		{"blank targets", "<a href=/ target=_BLANK>x</a><a href=/ target=_blank rel='nofollow noreferrer'>y</a><a target=_blank>z</a><form target=_blank></form>", []string{"S04 1:1", "S04 1:107"}},
+		{"insecure forms", "<form method=POST action=http://x><button formaction=http://y>a</button><button formmethod=get formaction=http://z>b</button></form><form action=http://x></form>", []string{"S05 1:1", "S05 1:35"}},
+		{"frames", "<iframe src=https://x></iframe><iframe sandbox srcdoc='<p>x</p>'></iframe>", []string{"S06 1:1", "S07 1:32"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			result := []string{}
+			for _, data := range document.AuditSecurity() {
+				result = append(result, data.Code+" "+fmt.Sprint(data.Line)+":"+fmt.Sprint(data.Column))
+			}
+			assert.Equal(result, tc.expected)
+		})
+	}
+}
+
+func Test_AuditSecurityRules(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<iframe srcdoc=x onload=y()></iframe>")
+
+	findings := document.AuditSecurity(SrcdocRule, EventHandlerRule)
+	assert.Equal(len(findings), 2)
+	assert.Equal(findings[0].Code, "S03")
+	assert.Equal(findings[0].Message, "Property 'onload' of 'iframe' is an inline event handler.")
+	assert.Equal(findings[1].Code, "S07")
+
+	// The document is left as it was
+	assert.Equal(outline(document), "iframe[srcdoc=x onload=y()]('')")
+}
+
+func Test_AuditSecurityRefreshCase(t *testing.T) {
+	assert := assert.New(t)
+	document, _, err := parseString(XmlMode, "<html><meta http-equiv=\"refresh\" Content=\"0;url=javascript:x\"/></html>")
+	assert.Nil(err)
+
+	var findings []ErrorData
+	assert.NotPanics(func() {
+		findings = document.AuditSecurity(JavascriptURLRule)
+	})
+	assert.Len(findings, 1)
+	assert.Equal(findings[0].Message, "Property 'Content' of 'meta' runs a javascript URL.")
+}
+
+func Test_AuditSecurityReports(t *testing.T) {
+	t.Cleanup(func() {
+		errors.InitLogger(nil)
+	})
+	assert := assert.New(t)
+
+	pool := &errors.ErrorPool{}
+	observer := &errorObserver{}
+	pool.Subscribe(observer)
+	errors.InitLogger(pool)
+
+	document, _, _ := parseString(HtmlMode, "<a href='javascript:x' target=_blank>x</a>")
+	document.AuditSecurity()
+
+	assert.Equal(len(observer.infos), 2)
+	assert.Equal(observer.infos[0].Code, "S01")
+	assert.Equal(observer.infos[0].Module, "Security Audit")
+	assert.Equal(observer.infos[0].Level, errors.Warning)
+	assert.Equal(observer.infos[0].Message, "Property 'href' of 'a' runs a javascript URL. (line 1, column 1)")
+	assert.Equal(observer.infos[1].Code, "S04")
+}
+
+func Test_AuditSecurityControlCharacters(t *testing.T) {
+	t.Cleanup(func() {
+		errors.InitLogger(nil)
+	})
+	assert := assert.New(t)
+
+	pool := &errors.ErrorPool{}
+	observer := &errorObserver{}
+	pool.Subscribe(observer)
+	errors.InitLogger(pool)
+
+	document, _, _ := parseString(HtmlMode, "<form method=post action=\"http://x/a\tb\nc\"></form>")
+	var findings []ErrorData
+	assert.NotPanics(func() {
+		findings = document.AuditSecurity(InsecureFormRule)
+	})
+
+	assert.Len(observer.infos, 1)
+	assert.Equal(findings[0].Message, "Element 'form' posts data over HTTP to 'http://x/a\\tb\\nc'.")
+}
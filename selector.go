@@ -0,0 +1,563 @@
+package parseme
+
+import (
+	"strings"
+)
+
+// Selector is a compiled CSS selector list that can be matched against any
+// number of trees.
+type Selector struct {
+	source string
+	list   []*complexSelector
+}
+
+type complexSelector struct {
+	compounds []*compoundSelector
+	// combinators[i] joins compounds[i] and compounds[i+1]
+	combinators []byte
+}
+
+type compoundSelector struct {
+	typeName string
+	matchers []selectorMatcher
+}
+
+type selectorMatcher interface {
+	matches(element *Element, scope *Element) bool
+}
+
+func CompileSelector(selector string) (*Selector, error) {
+	list, err := parseSelectorList(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Selector{source: selector, list: list}, nil
+}
+
+func MustCompileSelector(selector string) *Selector {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+
+	return compiled
+}
+
+func (s *Selector) String() string {
+	return s.source
+}
+
+func (s *Selector) Match(element *Element) bool {
+	return element != nil && element.elementType == TagElement && matchList(s.list, element, nil)
+}
+
+// QueryFirst returns the first descendant of root in document order that
+// matches the selector, or nil.
+func (s *Selector) QueryFirst(root *Element) *Element {
+	var found *Element
+	walkElements(root, func(element *Element) bool {
+		if matchList(s.list, element, root) {
+			found = element
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// QueryAll returns every descendant of root that matches the selector, in
+// document order.
+func (s *Selector) QueryAll(root *Element) []*Element {
+	found := []*Element{}
+	walkElements(root, func(element *Element) bool {
+		if matchList(s.list, element, root) {
+			found = append(found, element)
+		}
+		return true
+	})
+	return found
+}
+
+func (e *Element) QuerySelector(selector string) (*Element, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.QueryFirst(e), nil
+}
+
+func (e *Element) QuerySelectorAll(selector string) ([]*Element, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.QueryAll(e), nil
+}
+
+func (e *Element) Matches(selector string) (bool, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	return compiled.Match(e), nil
+}
+
+// Specificity counts the ID selectors, the class, attribute and pseudo-class
+// selectors, and the type selectors of a selector, in that order.
+type Specificity [3]int
+
+// Compare returns -1, 0 or 1 when s is lower than, equal to or higher than
+// other.
+func (s Specificity) Compare(other Specificity) int {
+	for i := range s {
+		switch {
+		case s[i] < other[i]:
+			return -1
+		case s[i] > other[i]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Specificity returns the highest specificity of the selectors in the list.
+func (s *Selector) Specificity() Specificity {
+	return maxSpecificity(s.list)
+}
+
+// MatchSpecificity returns the highest specificity of the selectors in the
+// list that match the element, which is the specificity the cascade uses.
+func (s *Selector) MatchSpecificity(element *Element) (Specificity, bool) {
+	result, matched := Specificity{}, false
+	if element == nil || element.elementType != TagElement {
+		return result, false
+	}
+
+	for _, complex := range s.list {
+		if !complex.matchAt(element, len(complex.compounds)-1, nil) {
+			continue
+		}
+
+		if specificity := complex.specificity(); !matched || specificity.Compare(result) > 0 {
+			result = specificity
+		}
+		matched = true
+	}
+
+	return result, matched
+}
+
+func maxSpecificity(list []*complexSelector) Specificity {
+	result := Specificity{}
+	for _, complex := range list {
+		if specificity := complex.specificity(); specificity.Compare(result) > 0 {
+			result = specificity
+		}
+	}
+
+	return result
+}
+
+func (c *complexSelector) specificity() Specificity {
+	result := Specificity{}
+
+	for _, compound := range c.compounds {
+		if compound.typeName != "" && compound.typeName != "*" {
+			result[2]++
+		}
+
+		for _, matcher := range compound.matchers {
+			var nested Specificity
+
+			switch m := matcher.(type) {
+			case *idMatcher:
+				result[0]++
+			case *listMatcher:
+				// :where never adds to the specificity
+				if m.name != "where" {
+					nested = maxSpecificity(m.list)
+				}
+			case *hasMatcher:
+				// The :scope compound the parser puts in front of relative
+				// selectors is implicit and does not count
+				nested = maxSpecificity(m.list)
+				nested[1]--
+			case *nthMatcher:
+				result[1]++
+				nested = maxSpecificity(m.of)
+			default:
+				result[1]++
+			}
+
+			for i := range result {
+				result[i] += nested[i]
+			}
+		}
+	}
+
+	return result
+}
+
+// walkElements visits the tag descendants of root in document order until
+// visit returns false.
+func walkElements(root *Element, visit func(element *Element) bool) {
+	for node := range root.Descendants() {
+		if node.elementType == TagElement && !visit(node) {
+			return
+		}
+	}
+}
+
+func matchList(list []*complexSelector, element *Element, scope *Element) bool {
+	for _, complex := range list {
+		if complex.matchAt(element, len(complex.compounds)-1, scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchAt matches compounds right to left, backtracking over the elements
+// that satisfy each combinator.
+func (c *complexSelector) matchAt(element *Element, index int, scope *Element) bool {
+	if !c.compounds[index].matches(element, scope) {
+		return false
+	}
+
+	if index == 0 {
+		return true
+	}
+
+	switch c.combinators[index-1] {
+	case '>':
+		parent := parentElement(element)
+		return parent != nil && c.matchAt(parent, index-1, scope)
+	case ' ':
+		for parent := parentElement(element); parent != nil; parent = parentElement(parent) {
+			if c.matchAt(parent, index-1, scope) {
+				return true
+			}
+		}
+	case '+':
+		previous := previousElementSibling(element)
+		return previous != nil && c.matchAt(previous, index-1, scope)
+	case '~':
+		for previous := previousElementSibling(element); previous != nil; previous = previousElementSibling(previous) {
+			if c.matchAt(previous, index-1, scope) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *compoundSelector) matches(element *Element, scope *Element) bool {
+	if c.typeName != "" && c.typeName != "*" && !equalNames(element, element.name, c.typeName) {
+		return false
+	}
+
+	for _, matcher := range c.matchers {
+		if !matcher.matches(element, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Names of HTML elements and properties are matched case-insensitively,
+// names in XML documents must match exactly.
+func equalNames(element *Element, name string, selectorName string) bool {
+	if isHtmlNamespace(element.namespace) && !element.isXml() {
+		return strings.EqualFold(name, selectorName)
+	}
+
+	return name == selectorName
+}
+
+// attributeValue returns the value a property has for selector matching.
+// Boolean properties set to false are absent.
+func attributeValue(element *Element, name string) (string, bool) {
+	for _, property := range element.properties {
+		if !equalNames(element, property.name, name) {
+			continue
+		}
+
+		if property.IsBoolean() {
+			return property.literal, property.value != "false"
+		}
+
+		return property.value, true
+	}
+
+	return "", false
+}
+
+func parentElement(element *Element) *Element {
+	if parent := element.parent; parent != nil && parent.elementType == TagElement {
+		return parent
+	}
+
+	return nil
+}
+
+func previousElementSibling(element *Element) *Element {
+	for sibling := element.prevSibling; sibling != nil; sibling = sibling.prevSibling {
+		if sibling.elementType == TagElement {
+			return sibling
+		}
+	}
+
+	return nil
+}
+
+func nextElementSibling(element *Element) *Element {
+	for sibling := element.nextSibling; sibling != nil; sibling = sibling.nextSibling {
+		if sibling.elementType == TagElement {
+			return sibling
+		}
+	}
+
+	return nil
+}
+
+type scopeMatcher struct{}
+
+func (m scopeMatcher) matches(element *Element, scope *Element) bool {
+	return element == scope
+}
+
+type idMatcher struct {
+	id string
+}
+
+func (m *idMatcher) matches(element *Element, scope *Element) bool {
+	value, ok := attributeValue(element, "id")
+	return ok && value == m.id
+}
+
+type classMatcher struct {
+	class string
+}
+
+func (m *classMatcher) matches(element *Element, scope *Element) bool {
+	value, ok := attributeValue(element, "class")
+	if !ok {
+		return false
+	}
+
+	for _, class := range strings.Fields(value) {
+		if class == m.class {
+			return true
+		}
+	}
+
+	return false
+}
+
+type attributeMatcher struct {
+	name        string
+	operator    string
+	value       string
+	insensitive bool
+}
+
+func (m *attributeMatcher) matches(element *Element, scope *Element) bool {
+	value, ok := attributeValue(element, m.name)
+	if !ok {
+		return false
+	}
+
+	if m.operator == "" {
+		return true
+	}
+
+	expected := m.value
+	if m.insensitive {
+		value = strings.ToLower(value)
+		expected = strings.ToLower(expected)
+	}
+
+	switch m.operator {
+	case "=":
+		return value == expected
+	case "~=":
+		for _, word := range strings.Fields(value) {
+			if word == expected {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return value == expected || strings.HasPrefix(value, expected+"-")
+	case "^=":
+		return expected != "" && strings.HasPrefix(value, expected)
+	case "$=":
+		return expected != "" && strings.HasSuffix(value, expected)
+	case "*=":
+		return expected != "" && strings.Contains(value, expected)
+	}
+
+	return false
+}
+
+var simplePseudoClasses = map[string]func(element *Element) bool{
+	"root": func(element *Element) bool {
+		return element.parent != nil && element.parent.elementType == DocumentElement
+	},
+	"empty": func(element *Element) bool {
+		for child := element.firstChild; child != nil; child = child.nextSibling {
+			if child.elementType == TagElement || child.elementType == TextElement || child.elementType == CDATAElement {
+				return false
+			}
+		}
+		return true
+	},
+	"first-child": func(element *Element) bool {
+		return previousElementSibling(element) == nil
+	},
+	"last-child": func(element *Element) bool {
+		return nextElementSibling(element) == nil
+	},
+	"only-child": func(element *Element) bool {
+		return previousElementSibling(element) == nil && nextElementSibling(element) == nil
+	},
+	"first-of-type": func(element *Element) bool {
+		return siblingIndex(element, false, true, nil) == 1
+	},
+	"last-of-type": func(element *Element) bool {
+		return siblingIndex(element, true, true, nil) == 1
+	},
+	"only-of-type": func(element *Element) bool {
+		return siblingIndex(element, false, true, nil) == 1 && siblingIndex(element, true, true, nil) == 1
+	},
+}
+
+type pseudoMatcher struct {
+	name string
+}
+
+func (m *pseudoMatcher) matches(element *Element, scope *Element) bool {
+	return simplePseudoClasses[m.name](element)
+}
+
+// listMatcher implements :not, :is and :where, as well as the legacy
+// :matches alias of :is.
+type listMatcher struct {
+	name string
+	list []*complexSelector
+}
+
+func (m *listMatcher) matches(element *Element, scope *Element) bool {
+	matched := matchList(m.list, element, scope)
+	if m.name == "not" {
+		return !matched
+	}
+
+	return matched
+}
+
+type hasMatcher struct {
+	list []*complexSelector
+}
+
+func (m *hasMatcher) matches(element *Element, scope *Element) bool {
+	for _, complex := range m.list {
+		if complex.matchesRelative(element) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesRelative looks for an element that matches the relative selector
+// with anchor as its :scope. Only the elements the leading combinator can
+// reach are candidates.
+func (c *complexSelector) matchesRelative(anchor *Element) bool {
+	last := len(c.compounds) - 1
+	found := false
+
+	visit := func(candidate *Element) bool {
+		found = c.matchAt(candidate, last, anchor)
+		return !found
+	}
+
+	switch c.combinators[0] {
+	case ' ', '>':
+		walkElements(anchor, visit)
+	case '+', '~':
+		for sibling := nextElementSibling(anchor); sibling != nil && !found; sibling = nextElementSibling(sibling) {
+			if !visit(sibling) {
+				break
+			}
+
+			if last > 1 {
+				walkElements(sibling, visit)
+			}
+		}
+	}
+
+	return found
+}
+
+type nthMatcher struct {
+	a      int
+	b      int
+	last   bool
+	ofType bool
+	of     []*complexSelector
+}
+
+func (m *nthMatcher) matches(element *Element, scope *Element) bool {
+	if m.of != nil && !matchList(m.of, element, scope) {
+		return false
+	}
+
+	index := siblingIndex(element, m.last, m.ofType, m.of)
+
+	// Look for a non-negative n such that an + b equals the index
+	if m.a == 0 {
+		return index == m.b
+	}
+
+	difference := index - m.b
+	return difference%m.a == 0 && difference/m.a >= 0
+}
+
+// siblingIndex returns the 1-based position of the element among its
+// siblings, counting from the end when fromEnd is set. Only siblings of the
+// same type, or siblings matching the list, are counted when requested.
+func siblingIndex(element *Element, fromEnd bool, ofType bool, of []*complexSelector) int {
+	index := 1
+	sibling := element
+
+	for {
+		if fromEnd {
+			sibling = nextElementSibling(sibling)
+		} else {
+			sibling = previousElementSibling(sibling)
+		}
+
+		if sibling == nil {
+			return index
+		}
+
+		if ofType && !equalNames(element, sibling.name, element.name) {
+			continue
+		}
+
+		if of != nil && !matchList(of, sibling, nil) {
+			continue
+		}
+
+		index++
+	}
+}
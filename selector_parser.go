@@ -0,0 +1,492 @@
+package parseme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+func parseSelectorList(input string) ([]*complexSelector, error) {
+	p := &selectorParser{input: input}
+	list, err := p.parseList(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.atEnd() {
+		return nil, p.fail("unexpected '%v'", string(p.peek()))
+	}
+
+	return list, nil
+}
+
+func (p *selectorParser) fail(format string, args ...any) error {
+	return &SelectorSyntaxError{selector: p.input, position: p.pos, message: fmt.Sprintf(format, args...)}
+}
+
+func (p *selectorParser) atEnd() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *selectorParser) peek() byte {
+	if p.atEnd() {
+		return 0
+	}
+
+	return p.input[p.pos]
+}
+
+func (p *selectorParser) skipSpaces() bool {
+	start := p.pos
+	for !p.atEnd() && isSpace(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) consume(b byte) bool {
+	if p.peek() != b || p.atEnd() {
+		return false
+	}
+
+	p.pos++
+	return true
+}
+
+// parseList reads comma separated complex selectors up to a closing
+// parenthesis or the end of the input. Relative lists are the arguments of
+// :has, whose selectors may start with a combinator.
+func (p *selectorParser) parseList(relative bool) ([]*complexSelector, error) {
+	list := []*complexSelector{}
+
+	for {
+		p.skipSpaces()
+		complex, err := p.parseComplex(relative)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, complex)
+
+		p.skipSpaces()
+		if !p.consume(',') {
+			return list, nil
+		}
+	}
+}
+
+func (p *selectorParser) parseComplex(relative bool) (*complexSelector, error) {
+	complex := &complexSelector{}
+
+	if relative {
+		combinator := byte(' ')
+		if isCombinator(p.peek()) {
+			combinator = p.peek()
+			p.pos++
+			p.skipSpaces()
+		}
+
+		complex.compounds = append(complex.compounds, &compoundSelector{matchers: []selectorMatcher{scopeMatcher{}}})
+		complex.combinators = append(complex.combinators, combinator)
+	}
+
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		complex.compounds = append(complex.compounds, compound)
+
+		hasSpace := p.skipSpaces()
+		next := p.peek()
+
+		if p.atEnd() || next == ',' || next == ')' {
+			return complex, nil
+		}
+
+		if isCombinator(next) {
+			p.pos++
+			p.skipSpaces()
+			complex.combinators = append(complex.combinators, next)
+			continue
+		}
+
+		if !hasSpace {
+			return nil, p.fail("unexpected '%v'", string(next))
+		}
+
+		complex.combinators = append(complex.combinators, ' ')
+	}
+}
+
+func (p *selectorParser) parseCompound() (*compoundSelector, error) {
+	compound := &compoundSelector{}
+	start := p.pos
+
+	if p.consume('*') {
+		compound.typeName = "*"
+	} else if p.startsIdent() {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		compound.typeName = name
+	}
+
+	for !p.atEnd() {
+		var matcher selectorMatcher
+		var err error
+
+		switch p.peek() {
+		case '#':
+			p.pos++
+			var id string
+			id, err = p.parseIdent()
+			matcher = &idMatcher{id: id}
+		case '.':
+			p.pos++
+			var class string
+			class, err = p.parseIdent()
+			matcher = &classMatcher{class: class}
+		case '[':
+			matcher, err = p.parseAttribute()
+		case ':':
+			matcher, err = p.parsePseudo()
+		default:
+			if p.pos == start {
+				return nil, p.fail("expected a selector")
+			}
+			return compound, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		compound.matchers = append(compound.matchers, matcher)
+	}
+
+	if p.pos == start {
+		return nil, p.fail("expected a selector")
+	}
+
+	return compound, nil
+}
+
+func (p *selectorParser) parseAttribute() (selectorMatcher, error) {
+	p.pos++
+	p.skipSpaces()
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &attributeMatcher{name: name}
+	p.skipSpaces()
+
+	if p.consume(']') {
+		return matcher, nil
+	}
+
+	switch {
+	case p.consume('='):
+		matcher.operator = "="
+	case strings.HasPrefix(p.input[p.pos:], "~="), strings.HasPrefix(p.input[p.pos:], "|="),
+		strings.HasPrefix(p.input[p.pos:], "^="), strings.HasPrefix(p.input[p.pos:], "$="),
+		strings.HasPrefix(p.input[p.pos:], "*="):
+		matcher.operator = p.input[p.pos : p.pos+2]
+		p.pos += 2
+	default:
+		return nil, p.fail("expected an attribute operator")
+	}
+
+	p.skipSpaces()
+	if p.peek() == '"' || p.peek() == '\'' {
+		matcher.value, err = p.parseString()
+	} else {
+		matcher.value, err = p.parseIdent()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	switch p.peek() {
+	case 'i', 'I':
+		matcher.insensitive = true
+		p.pos++
+		p.skipSpaces()
+	case 's', 'S':
+		p.pos++
+		p.skipSpaces()
+	}
+
+	if !p.consume(']') {
+		return nil, p.fail("expected ']'")
+	}
+
+	return matcher, nil
+}
+
+func (p *selectorParser) parsePseudo() (selectorMatcher, error) {
+	p.pos++
+	if p.peek() == ':' {
+		return nil, p.fail("pseudo-elements are not supported")
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+
+	if !p.consume('(') {
+		if _, ok := simplePseudoClasses[name]; !ok {
+			return nil, p.fail("unknown pseudo-class ':%v'", name)
+		}
+		return &pseudoMatcher{name: name}, nil
+	}
+
+	var matcher selectorMatcher
+	switch name {
+	case "not", "is", "where", "matches":
+		var list []*complexSelector
+		list, err = p.parseList(false)
+		matcher = &listMatcher{name: name, list: list}
+	case "has":
+		var list []*complexSelector
+		list, err = p.parseList(true)
+		matcher = &hasMatcher{list: list}
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+		matcher, err = p.parseNth(name)
+	default:
+		return nil, p.fail("unknown pseudo-class ':%v()'", name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if !p.consume(')') {
+		return nil, p.fail("expected ')'")
+	}
+
+	return matcher, nil
+}
+
+func (p *selectorParser) parseNth(name string) (selectorMatcher, error) {
+	p.skipSpaces()
+	start := p.pos
+
+	for !p.atEnd() && p.peek() != ')' && !p.startsOf() {
+		p.pos++
+	}
+
+	a, b, ok := parseAnPlusB(p.input[start:p.pos])
+	if !ok {
+		p.pos = start
+		return nil, p.fail("invalid An+B expression")
+	}
+
+	matcher := &nthMatcher{
+		a:      a,
+		b:      b,
+		last:   strings.Contains(name, "last"),
+		ofType: strings.HasSuffix(name, "of-type"),
+	}
+
+	if p.startsOf() {
+		if matcher.ofType {
+			return nil, p.fail("':%v()' does not accept a selector list", name)
+		}
+
+		p.pos += 2
+		list, err := p.parseList(false)
+		if err != nil {
+			return nil, err
+		}
+		matcher.of = list
+	}
+
+	return matcher, nil
+}
+
+// startsOf reports whether the input continues with the "of S" clause of
+// :nth-child.
+func (p *selectorParser) startsOf() bool {
+	if p.pos == 0 || !isSpace(p.input[p.pos-1]) {
+		return false
+	}
+
+	rest := p.input[p.pos:]
+	return len(rest) > 2 && strings.EqualFold(rest[:2], "of") && isSpace(rest[2])
+}
+
+func (p *selectorParser) startsIdent() bool {
+	if p.atEnd() {
+		return false
+	}
+
+	b := p.peek()
+	if b == '-' && p.pos+1 < len(p.input) {
+		b = p.input[p.pos+1]
+		if b == '-' {
+			return true
+		}
+	}
+
+	return isIdentStart(b) || b == '\\'
+}
+
+func (p *selectorParser) parseIdent() (string, error) {
+	if !p.startsIdent() {
+		if p.atEnd() {
+			return "", p.fail("unexpected end of selector")
+		}
+		return "", p.fail("expected an identifier")
+	}
+
+	builder := strings.Builder{}
+	for !p.atEnd() {
+		b := p.peek()
+
+		if b == '\\' {
+			builder.WriteString(p.parseEscape())
+			continue
+		}
+
+		if !isIdentStart(b) && !(b >= '0' && b <= '9') && b != '-' {
+			break
+		}
+
+		builder.WriteByte(b)
+		p.pos++
+	}
+
+	return builder.String(), nil
+}
+
+func (p *selectorParser) parseString() (string, error) {
+	quote := p.peek()
+	p.pos++
+
+	builder := strings.Builder{}
+	for !p.atEnd() {
+		b := p.peek()
+
+		if b == quote {
+			p.pos++
+			return builder.String(), nil
+		}
+
+		if b == '\\' {
+			// An escaped newline continues the string on the next line
+			if p.pos+1 < len(p.input) && p.input[p.pos+1] == '\n' {
+				p.pos += 2
+				continue
+			}
+			builder.WriteString(p.parseEscape())
+			continue
+		}
+
+		builder.WriteByte(b)
+		p.pos++
+	}
+
+	return "", p.fail("unterminated string")
+}
+
+func (p *selectorParser) parseEscape() string {
+	p.pos++
+	if p.atEnd() {
+		return "�"
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && p.pos-start < 6 && isDigitFor(p.input[p.pos], true) {
+		p.pos++
+	}
+
+	if p.pos == start {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		p.pos += size
+		return string(r)
+	}
+
+	value, _ := strconv.ParseInt(p.input[start:p.pos], 16, 32)
+	if !p.atEnd() && isSpace(p.peek()) {
+		p.pos++
+	}
+
+	if value == 0 || value > utf8.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+		return "�"
+	}
+
+	return string(rune(value))
+}
+
+// parseAnPlusB reads the argument of the :nth-* pseudo-classes.
+func parseAnPlusB(input string) (int, int, bool) {
+	value := strings.ToLower(strings.Join(strings.Fields(input), ""))
+
+	switch value {
+	case "odd":
+		return 2, 1, true
+	case "even":
+		return 2, 0, true
+	case "":
+		return 0, 0, false
+	}
+
+	index := strings.IndexByte(value, 'n')
+	if index == -1 {
+		b, err := strconv.Atoi(value)
+		return 0, b, err == nil
+	}
+
+	var a int
+	switch coefficient := value[:index]; coefficient {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		parsed, err := strconv.Atoi(coefficient)
+		if err != nil {
+			return 0, 0, false
+		}
+		a = parsed
+	}
+
+	rest := value[index+1:]
+	if rest == "" {
+		return a, 0, true
+	}
+
+	if rest[0] != '+' && rest[0] != '-' {
+		return 0, 0, false
+	}
+
+	b, err := strconv.Atoi(rest)
+	if err != nil || rest[1] == '+' || rest[1] == '-' {
+		return 0, 0, false
+	}
+
+	return a, b, true
+}
+
+func isCombinator(b byte) bool {
+	return b == '>' || b == '+' || b == '~'
+}
+
+func isIdentStart(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_' || b == '-' || b >= 0x80
+}
@@ -0,0 +1,76 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseAnPlusB(t *testing.T) {
+	testcases := []struct {
+		value string
+		a     int
+		b     int
+		ok    bool
+	}{
+		{"odd", 2, 1, true},
+		{"EVEN", 2, 0, true},
+		{"3", 0, 3, true},
+		{"-2", 0, -2, true},
+		{"n", 1, 0, true},
+		{"-n+3", -1, 3, true},
+		{"+n", 1, 0, true},
+		{"2n + 1", 2, 1, true},
+		{"-3n-2", -3, -2, true},
+		{"", 0, 0, false},
+		{"2n+", 0, 0, false},
+		{"2n++1", 0, 0, false},
+		{"xn", 0, 0, false},
+		{"2n1", 0, 0, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.value, func(t *testing.T) {
+			a, b, ok := parseAnPlusB(tc.value)
+			assert.Equal(t, ok, tc.ok)
+			assert.Equal(t, a, tc.a)
+			assert.Equal(t, b, tc.b)
+		})
+	}
+}
+
+func Test_parseSelectorList(t *testing.T) {
+	testcases := []struct {
+		selector string
+		position int
+	}{
+		{"", 0},
+		{"a,", 2},
+		{"a >", 3},
+		{"a > > b", 4},
+		{"[href", 5},
+		{"[href=]", 6},
+		{"[href!=x]", 5},
+		{"a:hover", 7},
+		{"p::before", 2},
+		{"li:nth-child(x)", 13},
+		{"li:nth-of-type(1 of a)", 17},
+		{"a:not(b", 7},
+		{"[title='x]", 10},
+		{"a)", 1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.selector, func(t *testing.T) {
+			_, err := parseSelectorList(tc.selector)
+			assert.IsType(t, err, &SelectorSyntaxError{})
+			assert.Equal(t, err.(*SelectorSyntaxError).position, tc.position)
+		})
+	}
+
+	list, err := parseSelectorList(`#a\31 b.c\.d, e`)
+	assert.Nil(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, list[0].compounds[0].matchers[0].(*idMatcher).id, "a1b")
+	assert.Equal(t, list[0].compounds[0].matchers[1].(*classMatcher).class, "c.d")
+}
@@ -0,0 +1,147 @@
+package parseme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const selectorDocument = `<html>
+<body id="top" class="page">
+	<ul id="list">
+		<li id="a" class="item first" lang="en-US">One</li>
+		<li id="b" class="item" data-value="prefix-middle-suffix">Two</li>
+		<li id="c" class="item special" data-flag>Three</li>
+		<li id="d" class="item"></li>
+	</ul>
+	<div id="e"><p id="f">Text</p><span id="g"></span><p id="h"></p></div>
+	<section id="i"><h2 id="j">Title</h2></section>
+	<input id="k" type="checkbox" disabled>
+</body>
+</html>`
+
+// ids returns the id properties of the elements joined by spaces.
+func ids(elements []*Element) string {
+	values := []string{}
+	for _, element := range elements {
+		value, _ := attributeValue(element, "id")
+		values = append(values, value)
+	}
+	return strings.Join(values, " ")
+}
+
+func Test_QuerySelectorAll(t *testing.T) {
+	document, _, err := parseString(HtmlMode, selectorDocument)
+	assert.Nil(t, err)
+
+	testcases := []struct {
+		selector string
+		expected string
+	}{
+		{"li", "a b c d"},
+		{"LI", "a b c d"},
+		{"*#list", "list"},
+		{".item.special", "c"},
+		{"#e > p", "f h"},
+		{"body p", "f h"},
+		{"#a + li", "b"},
+		{"#b ~ li", "c d"},
+		{"p, h2", "f h j"},
+		{"[data-flag]", "c"},
+		{"[disabled]", "k"},
+		{"[class~=first]", "a"},
+		{"[lang|=en]", "a"},
+		{"[data-value^=prefix]", "b"},
+		{"[data-value$='suffix']", "b"},
+		{"[data-value*=\"middle\"]", "b"},
+		{"[data-value^='']", ""},
+		{"[type=CHECKBOX i]", "k"},
+		{"[type=CHECKBOX]", ""},
+		{"li:not(.first, .special)", "b d"},
+		{":is(section, div) > :where(h2, span)", "g j"},
+		{"div:has(> span)", "e"},
+		{"div:has(+ section h2)", "e"},
+		{"ul:has(.special)", "list"},
+		{"span:has(p)", ""},
+		{"li:nth-child(2n+1)", "a c"},
+		{"li:nth-child(odd of .item:not(.first))", "b d"},
+		{"li:nth-last-child(1)", "d"},
+		{"p:first-of-type", "f"},
+		{"p:last-of-type", "h"},
+		{"span:only-of-type", "g"},
+		{"li:empty, p:empty", "d h"},
+		{":root", ""},
+		{"li:first-child", "a"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.selector, func(t *testing.T) {
+			result, err := document.QuerySelectorAll(tc.selector)
+			assert.Nil(t, err)
+			assert.Equal(t, ids(result), tc.expected)
+		})
+	}
+}
+
+func Test_QuerySelector(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, selectorDocument)
+
+	first, err := document.QuerySelector("li.item")
+	assert.Nil(t, err)
+	assert.Equal(t, ids([]*Element{first}), "a")
+
+	missing, err := document.QuerySelector("table")
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+
+	root, _ := document.QuerySelector(":root")
+	assert.Equal(t, root.Name(), "html")
+
+	// Queries only return descendants of the element they start from
+	list, _ := document.QuerySelector("#list")
+	result, _ := list.QuerySelectorAll("ul, li:first-child")
+	assert.Equal(t, ids(result), "a")
+}
+
+func Test_Matches(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, selectorDocument)
+	item, _ := document.QuerySelector("#c")
+
+	matched, err := item.Matches("ul > li.special")
+	assert.Nil(t, err)
+	assert.True(t, matched)
+
+	matched, _ = item.Matches("div li")
+	assert.False(t, matched)
+
+	_, err = item.Matches("li[")
+	assert.NotNil(t, err)
+}
+
+func Test_CompileSelector(t *testing.T) {
+	selector, err := CompileSelector("li.item")
+	assert.Nil(t, err)
+	assert.Equal(t, selector.String(), "li.item")
+
+	// A compiled selector can be reused across documents
+	first, _, _ := parseString(HtmlMode, "<ul><li class=item>1<li>2</ul>")
+	second, _, _ := parseString(HtmlMode, "<ol><li class=item>1<li class=item>2</ol>")
+	assert.Len(t, selector.QueryAll(first), 1)
+	assert.Len(t, selector.QueryAll(second), 2)
+	assert.True(t, selector.Match(selector.QueryFirst(second)))
+
+	assert.Panics(t, func() { MustCompileSelector("a >") })
+}
+
+func Test_selectorXml(t *testing.T) {
+	document, _, err := parseString(XmlMode, `<Root><Item Kind="x"/><item kind="y"/></Root>`)
+	assert.Nil(t, err)
+
+	result, _ := document.QuerySelectorAll("Item")
+	assert.Len(t, result, 1)
+
+	result, _ = document.QuerySelectorAll("[kind]")
+	assert.Len(t, result, 1)
+	assert.Equal(t, result[0].Name(), "item")
+}
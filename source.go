@@ -0,0 +1,41 @@
+package parseme
+
+// elementSource keeps the markup a node was parsed from, so that lossless
+// trees can be written back byte for byte. The snapshot fields record the
+// parsed state; a node that no longer matches them has been edited and is
+// rendered from the tree instead.
+type elementSource struct {
+	// head is the raw start tag up to the end of its name for tags, and the
+	// whole markup for every other node.
+	head string
+	// closing is the raw start tag after the last property, including
+	// anything the tree builder ignored before the next node.
+	closing string
+	// end is the raw end tag, empty when it was implied.
+	end string
+
+	name  string
+	value string
+}
+
+type propertySource struct {
+	// dropped is the markup of the repeated properties KeepLastAttribute
+	// removed before this one. It is only written while the property is
+	// unchanged.
+	dropped string
+	// prefix is the text between the previous property and this one.
+	prefix  string
+	raw     string
+	rawName string
+	quote   byte
+
+	propertyType PropertyType
+	name         string
+	value        string
+	literal      string
+}
+
+func (s *propertySource) matches(property *Property) bool {
+	return s.name == property.name && s.value == property.value && s.propertyType == property.propertyType &&
+		s.literal == property.literal
+}
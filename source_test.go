@@ -0,0 +1,167 @@
+package parseme
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseLossless(mode ParserMode, input string) *Element {
+	parser := NewHtmlParser("")
+	parser.SetMode(mode)
+	parser.SetLossless(true)
+	document, _ := parser.ParseString(input)
+	return document
+}
+
+func renderString(node *Element) string {
+	buffer := &bytes.Buffer{}
+	Render(buffer, node)
+	return buffer.String()
+}
+
+func Test_LosslessRoundTrip(t *testing.T) {
+	testcases := []struct {
+		name  string
+		mode  ParserMode
+		value string
+	}{
+		{"quote styles", HtmlMode, "<a HREF='/x' title=\"y\" data-z=w>link</a>"},
+		{"whitespace inside tags", HtmlMode, "<div  class = \"a\"\n\tid=b  >x</div >"},
+		{"letter case", HtmlMode, "<DiV><SPAN>x</span></DIV>"},
+		{"character references", HtmlMode, "<p title=\"&quot;&#39;\">&copy &amp; &#x41; &nbsp;</p>"},
+		{"implied end tags", HtmlMode, "<ul><li>a<li>b</ul><p>c<p>d"},
+		{"stray end tags", HtmlMode, "<div>a</span>b</></div></p>"},
+		{"duplicate properties", HtmlMode, "<a href=x href=y class=c>a</a>"},
+		{"self closing syntax", HtmlMode, "<br/><img src=x /><div/>a</div>"},
+		{"end br", HtmlMode, "a</br>b"},
+		{"raw text", HtmlMode, "<script>if (a<b) {}</script><title>a &amp; b</title>"},
+		{"comments and doctype", HtmlMode, "<!DOCTYPE html>\n<!-- c --><?php x ?><!---->"},
+		{"unterminated tag", HtmlMode, "<p>a</p><div class="},
+		{"xml document", XmlMode, "<?xml version='1.0'?>\n<r xmlns:a='u'>\n  <a:b  c = 'd'/>\n  <![CDATA[x]]><?pi y?>\n</r>\n"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document := parseLossless(tc.mode, tc.value)
+			assert.Equal(renderString(document), tc.value)
+		})
+	}
+}
+
+func Test_LosslessFiles(t *testing.T) {
+	testcases := []string{
+		"test_data/example1.html",
+		"test_data/example2.html",
+		"test_data/example3.html",
+		"test_data/example4.html",
+	}
+
+	for _, filepath := range testcases {
+		t.Run(filepath, func(t *testing.T) {
+			assert := assert.New(t)
+			parser := NewHtmlParser(filepath)
+			parser.SetLossless(true)
+			document, err := parser.ParseDocument()
+			assert.Nil(err)
+
+			input, _ := os.ReadFile(filepath)
+			assert.Equal(renderString(document), string(input))
+		})
+	}
+}
+
+func Test_LosslessEdits(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		edit     func(document *Element)
+		expected string
+	}{
+		{
+			"edit property value",
+			"<A  HREF='/old'  class=x>link</A>",
+			func(document *Element) {
+				document.firstChild.properties[0].SetValue("/new")
+			},
+			"<A  HREF='/new'  class=x>link</A>",
+		},
+		{
+			"edit unquoted property value",
+			"<a class=x title=y>",
+			func(document *Element) {
+				document.firstChild.properties[0].SetValue("a b")
+			},
+			"<a class=\"a b\" title=y>",
+		},
+		{
+			"remove property",
+			"<a  href=x   title=y >a</a>",
+			func(document *Element) {
+				element := document.firstChild
+				element.properties = element.properties[1:]
+			},
+			"<a   title=y >a</a>",
+		},
+		{
+			"add property",
+			"<a href='x'>a</a>",
+			func(document *Element) {
+				element := document.firstChild
+				element.properties = append(element.properties, NewProperty(Value, "rel", "nofollow"))
+			},
+			"<a href='x' rel=\"nofollow\">a</a>",
+		},
+		{
+			"rename tag",
+			"<B class=x>bold</B>",
+			func(document *Element) {
+				document.firstChild.name = "strong"
+			},
+			"<strong class=x>bold</strong>",
+		},
+		{
+			"edit text",
+			"<p>a &amp; b</p><p>&copy;</p>",
+			func(document *Element) {
+				document.firstChild.firstChild.value = "c < d"
+			},
+			"<p>c &lt; d</p><p>&copy;</p>",
+		},
+		{
+			"append child",
+			"<ul>\n  <li>a\n</ul>",
+			func(document *Element) {
+				item := &Element{elementType: TagElement, name: "li"}
+				item.appendChild(&Element{elementType: TextElement, value: "b"})
+				document.firstChild.appendChild(item)
+			},
+			"<ul>\n  <li>a\n<li>b</li></ul>",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document := parseLossless(HtmlMode, tc.value)
+			tc.edit(document)
+			assert.Equal(renderString(document), tc.expected)
+		})
+	}
+}
+
+func Test_LosslessKeepLastAttribute(t *testing.T) {
+	assert := assert.New(t)
+	parser := &HtmlParser{lossless: true, duplicates: KeepLastAttribute}
+	bytes := []byte("<a x=1 title=t  x=2>a</a>")
+	document, _ := parser.ParseBytes(bytes)
+	assert.Equal(renderString(document), "<a x=1 title=t  x=2>a</a>")
+
+	// The dropped occurrence goes away once the property after it is edited
+	element := document.firstChild
+	assert.Nil(element.Property("title").SetValue("u"))
+	assert.Equal(renderString(document), "<a title=\"u\"  x=2>a</a>")
+}
@@ -0,0 +1,457 @@
+package parseme
+
+const (
+	xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+	xmlNamespace   = "http://www.w3.org/XML/1998/namespace"
+	xmlnsNamespace = "http://www.w3.org/2000/xmlns/"
+)
+
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// Elements whose contents are not parsed as markup. Character references
+// are only decoded inside the escapable ones.
+var rawTextElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"xmp":      true,
+	"iframe":   true,
+	"noembed":  true,
+	"noframes": true,
+}
+
+var escapableRawTextElements = map[string]bool{
+	"textarea": true,
+	"title":    true,
+}
+
+// Start tags that implicitly close an open paragraph.
+var paragraphClosers = map[string]bool{
+	"address":    true,
+	"article":    true,
+	"aside":      true,
+	"blockquote": true,
+	"details":    true,
+	"dialog":     true,
+	"div":        true,
+	"dl":         true,
+	"fieldset":   true,
+	"figcaption": true,
+	"figure":     true,
+	"footer":     true,
+	"form":       true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"header":     true,
+	"hgroup":     true,
+	"hr":         true,
+	"li":         true,
+	"dd":         true,
+	"dt":         true,
+	"main":       true,
+	"menu":       true,
+	"nav":        true,
+	"ol":         true,
+	"p":          true,
+	"pre":        true,
+	"section":    true,
+	"summary":    true,
+	"table":      true,
+	"ul":         true,
+}
+
+// Elements that stop the search for an element to implicitly close.
+var scopeElements = map[string]bool{
+	"applet":   true,
+	"button":   true,
+	"caption":  true,
+	"html":     true,
+	"table":    true,
+	"td":       true,
+	"th":       true,
+	"marquee":  true,
+	"object":   true,
+	"template": true,
+	"svg":      true,
+	"math":     true,
+}
+
+func isVoidElement(name string) bool {
+	return voidElements[name]
+}
+
+func isRawTextElement(name string) bool {
+	return rawTextElements[name] || escapableRawTextElements[name]
+}
+
+// Elements parsed in HTML mode carry no namespace but are HTML elements all
+// the same.
+func isHtmlNamespace(namespace string) bool {
+	return namespace == "" || namespace == xhtmlNamespace
+}
+
+// Elements rendered inline by default. Whitespace between them is
+// significant, so formatters must leave runs of them as they are.
+var inlineElements = map[string]bool{
+	"a":        true,
+	"abbr":     true,
+	"acronym":  true,
+	"audio":    true,
+	"b":        true,
+	"bdi":      true,
+	"bdo":      true,
+	"big":      true,
+	"br":       true,
+	"button":   true,
+	"canvas":   true,
+	"cite":     true,
+	"code":     true,
+	"data":     true,
+	"del":      true,
+	"dfn":      true,
+	"em":       true,
+	"embed":    true,
+	"font":     true,
+	"i":        true,
+	"iframe":   true,
+	"img":      true,
+	"input":    true,
+	"ins":      true,
+	"kbd":      true,
+	"label":    true,
+	"map":      true,
+	"mark":     true,
+	"math":     true,
+	"meter":    true,
+	"object":   true,
+	"output":   true,
+	"picture":  true,
+	"progress": true,
+	"q":        true,
+	"ruby":     true,
+	"s":        true,
+	"samp":     true,
+	"select":   true,
+	"slot":     true,
+	"small":    true,
+	"span":     true,
+	"strike":   true,
+	"strong":   true,
+	"sub":      true,
+	"sup":      true,
+	"svg":      true,
+	"textarea": true,
+	"time":     true,
+	"tt":       true,
+	"u":        true,
+	"var":      true,
+	"video":    true,
+	"wbr":      true,
+}
+
+// Elements displayed as blocks by default, which start and end a line of
+// rendered text.
+var blockElements = map[string]bool{
+	"address":    true,
+	"article":    true,
+	"aside":      true,
+	"blockquote": true,
+	"body":       true,
+	"caption":    true,
+	"center":     true,
+	"dd":         true,
+	"details":    true,
+	"dialog":     true,
+	"dir":        true,
+	"div":        true,
+	"dl":         true,
+	"dt":         true,
+	"fieldset":   true,
+	"figcaption": true,
+	"figure":     true,
+	"footer":     true,
+	"form":       true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"header":     true,
+	"hgroup":     true,
+	"hr":         true,
+	"html":       true,
+	"legend":     true,
+	"li":         true,
+	"listing":    true,
+	"main":       true,
+	"menu":       true,
+	"nav":        true,
+	"ol":         true,
+	"optgroup":   true,
+	"p":          true,
+	"plaintext":  true,
+	"pre":        true,
+	"search":     true,
+	"section":    true,
+	"summary":    true,
+	"table":      true,
+	"ul":         true,
+	"xmp":        true,
+}
+
+// Elements whose contents are never rendered as text: those hidden by the
+// default stylesheet, and those showing something other than their
+// children.
+var unrenderedElements = map[string]bool{
+	"area":     true,
+	"audio":    true,
+	"base":     true,
+	"basefont": true,
+	"canvas":   true,
+	"datalist": true,
+	"head":     true,
+	"iframe":   true,
+	"link":     true,
+	"meta":     true,
+	"noembed":  true,
+	"noframes": true,
+	"noscript": true,
+	"param":    true,
+	"rp":       true,
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+	"video":    true,
+}
+
+// Elements whose contents must be reproduced exactly.
+var preformattedElements = map[string]bool{
+	"pre":       true,
+	"listing":   true,
+	"plaintext": true,
+	"textarea":  true,
+	"title":     true,
+	"script":    true,
+	"style":     true,
+	"xmp":       true,
+	"iframe":    true,
+	"noembed":   true,
+	"noframes":  true,
+}
+
+// Boolean attributes defined by the HTML standard. Their presence alone
+// makes them true.
+var booleanAttributes = map[string]bool{
+	"allowfullscreen":          true,
+	"async":                    true,
+	"autofocus":                true,
+	"autoplay":                 true,
+	"checked":                  true,
+	"controls":                 true,
+	"default":                  true,
+	"defer":                    true,
+	"disabled":                 true,
+	"formnovalidate":           true,
+	"hidden":                   true,
+	"inert":                    true,
+	"ismap":                    true,
+	"itemscope":                true,
+	"loop":                     true,
+	"multiple":                 true,
+	"muted":                    true,
+	"nomodule":                 true,
+	"novalidate":               true,
+	"open":                     true,
+	"playsinline":              true,
+	"readonly":                 true,
+	"required":                 true,
+	"reversed":                 true,
+	"selected":                 true,
+	"shadowrootclonable":       true,
+	"shadowrootdelegatesfocus": true,
+	"shadowrootserializable":   true,
+}
+
+// Elements whose end tag may be omitted when followed by one of the listed
+// siblings. An empty list of siblings only allows it when the element is the
+// last child of its parent.
+var optionalEndTags = map[string]map[string]bool{
+	"li":       {"li": true},
+	"dt":       {"dt": true, "dd": true},
+	"dd":       {"dt": true, "dd": true},
+	"p":        paragraphClosers,
+	"rt":       {"rt": true, "rp": true},
+	"rp":       {"rt": true, "rp": true},
+	"optgroup": {"optgroup": true},
+	"option":   {"option": true, "optgroup": true},
+	"thead":    {"tbody": true, "tfoot": true},
+	"tbody":    {"tbody": true, "tfoot": true},
+	"tfoot":    {},
+	"tr":       {"tr": true},
+	"td":       {"td": true, "th": true},
+	"th":       {"td": true, "th": true},
+	"body":     {},
+	"html":     {},
+}
+
+// Parents in which a paragraph must keep its end tag even as last child.
+var transparentParents = map[string]bool{
+	"a":        true,
+	"audio":    true,
+	"del":      true,
+	"ins":      true,
+	"map":      true,
+	"noscript": true,
+	"video":    true,
+}
+
+// Event handler content attributes defined by HTML, SVG and the pointer,
+// touch, animation and transition specifications.
+var eventHandlerAttributes = map[string]bool{
+	"onabort":                            true,
+	"onafterprint":                       true,
+	"onanimationcancel":                  true,
+	"onanimationend":                     true,
+	"onanimationiteration":               true,
+	"onanimationstart":                   true,
+	"onauxclick":                         true,
+	"onbeforecopy":                       true,
+	"onbeforecut":                        true,
+	"onbeforeinput":                      true,
+	"onbeforematch":                      true,
+	"onbeforepaste":                      true,
+	"onbeforeprint":                      true,
+	"onbeforetoggle":                     true,
+	"onbeforeunload":                     true,
+	"onbegin":                            true,
+	"onblur":                             true,
+	"oncancel":                           true,
+	"oncanplay":                          true,
+	"oncanplaythrough":                   true,
+	"onchange":                           true,
+	"onclick":                            true,
+	"onclose":                            true,
+	"oncontentvisibilityautostatechange": true,
+	"oncontextlost":                      true,
+	"oncontextmenu":                      true,
+	"oncontextrestored":                  true,
+	"oncopy":                             true,
+	"oncuechange":                        true,
+	"oncut":                              true,
+	"ondblclick":                         true,
+	"ondrag":                             true,
+	"ondragend":                          true,
+	"ondragenter":                        true,
+	"ondragleave":                        true,
+	"ondragover":                         true,
+	"ondragstart":                        true,
+	"ondrop":                             true,
+	"ondurationchange":                   true,
+	"onemptied":                          true,
+	"onend":                              true,
+	"onended":                            true,
+	"onerror":                            true,
+	"onfocus":                            true,
+	"onfocusin":                          true,
+	"onfocusout":                         true,
+	"onformdata":                         true,
+	"ongotpointercapture":                true,
+	"onhashchange":                       true,
+	"oninput":                            true,
+	"oninvalid":                          true,
+	"onkeydown":                          true,
+	"onkeypress":                         true,
+	"onkeyup":                            true,
+	"onlanguagechange":                   true,
+	"onload":                             true,
+	"onloadeddata":                       true,
+	"onloadedmetadata":                   true,
+	"onloadstart":                        true,
+	"onlostpointercapture":               true,
+	"onmessage":                          true,
+	"onmessageerror":                     true,
+	"onmousedown":                        true,
+	"onmouseenter":                       true,
+	"onmouseleave":                       true,
+	"onmousemove":                        true,
+	"onmouseout":                         true,
+	"onmouseover":                        true,
+	"onmouseup":                          true,
+	"onmousewheel":                       true,
+	"onoffline":                          true,
+	"ononline":                           true,
+	"onpagehide":                         true,
+	"onpagereveal":                       true,
+	"onpageshow":                         true,
+	"onpageswap":                         true,
+	"onpaste":                            true,
+	"onpause":                            true,
+	"onplay":                             true,
+	"onplaying":                          true,
+	"onpointercancel":                    true,
+	"onpointerdown":                      true,
+	"onpointerenter":                     true,
+	"onpointerleave":                     true,
+	"onpointermove":                      true,
+	"onpointerout":                       true,
+	"onpointerover":                      true,
+	"onpointerrawupdate":                 true,
+	"onpointerup":                        true,
+	"onpopstate":                         true,
+	"onprogress":                         true,
+	"onratechange":                       true,
+	"onrejectionhandled":                 true,
+	"onrepeat":                           true,
+	"onreset":                            true,
+	"onresize":                           true,
+	"onscroll":                           true,
+	"onscrollend":                        true,
+	"onsearch":                           true,
+	"onsecuritypolicyviolation":          true,
+	"onseeked":                           true,
+	"onseeking":                          true,
+	"onselect":                           true,
+	"onselectionchange":                  true,
+	"onselectstart":                      true,
+	"onslotchange":                       true,
+	"onstalled":                          true,
+	"onstorage":                          true,
+	"onsubmit":                           true,
+	"onsuspend":                          true,
+	"ontimeupdate":                       true,
+	"ontoggle":                           true,
+	"ontouchcancel":                      true,
+	"ontouchend":                         true,
+	"ontouchmove":                        true,
+	"ontouchstart":                       true,
+	"ontransitioncancel":                 true,
+	"ontransitionend":                    true,
+	"ontransitionrun":                    true,
+	"ontransitionstart":                  true,
+	"onunhandledrejection":               true,
+	"onunload":                           true,
+	"onvolumechange":                     true,
+	"onwaiting":                          true,
+	"onwheel":                            true,
+}
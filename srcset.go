@@ -0,0 +1,434 @@
+package parseme
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ImageCandidate is an image of a srcset property. Width and Height come
+// from w and h descriptors and Density from an x descriptor, and are zero
+// when the descriptor is absent.
+type ImageCandidate struct {
+	URL     string
+	Width   int
+	Height  int
+	Density float64
+}
+
+func (c ImageCandidate) String() string {
+	parts := []string{c.URL}
+	if c.Width > 0 {
+		parts = append(parts, strconv.Itoa(c.Width)+"w")
+	}
+	if c.Height > 0 {
+		parts = append(parts, strconv.Itoa(c.Height)+"h")
+	}
+	if c.Density > 0 {
+		parts = append(parts, strconv.FormatFloat(c.Density, 'g', -1, 64)+"x")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Srcset is the list of image candidates of a srcset property. Edited
+// lists are written back with SetValue(srcset.String()).
+type Srcset []ImageCandidate
+
+// SourceSize is an entry of a sizes property: the slot width used when the
+// media condition holds. The last entry usually has no condition.
+type SourceSize struct {
+	Condition string
+	Length    string
+}
+
+func (s SourceSize) String() string {
+	if s.Condition == "" {
+		return s.Length
+	}
+
+	return s.Condition + " " + s.Length
+}
+
+type Sizes []SourceSize
+
+func (p *Property) Srcset() Srcset {
+	return ParseSrcset(p.value)
+}
+
+func (p *Property) Sizes() Sizes {
+	return ParseSizes(p.value)
+}
+
+// ParseSrcset follows the HTML algorithm for parsing srcset properties.
+// Candidates with invalid descriptors are dropped.
+func ParseSrcset(value string) Srcset {
+	candidates := Srcset{}
+	position := 0
+
+	for {
+		for position < len(value) && (isSpace(value[position]) || value[position] == ',') {
+			position++
+		}
+		if position >= len(value) {
+			return candidates
+		}
+
+		start := position
+		for position < len(value) && !isSpace(value[position]) {
+			position++
+		}
+		url := value[start:position]
+
+		descriptors := []string{}
+		if strings.HasSuffix(url, ",") {
+			url = strings.TrimRight(url, ",")
+		} else {
+			descriptors, position = readSrcsetDescriptors(value, position)
+		}
+
+		if candidate, ok := parseSrcsetDescriptors(url, descriptors); ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+}
+
+// readSrcsetDescriptors reads the descriptors of a candidate up to the
+// comma that ends it. Commas inside parentheses do not end a descriptor.
+func readSrcsetDescriptors(value string, position int) ([]string, int) {
+	descriptors := []string{}
+	current := strings.Builder{}
+	depth := 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			descriptors = append(descriptors, current.String())
+			current.Reset()
+		}
+	}
+
+	for ; position < len(value); position++ {
+		character := value[position]
+
+		switch {
+		case depth > 0:
+			if character == ')' {
+				depth = 0
+			}
+			current.WriteByte(character)
+		case isSpace(character):
+			flush()
+		case character == ',':
+			flush()
+			return descriptors, position + 1
+		default:
+			if character == '(' {
+				depth = 1
+			}
+			current.WriteByte(character)
+		}
+	}
+
+	flush()
+	return descriptors, position
+}
+
+func parseSrcsetDescriptors(url string, descriptors []string) (ImageCandidate, bool) {
+	candidate := ImageCandidate{URL: url}
+	hasDensity := false
+
+	for _, descriptor := range descriptors {
+		number, suffix := descriptor[:len(descriptor)-1], descriptor[len(descriptor)-1]
+
+		switch {
+		case suffix == 'w' && isNonNegativeInteger(number):
+			width, err := strconv.Atoi(number)
+			if err != nil || width == 0 || candidate.Width > 0 || hasDensity {
+				return candidate, false
+			}
+			candidate.Width = width
+		case suffix == 'x' && isValidFloat(number):
+			density, err := strconv.ParseFloat(number, 64)
+			if err != nil || density < 0 || candidate.Width > 0 || candidate.Height > 0 || hasDensity {
+				return candidate, false
+			}
+			candidate.Density, hasDensity = density, true
+		case suffix == 'h' && isNonNegativeInteger(number):
+			height, err := strconv.Atoi(number)
+			if err != nil || height == 0 || candidate.Height > 0 || hasDensity {
+				return candidate, false
+			}
+			candidate.Height = height
+		default:
+			return candidate, false
+		}
+	}
+
+	// A height is only allowed along with a width
+	if candidate.Height > 0 && candidate.Width == 0 {
+		return candidate, false
+	}
+
+	return candidate, true
+}
+
+func isNonNegativeInteger(value string) bool {
+	return value != "" && leadingDigits(value) == value
+}
+
+// isValidFloat checks the HTML syntax of floating-point numbers, which is
+// stricter than the parsing rules: no leading '+' and no trailing dot.
+func isValidFloat(value string) bool {
+	value = strings.TrimPrefix(value, "-")
+
+	integer := leadingDigits(value)
+	value = value[len(integer):]
+
+	fraction := ""
+	if strings.HasPrefix(value, ".") {
+		fraction = leadingDigits(value[1:])
+		if fraction == "" {
+			return false
+		}
+		value = value[1+len(fraction):]
+	}
+
+	if integer == "" && fraction == "" {
+		return false
+	}
+
+	if value != "" && (value[0] == 'e' || value[0] == 'E') {
+		value = value[1:]
+		if value != "" && (value[0] == '-' || value[0] == '+') {
+			value = value[1:]
+		}
+		return isNonNegativeInteger(value)
+	}
+
+	return value == ""
+}
+
+func (s Srcset) String() string {
+	parts := []string{}
+	for _, candidate := range s {
+		parts = append(parts, candidate.String())
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Select picks the candidate for a viewport width in CSS pixels and a
+// device pixel ratio: the one with the lowest density that is at least dpr
+// or, when there is none, the one with the highest density. The density of
+// a w descriptor is its width divided by the slot width given by sizes.
+func (s Srcset) Select(sizes Sizes, viewport float64, dpr float64) (ImageCandidate, bool) {
+	slot := sizes.Width(viewport)
+	var best ImageCandidate
+	bestDensity, found := 0.0, false
+
+	for _, candidate := range s {
+		density := candidate.Density
+		switch {
+		case candidate.Width > 0:
+			density = float64(candidate.Width) / slot
+		case density == 0:
+			density = 1
+		}
+
+		var better bool
+		switch {
+		case !found:
+			better = true
+		case bestDensity < dpr:
+			better = density > bestDensity
+		default:
+			better = density >= dpr && density < bestDensity
+		}
+
+		if better {
+			best, bestDensity, found = candidate, density, true
+		}
+	}
+
+	return best, found
+}
+
+// ParseSizes follows the HTML algorithm for parsing sizes properties.
+// Entries with an invalid length or media condition are dropped, as are
+// the entries after the first one without a condition, other than auto.
+func ParseSizes(value string) Sizes {
+	sizes := Sizes{}
+
+	for _, entry := range splitCss(stripCssComments(value), ',') {
+		entry = strings.Trim(entry, cssSpaces)
+
+		split := len(entry)
+		scanCss(entry, func(index int, depth int) bool {
+			if depth == 0 && isSpace(entry[index]) {
+				split = index
+			}
+			return true
+		})
+
+		condition, length := "", entry
+		if split < len(entry) {
+			condition, length = strings.Trim(entry[:split], cssSpaces), entry[split+1:]
+		}
+
+		if !isSourceSizeValue(length) {
+			continue
+		}
+
+		if condition != "" {
+			if _, ok := parseMediaCondition(condition); !ok {
+				continue
+			}
+		}
+
+		// auto needs layout, so the entries that follow it still matter
+		sizes = append(sizes, SourceSize{Condition: condition, Length: length})
+		if condition == "" && !strings.EqualFold(length, "auto") {
+			break
+		}
+	}
+
+	return sizes
+}
+
+func (s Sizes) String() string {
+	parts := []string{}
+	for _, size := range s {
+		parts = append(parts, size.String())
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Width returns the slot width in CSS pixels of the first entry whose
+// condition holds for the viewport width. Lengths that depend on layout,
+// such as auto, percentages of the viewport height or calc(), are skipped.
+// Without a matching entry the slot is as wide as the viewport.
+func (s Sizes) Width(viewport float64) float64 {
+	for _, size := range s {
+		if size.Condition != "" {
+			condition, _ := parseMediaCondition(size.Condition)
+			if !condition(viewport) {
+				continue
+			}
+		}
+
+		if width, ok := resolveCssLength(size.Length, viewport); ok {
+			return width
+		}
+	}
+
+	return viewport
+}
+
+// CurrentSource returns the URL an img element displays for a viewport
+// width and device pixel ratio. Its src is a 1x candidate unless srcset
+// uses width descriptors or already has a 1x candidate.
+func (e *Element) CurrentSource(viewport float64, dpr float64) (string, bool) {
+	candidates, sizes := Srcset{}, Sizes{}
+	if srcset := e.Property("srcset"); srcset != nil {
+		candidates = srcset.Srcset()
+	}
+	if property := e.Property("sizes"); property != nil {
+		sizes = property.Sizes()
+	}
+
+	if src := e.Property("src"); src != nil && !src.IsBoolean() && src.value != "" {
+		fallback := true
+		for _, candidate := range candidates {
+			if candidate.Width > 0 || candidate.Density == 1 || candidate.Density == 0 {
+				fallback = false
+			}
+		}
+
+		if fallback {
+			candidates = append(candidates, ImageCandidate{URL: src.value, Density: 1})
+		}
+	}
+
+	candidate, ok := candidates.Select(sizes, viewport, dpr)
+	return candidate.URL, ok
+}
+
+var cssLengthUnits = map[string]float64{
+	"px": 1, "em": 16, "rem": 16, "in": 96, "cm": 96 / 2.54, "mm": 96 / 25.4,
+	"q": 96 / 101.6, "pt": 96.0 / 72, "pc": 16,
+}
+
+// isSourceSizeValue accepts auto, non-negative lengths and math functions.
+func isSourceSizeValue(value string) bool {
+	lower := strings.ToLower(value)
+	if lower == "auto" {
+		return true
+	}
+
+	for _, function := range []string{"calc(", "min(", "max(", "clamp("} {
+		if strings.HasPrefix(lower, function) && strings.HasSuffix(lower, ")") {
+			return true
+		}
+	}
+
+	number, unit, ok := splitCssDimension(lower)
+	if !ok || number < 0 {
+		return false
+	}
+
+	if number == 0 && unit == "" {
+		return true
+	}
+
+	switch unit {
+	case "vw", "vh", "vmin", "vmax", "svw", "lvw", "dvw", "svh", "lvh", "dvh", "ch", "ex", "cap", "ic", "lh", "rlh":
+		return true
+	}
+
+	_, known := cssLengthUnits[unit]
+	return known
+}
+
+// resolveCssLength converts absolute lengths, font-relative lengths based
+// on the default font size and vw lengths to pixels.
+func resolveCssLength(value string, viewport float64) (float64, bool) {
+	number, unit, ok := splitCssDimension(strings.ToLower(value))
+	if !ok {
+		return 0, false
+	}
+
+	if factor, known := cssLengthUnits[unit]; known {
+		return number * factor, true
+	}
+
+	switch unit {
+	case "vw", "svw", "lvw", "dvw":
+		return number * viewport / 100, true
+	case "":
+		return number, number == 0
+	}
+
+	return 0, false
+}
+
+// splitCssDimension splits a CSS number from its unit.
+func splitCssDimension(value string) (float64, string, bool) {
+	end := 0
+	for end < len(value) && (value[end] == '.' || value[end] == '-' || value[end] == '+' || (value[end] >= '0' && value[end] <= '9')) {
+		end++
+	}
+
+	number, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	unit := value[end:]
+	for i := 0; i < len(unit); i++ {
+		if unit[i] < 'a' || unit[i] > 'z' {
+			return 0, "", false
+		}
+	}
+
+	return number, unit, true
+}
@@ -0,0 +1,204 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseSrcset(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected Srcset
+	}{
+		{"densities", "a.jpg 1x, b.jpg 2x", Srcset{{URL: "a.jpg", Density: 1}, {URL: "b.jpg", Density: 2}}},
+		{"widths", " a.jpg 480w,\n b.jpg 800w 600h ", Srcset{{URL: "a.jpg", Width: 480}, {URL: "b.jpg", Width: 800, Height: 600}}},
+		{"no descriptor", "a.jpg, b.jpg", Srcset{{URL: "a.jpg"}, {URL: "b.jpg"}}},
+		{"commas in URL", "a,b.jpg 1.5x,c.jpg", Srcset{{URL: "a,b.jpg", Density: 1.5}, {URL: "c.jpg"}}},
+		{"data URL", "data:image/png;base64,AA== 2x", Srcset{{URL: "data:image/png;base64,AA==", Density: 2}}},
+		{"fraction density", "a.jpg .5x, b.jpg 1e1x", Srcset{{URL: "a.jpg", Density: 0.5}, {URL: "b.jpg", Density: 10}}},
+		{"parentheses in descriptor", "a.jpg 1x (b, c), d.jpg", Srcset{{URL: "d.jpg"}}},
+		{"width and density", "a.jpg 100w 1x, b.jpg", Srcset{{URL: "b.jpg"}}},
+		{"zero width", "a.jpg 0w", Srcset{}},
+		{"height without width", "a.jpg 100h", Srcset{}},
+		{"invalid descriptor", "a.jpg 2X, b.jpg +1x, c.jpg 1.x, d.jpg 10", Srcset{}},
+		{"empty value", " , ", Srcset{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(NewProperty(Value, "srcset", tc.value).Srcset(), tc.expected)
+		})
+	}
+}
+
+func Test_SrcsetString(t *testing.T) {
+	assert := assert.New(t)
+	property := NewProperty(Value, "srcset", "a.jpg   480w,b.jpg 1.5x , c.jpg")
+	srcset := property.Srcset()
+	srcset[0].URL = "small.jpg"
+	srcset = append(srcset, ImageCandidate{URL: "d.jpg", Width: 1200, Height: 800})
+
+	assert.Nil(property.SetValue(srcset.String()))
+	assert.Equal(property.Value(), "small.jpg 480w, b.jpg 1.5x, c.jpg, d.jpg 1200w 800h")
+	assert.Equal(property.Srcset(), srcset)
+}
+
+func Test_ParseSizes(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected Sizes
+	}{
+		{"default only", "50vw", Sizes{{Length: "50vw"}}},
+		{
+			"conditions",
+			"(max-width: 600px) 100vw, (min-width: 601px) and (max-width: 1200px) 50vw, 33vw",
+			Sizes{{"(max-width: 600px)", "100vw"}, {"(min-width: 601px) and (max-width: 1200px)", "50vw"}, {Length: "33vw"}},
+		},
+		{"entries after the default are dropped", "100vw, (min-width: 1px) 10px", Sizes{{Length: "100vw"}}},
+		{"invalid lengths", "(max-width: 1px) 50%, (max-width: 2px) -1px, (max-width: 3px) 2em", Sizes{{"(max-width: 3px)", "2em"}}},
+		{"invalid conditions", "max-width: 1px 10px, (a) and (b) or (c) 20px, (width >= 40em) calc(100vw - 2rem)", Sizes{{"(width >= 40em)", "calc(100vw - 2rem)"}}},
+		{"auto and comments", "auto /* lazy */, 100vw", Sizes{{Length: "auto"}, {Length: "100vw"}}},
+		{"empty value", "", Sizes{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(NewProperty(Value, "sizes", tc.value).Sizes(), tc.expected)
+		})
+	}
+}
+
+func Test_SizesWidth(t *testing.T) {
+	sizes := ParseSizes("(max-width: 600px) 100vw, (min-width: 601px) and (max-width: 75em) 50vw, (orientation: portrait) 10px, 400px")
+
+	testcases := []struct {
+		viewport float64
+		expected float64
+	}{
+		{320, 320},
+		{600, 600},
+		{800, 400},
+		{1200, 600},
+		{1201, 400},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, sizes.Width(tc.viewport), tc.expected)
+	}
+
+	assert.Equal(t, Sizes{}.Width(500), 500.0)
+	assert.Equal(t, ParseSizes("auto, (max-width: 1px) 1px, 2in").Width(500), 192.0)
+	assert.Equal(t, ParseSizes("calc(50vw), 2in").Width(500), 500.0)
+	assert.Equal(t, sizes.String(), "(max-width: 600px) 100vw, (min-width: 601px) and (max-width: 75em) 50vw, (orientation: portrait) 10px, 400px")
+}
+
+func Test_parseMediaCondition(t *testing.T) {
+	testcases := []struct {
+		condition string
+		valid     bool
+		matches   []float64
+	}{
+		{"(min-width: 500px)", true, []float64{500, 900}},
+		{"not (min-width: 500px)", true, []float64{100, 200, 480, 499}},
+		{"(min-width: 200px) and (max-width: 500px)", true, []float64{200, 480, 499, 500}},
+		{"(max-width: 200px) or (min-width: 500px)", true, []float64{100, 200, 500, 900}},
+		{"(400px < width <= 500px)", true, []float64{480, 499, 500}},
+		{"(width > 499px) and (not (width > 500px))", true, []float64{500}},
+		{"(30em = width)", true, []float64{480}},
+		{"(width)", true, []float64{100, 200, 480, 499, 500, 900}},
+		{"(prefers-reduced-motion: reduce) or (max-width: 100px)", true, []float64{100}},
+		{"(hover)", true, []float64{}},
+		{"(a) and (b) or (c)", false, nil},
+		{"min-width: 1px", false, nil},
+		{"(min-width: 1px", false, nil},
+		{"(min-width: 1px) (max-width: 2px)", false, nil},
+	}
+
+	viewports := []float64{100, 200, 480, 499, 500, 900}
+
+	for _, tc := range testcases {
+		t.Run(tc.condition, func(t *testing.T) {
+			assert := assert.New(t)
+			condition, ok := parseMediaCondition(tc.condition)
+			assert.Equal(ok, tc.valid)
+			if !ok {
+				return
+			}
+
+			matches := []float64{}
+			for _, viewport := range viewports {
+				if condition(viewport) {
+					matches = append(matches, viewport)
+				}
+			}
+			assert.Equal(matches, tc.matches)
+		})
+	}
+}
+
+func Test_Select(t *testing.T) {
+	densities := ParseSrcset("a.jpg 1x, b.jpg 2x, c.jpg 3x")
+	widths := ParseSrcset("s.jpg 400w, m.jpg 800w, l.jpg 1600w")
+	sizes := ParseSizes("(max-width: 600px) 100vw, 50vw")
+
+	testcases := []struct {
+		name     string
+		srcset   Srcset
+		viewport float64
+		dpr      float64
+		expected string
+	}{
+		{"exact density", densities, 1000, 2, "b.jpg"},
+		{"next higher density", densities, 1000, 1.5, "b.jpg"},
+		{"highest density when none is enough", densities, 1000, 4, "c.jpg"},
+		{"small viewport", widths, 400, 1, "s.jpg"},
+		{"small viewport high dpr", widths, 400, 2, "m.jpg"},
+		{"large viewport uses half the width", widths, 1200, 1, "m.jpg"},
+		{"large viewport high dpr", widths, 1200, 3, "l.jpg"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			candidate, ok := tc.srcset.Select(sizes, tc.viewport, tc.dpr)
+			assert.True(ok)
+			assert.Equal(candidate.URL, tc.expected)
+		})
+	}
+
+	_, ok := Srcset{}.Select(sizes, 100, 1)
+	assert.False(t, ok)
+}
+
+func Test_CurrentSource(t *testing.T) {
+	testcases := []struct {
+		name     string
+		html     string
+		dpr      float64
+		expected string
+	}{
+		{"src only", `<img src="a.jpg">`, 2, "a.jpg"},
+		{"src is the 1x candidate", `<img src="a.jpg" srcset="b.jpg 2x">`, 1, "a.jpg"},
+		{"srcset wins at higher dpr", `<img src="a.jpg" srcset="b.jpg 2x">`, 2, "b.jpg"},
+		{"src ignored with a 1x candidate", `<img src="a.jpg" srcset="c.jpg, b.jpg 2x">`, 1, "c.jpg"},
+		{"src ignored with widths", `<img src="a.jpg" srcset="b.jpg 100w, c.jpg 900w" sizes="10vw">`, 1, "b.jpg"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.html)
+			source, ok := document.firstChild.CurrentSource(1000, tc.dpr)
+			assert.True(ok)
+			assert.Equal(source, tc.expected)
+		})
+	}
+
+	_, ok := NewElement(TagElement, "img", "").CurrentSource(1000, 1)
+	assert.False(t, ok)
+}
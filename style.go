@@ -0,0 +1,340 @@
+package parseme
+
+import (
+	"iter"
+	"strings"
+)
+
+// Declaration is a single property: value pair of a CSS declaration list.
+type Declaration struct {
+	Property  string
+	Value     string
+	Important bool
+}
+
+func (d Declaration) String() string {
+	if d.Important {
+		return d.Property + ": " + d.Value + " !important;"
+	}
+
+	return d.Property + ": " + d.Value + ";"
+}
+
+// Style edits a property whose value is a CSS declaration list, such as the
+// style property. Like TokenList it reads the value on every call and
+// writes every change back through SetValue.
+type Style struct {
+	property *Property
+}
+
+func (p *Property) Style() *Style {
+	return &Style{property: p}
+}
+
+// Declarations returns the valid declarations in the order they are
+// written, including repeated properties. Invalid declarations are skipped
+// as browsers do.
+func (s *Style) Declarations() []Declaration {
+	return parseDeclarations(s.property.value)
+}
+
+func (s *Style) All() iter.Seq2[int, Declaration] {
+	return func(yield func(int, Declaration) bool) {
+		for i, declaration := range s.Declarations() {
+			if !yield(i, declaration) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Style) Length() int {
+	return len(s.Declarations())
+}
+
+// Get returns the declaration that applies to the given property: the last
+// important one or, when there is none, the last one.
+func (s *Style) Get(property string) (Declaration, bool) {
+	property = normalizeCssProperty(property)
+	result, found := Declaration{}, false
+
+	for _, declaration := range s.Declarations() {
+		if declaration.Property != property || (found && result.Important && !declaration.Important) {
+			continue
+		}
+		result, found = declaration, true
+	}
+
+	return result, found
+}
+
+// Value returns the value that applies to the given property, or an empty
+// string when the property is not declared.
+func (s *Style) Value(property string) string {
+	declaration, _ := s.Get(property)
+	return declaration.Value
+}
+
+// Set declares the property in place of its first declaration, removing any
+// other, or at the end when it is not declared yet.
+func (s *Style) Set(property string, value string, important bool) error {
+	declaration, err := newDeclaration(property, value, important)
+	if err != nil {
+		return err
+	}
+
+	declarations := []Declaration{}
+	replaced := false
+
+	for _, current := range s.Declarations() {
+		if current.Property != declaration.Property {
+			declarations = append(declarations, current)
+		} else if !replaced {
+			declarations = append(declarations, declaration)
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		declarations = append(declarations, declaration)
+	}
+
+	return s.write(declarations)
+}
+
+// Remove removes every declaration of the property and reports whether
+// there was any.
+func (s *Style) Remove(property string) (bool, error) {
+	property = normalizeCssProperty(property)
+	declarations := []Declaration{}
+	removed := false
+
+	for _, declaration := range s.Declarations() {
+		if declaration.Property == property {
+			removed = true
+			continue
+		}
+		declarations = append(declarations, declaration)
+	}
+
+	if !removed {
+		return false, nil
+	}
+
+	return true, s.write(declarations)
+}
+
+// String serializes the declarations the way browsers serialize the
+// style property, dropping comments and invalid declarations.
+func (s *Style) String() string {
+	return serializeDeclarations(s.Declarations())
+}
+
+func (s *Style) write(declarations []Declaration) error {
+	return s.property.SetValue(serializeDeclarations(declarations))
+}
+
+func serializeDeclarations(declarations []Declaration) string {
+	parts := []string{}
+	for _, declaration := range declarations {
+		parts = append(parts, declaration.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// newDeclaration checks that the value can be written back without
+// changing the meaning of the rest of the list, which a value with an open
+// string or parenthesis would do.
+func newDeclaration(property string, value string, important bool) (Declaration, error) {
+	property = normalizeCssProperty(property)
+	value = strings.Trim(value, cssSpaces)
+
+	if !isCssIdentifier(property) {
+		return Declaration{}, &PropertyInvalidCssPropertyError{property: property}
+	}
+
+	parsed := parseDeclarations(property + ":" + value + ";x:y")
+	if value == "" || len(parsed) != 2 || parsed[0].Value != value || parsed[0].Important || parsed[1].Property != "x" {
+		return Declaration{}, &PropertyInvalidCssValueError{property: property, value: value}
+	}
+
+	return Declaration{Property: property, Value: value, Important: important}, nil
+}
+
+const cssSpaces = " \t\n\r\f"
+
+// normalizeCssProperty lowercases property names. Custom properties keep
+// their case.
+func normalizeCssProperty(property string) string {
+	property = strings.Trim(property, cssSpaces)
+	if strings.HasPrefix(property, "--") {
+		return property
+	}
+
+	return strings.ToLower(property)
+}
+
+func isCssIdentifier(name string) bool {
+	if name == "" || name == "-" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		current := name[i]
+		isLetter := (current >= 'a' && current <= 'z') || (current >= 'A' && current <= 'Z')
+		isDigit := current >= '0' && current <= '9'
+		if !isLetter && !isDigit && current != '-' && current != '_' && current < 0x80 {
+			return false
+		}
+	}
+
+	return !(name[0] == '-' && name[1] >= '0' && name[1] <= '9')
+}
+
+// parseDeclarations splits a declaration list on the semicolons that are
+// not inside strings, comments or blocks, and splits every declaration on
+// its first colon.
+func parseDeclarations(input string) []Declaration {
+	declarations := []Declaration{}
+
+	for _, part := range splitCss(stripCssComments(input), ';') {
+		colon := indexCss(part, ':')
+		if colon < 0 {
+			continue
+		}
+
+		property := normalizeCssProperty(part[:colon])
+		if !isCssIdentifier(property) {
+			continue
+		}
+
+		value, important := splitImportant(strings.Trim(part[colon+1:], cssSpaces))
+		if value == "" {
+			continue
+		}
+
+		declarations = append(declarations, Declaration{Property: property, Value: value, Important: important})
+	}
+
+	return declarations
+}
+
+// splitImportant removes a trailing !important, which may have whitespace
+// after the exclamation mark and any letter case.
+func splitImportant(value string) (string, bool) {
+	lower := strings.ToLower(value)
+	if !strings.HasSuffix(lower, "important") {
+		return value, false
+	}
+
+	rest := strings.TrimRight(value[:len(value)-len("important")], cssSpaces)
+	if !strings.HasSuffix(rest, "!") || strings.HasSuffix(rest, "\\!") {
+		return value, false
+	}
+
+	return strings.Trim(rest[:len(rest)-1], cssSpaces), true
+}
+
+// scanCss calls visit for every byte that is outside strings and escapes,
+// along with the nesting depth of parentheses, brackets and braces. Closing
+// characters are at the depth of the block they close.
+func scanCss(input string, visit func(index int, depth int) bool) {
+	depth := 0
+	var quote byte
+
+	for i := 0; i < len(input); i++ {
+		current := input[i]
+
+		switch {
+		case current == '\\':
+			i++
+		case quote != 0:
+			if current == quote || current == '\n' {
+				quote = 0
+			}
+		case current == '"' || current == '\'':
+			quote = current
+		default:
+			if !visit(i, depth) {
+				return
+			}
+			if current == '(' || current == '[' || current == '{' {
+				depth++
+			}
+			if current == ')' || current == ']' || current == '}' {
+				depth = max(depth-1, 0)
+			}
+		}
+	}
+}
+
+func splitCss(input string, separator byte) []string {
+	parts := []string{}
+	start := 0
+
+	scanCss(input, func(index int, depth int) bool {
+		if depth == 0 && input[index] == separator {
+			parts = append(parts, input[start:index])
+			start = index + 1
+		}
+		return true
+	})
+
+	return append(parts, input[start:])
+}
+
+func indexCss(input string, target byte) int {
+	result := -1
+
+	scanCss(input, func(index int, depth int) bool {
+		if depth == 0 && input[index] == target {
+			result = index
+			return false
+		}
+		return true
+	})
+
+	return result
+}
+
+// stripCssComments removes comments outside strings. A comment between two
+// tokens is replaced with a space so that they stay apart.
+func stripCssComments(input string) string {
+	if !strings.Contains(input, "/*") {
+		return input
+	}
+
+	builder := strings.Builder{}
+	var quote byte
+
+	for i := 0; i < len(input); i++ {
+		current := input[i]
+
+		switch {
+		case current == '\\' && i+1 < len(input):
+			builder.WriteByte(current)
+			builder.WriteByte(input[i+1])
+			i++
+			continue
+		case quote != 0:
+			if current == quote || current == '\n' {
+				quote = 0
+			}
+		case current == '"' || current == '\'':
+			quote = current
+		case current == '/' && strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return builder.String()
+			}
+			builder.WriteByte(' ')
+			i += end + 3
+			continue
+		}
+
+		builder.WriteByte(current)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,120 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseDeclarations(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected []Declaration
+	}{
+		{
+			"simple declarations",
+			"color: red; MARGIN:0 auto",
+			[]Declaration{{"color", "red", false}, {"margin", "0 auto", false}},
+		},
+		{
+			"important",
+			"color: red !important; width: 1px ! IMPORTANT;",
+			[]Declaration{{"color", "red", true}, {"width", "1px", true}},
+		},
+		{
+			"semicolons in strings and functions",
+			`content: "a;b"; background: url(data:image/png;base64,AA==) no-repeat`,
+			[]Declaration{{"content", `"a;b"`, false}, {"background", "url(data:image/png;base64,AA==) no-repeat", false}},
+		},
+		{
+			"comments are dropped",
+			"/* a */ color: /* b */ red; border: 1px/**/solid",
+			[]Declaration{{"color", "red", false}, {"border", "1px solid", false}},
+		},
+		{
+			"invalid declarations are skipped",
+			"color; : red; 1x: y; width:; height: 2px",
+			[]Declaration{{"height", "2px", false}},
+		},
+		{
+			"custom properties keep their case",
+			"--Main-Color: #fff; color: var(--Main-Color)",
+			[]Declaration{{"--Main-Color", "#fff", false}, {"color", "var(--Main-Color)", false}},
+		},
+		{
+			"escaped exclamation mark",
+			`content: "x" \!important`,
+			[]Declaration{{"content", `"x" \!important`, false}},
+		},
+		{
+			"empty value",
+			"  ",
+			[]Declaration{},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(NewProperty(Value, "style", tc.value).Style().Declarations(), tc.expected)
+		})
+	}
+}
+
+func Test_StyleGet(t *testing.T) {
+	assert := assert.New(t)
+	style := NewProperty(Value, "style", "color: red !important; color: blue; margin: 0; margin: 1px").Style()
+
+	declaration, ok := style.Get("Color")
+	assert.True(ok)
+	assert.Equal(declaration, Declaration{"color", "red", true})
+	assert.Equal(style.Value("margin"), "1px")
+	assert.Equal(style.Value("padding"), "")
+	assert.Equal(style.Length(), 4)
+}
+
+func Test_StyleEdit(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, `<div style="position:fixed; top: 0;color:red;POSITION: absolute"></div>`)
+	style := document.firstChild.Property("style").Style()
+
+	removed, err := style.Remove("position")
+	assert.Nil(err)
+	assert.True(removed)
+	removed, err = style.Remove("position")
+	assert.Nil(err)
+	assert.False(removed)
+
+	assert.Nil(style.Set("color", "blue", true))
+	assert.Nil(style.Set("z-index", "1", false))
+	assert.Equal(style.String(), "top: 0; color: blue !important; z-index: 1;")
+	assert.Equal(renderHtml(t, document), `<div style="top: 0; color: blue !important; z-index: 1;"></div>`)
+}
+
+func Test_StyleSetErrors(t *testing.T) {
+	testcases := []struct {
+		name     string
+		property string
+		value    string
+		expected error
+	}{
+		{"invalid property", "1x", "a", &PropertyInvalidCssPropertyError{property: "1x"}},
+		{"empty value", "color", " ", &PropertyInvalidCssValueError{property: "color", value: ""}},
+		{"semicolon", "color", "red; position: fixed", &PropertyInvalidCssValueError{property: "color", value: "red; position: fixed"}},
+		{"open parenthesis", "background", "url(x", &PropertyInvalidCssValueError{property: "background", value: "url(x"}},
+		{"open string", "content", "'x", &PropertyInvalidCssValueError{property: "content", value: "'x"}},
+		{"important in value", "color", "red !important", &PropertyInvalidCssValueError{property: "color", value: "red !important"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			property := NewProperty(Value, "style", "color: red")
+			err := property.Style().Set(tc.property, tc.value, false)
+			assert.Equal(err, tc.expected)
+			assert.EqualError(err, tc.expected.Error())
+			assert.Equal(property.Value(), "color: red")
+		})
+	}
+}
@@ -0,0 +1,561 @@
+package parseme
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+type CssRuleType int
+
+const (
+	StyleRule CssRuleType = iota
+	MediaRule
+	ImportRule
+	FontFaceRule
+	// OtherAtRule covers every other at-rule, such as @supports, @page or
+	// @keyframes.
+	OtherAtRule
+)
+
+// Stylesheet is the object model of a style element or of a linked
+// stylesheet.
+type Stylesheet struct {
+	Rules []*CssRule
+	// Owner is the style or link element the stylesheet comes from, nil
+	// for stylesheets parsed with ParseStylesheet.
+	Owner *Element
+	// Href is the path of a linked stylesheet.
+	Href string
+}
+
+// CssRule is a style rule or an at-rule. Line and Column give the position
+// of its first character in the HTML document or in the linked file.
+type CssRule struct {
+	Type CssRuleType
+	// Name is the name of an at-rule without the '@'.
+	Name string
+	// Prelude is what comes before the block: the selector list of a style
+	// rule, the media query list of @media or the whole @import prelude.
+	Prelude string
+	// Href is the URL imported by @import.
+	Href string
+	// Media is the media query list of @import.
+	Media        string
+	Declarations []*CssDeclaration
+	// Rules are the rules nested in @media, @supports, @keyframes and the
+	// other at-rules whose block contains rules.
+	Rules  []*CssRule
+	Line   int
+	Column int
+}
+
+type CssDeclaration struct {
+	Declaration
+	Line   int
+	Column int
+}
+
+// ParseStylesheet parses CSS the way browsers do: invalid rules and
+// declarations are skipped and unclosed blocks are closed at the end of
+// the input.
+func ParseStylesheet(css string) *Stylesheet {
+	parser := newCssParser(css, 1, 1)
+	return &Stylesheet{Rules: parser.parseRules(false)}
+}
+
+// Selectors splits the prelude of a style rule into its selectors.
+func (r *CssRule) Selectors() []string {
+	selectors := []string{}
+	for _, selector := range splitCss(r.Prelude, ',') {
+		selectors = append(selectors, strings.Trim(selector, cssSpaces))
+	}
+
+	return selectors
+}
+
+// Selector compiles the prelude of a style rule so that it can be matched
+// against the document.
+func (r *CssRule) Selector() (*Selector, error) {
+	return CompileSelector(r.Prelude)
+}
+
+// Declaration returns the declaration of the property that applies, like
+// Style.Get does.
+func (r *CssRule) Declaration(property string) *CssDeclaration {
+	property = normalizeCssProperty(property)
+	var result *CssDeclaration
+
+	for _, declaration := range r.Declarations {
+		if declaration.Property != property || (result != nil && result.Important && !declaration.Important) {
+			continue
+		}
+		result = declaration
+	}
+
+	return result
+}
+
+func (r *CssRule) String() string {
+	builder := &strings.Builder{}
+	r.write(builder, "")
+	return builder.String()
+}
+
+func (s *Stylesheet) String() string {
+	builder := &strings.Builder{}
+	writeCssRules(builder, s.Rules, "")
+	return builder.String()
+}
+
+// All returns the rules in order, including those nested in at-rules.
+func (s *Stylesheet) All() []*CssRule {
+	rules := []*CssRule{}
+
+	var visit func(list []*CssRule)
+	visit = func(list []*CssRule) {
+		for _, rule := range list {
+			rules = append(rules, rule)
+			visit(rule.Rules)
+		}
+	}
+	visit(s.Rules)
+
+	return rules
+}
+
+func writeCssRules(builder *strings.Builder, rules []*CssRule, indent string) {
+	for i, rule := range rules {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		rule.write(builder, indent)
+	}
+}
+
+func (r *CssRule) write(builder *strings.Builder, indent string) {
+	builder.WriteString(indent)
+
+	if r.Type != StyleRule {
+		builder.WriteString("@" + r.Name)
+		if r.Prelude != "" {
+			builder.WriteString(" ")
+		}
+	}
+	builder.WriteString(r.Prelude)
+
+	if r.Type == ImportRule || (r.Type == OtherAtRule && r.Declarations == nil && r.Rules == nil) {
+		builder.WriteString(";")
+		return
+	}
+
+	if r.Rules != nil {
+		builder.WriteString(" {\n")
+		writeCssRules(builder, r.Rules, indent+"  ")
+		builder.WriteString("\n" + indent + "}")
+		return
+	}
+
+	builder.WriteString(" {")
+	for _, declaration := range r.Declarations {
+		builder.WriteString(" " + declaration.String())
+	}
+	builder.WriteString(" }")
+}
+
+// Stylesheets parses the style elements and the linked stylesheets of the
+// document in document order. Linked stylesheets are read from dir when
+// their href is a relative URL or a file URL that resolves inside dir;
+// other links are skipped.
+// Files that cannot be read are left out of the sheets and reported
+// together in a StylesheetLoadError.
+func (e *Element) Stylesheets(dir string) ([]*Stylesheet, error) {
+	sheets := []*Stylesheet{}
+	failures := []error{}
+
+	for element := range e.Descendants() {
+		if element.elementType != TagElement {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(element.name, "style") && isCssType(element.Property("type")):
+			sheets = append(sheets, parseStyleElement(element))
+		case strings.EqualFold(element.name, "link") && isStylesheetLink(element):
+			path, ok := localStylesheetPath(element.Property("href").value, dir)
+			if !ok {
+				continue
+			}
+
+			bytes, err := fetchFileContents(path)
+			if err != nil {
+				failures = append(failures, err)
+				continue
+			}
+
+			parser := newCssParser(string(*bytes), 1, 1)
+			sheets = append(sheets, &Stylesheet{Rules: parser.parseRules(false), Owner: element, Href: path})
+		}
+	}
+
+	if len(failures) > 0 {
+		return sheets, &StylesheetLoadError{Errors: failures}
+	}
+
+	return sheets, nil
+}
+
+func parseStyleElement(element *Element) *Stylesheet {
+	builder := strings.Builder{}
+	line, column := element.line, element.column
+
+	for child := element.firstChild; child != nil; child = child.nextSibling {
+		if child.elementType == TextElement {
+			if builder.Len() == 0 {
+				line, column = child.line, child.column
+			}
+			builder.WriteString(child.value)
+		}
+	}
+
+	parser := newCssParser(builder.String(), line, column)
+	return &Stylesheet{Rules: parser.parseRules(false), Owner: element}
+}
+
+func isCssType(property *Property) bool {
+	return property == nil || property.value == "" || strings.EqualFold(strings.Trim(property.value, cssSpaces), "text/css")
+}
+
+func isStylesheetLink(element *Element) bool {
+	rel, href := element.Property("rel"), element.Property("href")
+	if rel == nil || href == nil {
+		return false
+	}
+
+	for _, token := range rel.TokenList().Values() {
+		if strings.EqualFold(token, "stylesheet") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func localStylesheetPath(href string, dir string) (string, bool) {
+	reference, err := url.Parse(strings.Trim(href, cssSpaces))
+	if err != nil || reference.Host != "" || reference.Path == "" {
+		return "", false
+	}
+
+	var path string
+	switch {
+	case reference.Scheme == "file":
+		path = filepath.FromSlash(reference.Path)
+	case reference.Scheme != "":
+		return "", false
+	default:
+		path = filepath.Join(dir, filepath.FromSlash(reference.Path))
+	}
+
+	// Documents must not reach files outside dir
+	if !isInsideDir(path, dir) {
+		return "", false
+	}
+
+	return path, true
+}
+
+func isInsideDir(path string, dir string) bool {
+	absoluteDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	relative, err := filepath.Rel(absoluteDir, absolutePath)
+	if err != nil {
+		return false
+	}
+
+	return relative != "." && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// Block at-rules whose block contains rules rather than declarations
+var cssGroupingRules = map[string]bool{
+	"media":             true,
+	"supports":          true,
+	"document":          true,
+	"layer":             true,
+	"container":         true,
+	"scope":             true,
+	"starting-style":    true,
+	"keyframes":         true,
+	"-webkit-keyframes": true,
+	"-moz-keyframes":    true,
+	"-o-keyframes":      true,
+	"-moz-document":     true,
+}
+
+type cssParser struct {
+	// input is the CSS with its comments replaced by spaces, so that
+	// offsets into it are offsets into source
+	input  string
+	source string
+	pos    int
+	line   int
+	column int
+}
+
+func newCssParser(css string, line int, column int) *cssParser {
+	return &cssParser{input: blankCssComments(css), source: css, line: line, column: column}
+}
+
+// position converts an offset to a position in the document, where the
+// first line of the CSS starts at the given column.
+func (p *cssParser) position(offset int) (int, int) {
+	before := p.source[:offset]
+	lines := strings.Count(before, "\n")
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+
+	if lines == 0 {
+		return p.line, p.column + column - 1
+	}
+
+	return p.line + lines, column
+}
+
+func (p *cssParser) skipSpaces() {
+	for p.pos < len(p.input) && strings.IndexByte(cssSpaces, p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// find returns the offset of the first byte of stops at depth zero, or the
+// end of the input.
+func (p *cssParser) find(start int, stops string) int {
+	result := len(p.input)
+
+	scanCss(p.input[start:], func(index int, depth int) bool {
+		if depth == 0 && strings.IndexByte(stops, p.input[start+index]) >= 0 {
+			result = start + index
+			return false
+		}
+		return true
+	})
+
+	return result
+}
+
+func (p *cssParser) text(start int, end int) string {
+	return strings.Trim(stripCssComments(p.source[start:end]), cssSpaces)
+}
+
+// parseRules reads rules until the end of the input or, inside a block,
+// until the closing brace.
+func (p *cssParser) parseRules(nested bool) []*CssRule {
+	rules := []*CssRule{}
+
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			return rules
+		}
+
+		if p.input[p.pos] == '}' {
+			p.pos++
+			if nested {
+				return rules
+			}
+			continue
+		}
+
+		// HTML comment delimiters are ignored at the top level
+		if !nested && strings.HasPrefix(p.input[p.pos:], "<!--") {
+			p.pos += len("<!--")
+			continue
+		}
+		if !nested && strings.HasPrefix(p.input[p.pos:], "-->") {
+			p.pos += len("-->")
+			continue
+		}
+
+		var rule *CssRule
+		if p.input[p.pos] == '@' {
+			rule = p.parseAtRule()
+		} else {
+			rule = p.parseStyleRule()
+		}
+
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+}
+
+func (p *cssParser) parseStyleRule() *CssRule {
+	start := p.pos
+	open := p.find(start, "{}")
+
+	// A style rule without a block is dropped
+	if open >= len(p.input) || p.input[open] == '}' {
+		p.pos = open
+		return nil
+	}
+
+	line, column := p.position(start)
+	rule := &CssRule{Type: StyleRule, Prelude: p.text(start, open), Line: line, Column: column}
+	rule.Declarations = p.parseDeclarationBlock(open + 1)
+
+	if rule.Prelude == "" {
+		return nil
+	}
+
+	return rule
+}
+
+func (p *cssParser) parseAtRule() *CssRule {
+	start := p.pos
+	nameEnd := start + 1
+	for nameEnd < len(p.input) && (isCssNameByte(p.input[nameEnd])) {
+		nameEnd++
+	}
+
+	name := strings.ToLower(p.input[start+1 : nameEnd])
+	end := p.find(nameEnd, ";{}")
+	line, column := p.position(start)
+	rule := &CssRule{Type: OtherAtRule, Name: name, Prelude: p.text(nameEnd, end), Line: line, Column: column}
+
+	switch name {
+	case "media":
+		rule.Type = MediaRule
+	case "import":
+		rule.Type = ImportRule
+		rule.Href, rule.Media = splitImportPrelude(rule.Prelude)
+	case "font-face":
+		rule.Type = FontFaceRule
+	}
+
+	switch {
+	case end >= len(p.input):
+		p.pos = end
+	case p.input[end] == ';':
+		p.pos = end + 1
+	case p.input[end] == '}':
+		// The closing brace belongs to the enclosing block
+		p.pos = end
+	case cssGroupingRules[name]:
+		p.pos = end + 1
+		rule.Rules = p.parseRules(true)
+	default:
+		rule.Declarations = p.parseDeclarationBlock(end + 1)
+	}
+
+	if name == "" || (rule.Type == ImportRule && rule.Href == "") {
+		return nil
+	}
+
+	return rule
+}
+
+// parseDeclarationBlock reads the declarations from start to the closing
+// brace and moves past it.
+func (p *cssParser) parseDeclarationBlock(start int) []*CssDeclaration {
+	declarations := []*CssDeclaration{}
+	close := p.find(start, "}")
+
+	for offset := start; offset < close; {
+		end := min(p.find(offset, ";}"), close)
+
+		segment := offset
+		for segment < end && strings.IndexByte(cssSpaces, p.input[segment]) >= 0 {
+			segment++
+		}
+
+		for _, declaration := range parseDeclarations(p.source[segment:end]) {
+			line, column := p.position(segment)
+			declarations = append(declarations, &CssDeclaration{Declaration: declaration, Line: line, Column: column})
+		}
+
+		offset = end + 1
+	}
+
+	p.pos = min(close+1, len(p.input))
+	return declarations
+}
+
+// splitImportPrelude reads the URL of @import, written as a string or with
+// url(), and the media queries that follow it.
+func splitImportPrelude(prelude string) (string, string) {
+	rest := prelude
+	href := ""
+
+	switch {
+	case rest != "" && (rest[0] == '"' || rest[0] == '\''):
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end < 0 {
+			return "", ""
+		}
+		href, rest = rest[1:end+1], rest[end+2:]
+	case len(rest) > 4 && strings.EqualFold(rest[:4], "url("):
+		end := strings.IndexByte(rest, ')')
+		if end < 0 {
+			return "", ""
+		}
+		href = strings.Trim(rest[4:end], cssSpaces+"\"'")
+		rest = rest[end+1:]
+	default:
+		return "", ""
+	}
+
+	return href, strings.Trim(rest, cssSpaces)
+}
+
+func isCssNameByte(current byte) bool {
+	return (current >= 'a' && current <= 'z') || (current >= 'A' && current <= 'Z') ||
+		(current >= '0' && current <= '9') || current == '-' || current == '_' || current >= 0x80
+}
+
+// blankCssComments replaces comments with spaces, keeping line breaks, so
+// that the result has the same offsets as the input.
+func blankCssComments(input string) string {
+	if !strings.Contains(input, "/*") {
+		return input
+	}
+
+	bytes := []byte(input)
+	var quote byte
+
+	for i := 0; i < len(bytes); i++ {
+		current := bytes[i]
+
+		switch {
+		case current == '\\':
+			i++
+		case quote != 0:
+			if current == quote || current == '\n' {
+				quote = 0
+			}
+		case current == '"' || current == '\'':
+			quote = current
+		case current == '/' && i+1 < len(bytes) && bytes[i+1] == '*':
+			end := strings.Index(input[i+2:], "*/")
+			last := len(bytes)
+			if end >= 0 {
+				last = i + end + 4
+			}
+			for j := i; j < last; j++ {
+				if bytes[j] != '\n' {
+					bytes[j] = ' '
+				}
+			}
+			i = last - 1
+		}
+	}
+
+	return string(bytes)
+}
@@ -0,0 +1,195 @@
+package parseme
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// describeRules lists rules as type, name, prelude and declarations, one
+// rule per entry, with nested rules following their parent.
+func describeRules(rules []*CssRule) []string {
+	result := []string{}
+	for _, rule := range (&Stylesheet{Rules: rules}).All() {
+		description := rule.Prelude
+		if rule.Type != StyleRule {
+			description = "@" + rule.Name + " " + description
+		}
+		for _, declaration := range rule.Declarations {
+			description += " | " + declaration.String()
+		}
+		result = append(result, description)
+	}
+
+	return result
+}
+
+func Test_ParseStylesheet(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			"style rules",
+			"h1, .title > a { color: red; margin: 0 !important } p{}",
+			[]string{"h1, .title > a | color: red; | margin: 0 !important;", "p"},
+		},
+		{
+			"comments",
+			"/* a { b: c } */ a /* x */ { /* y */ color: /* z */ red }",
+			[]string{"a | color: red;"},
+		},
+		{
+			"media rule",
+			"@media screen and (max-width: 600px) { a { color: red } @media print { b { x: y } } } c { d: e }",
+			[]string{"@media screen and (max-width: 600px)", "a | color: red;", "@media print", "b | x: y;", "c | d: e;"},
+		},
+		{
+			"font face",
+			"@font-face { font-family: \"Open Sans\"; src: url(a.woff2) format(\"woff2\") }",
+			[]string{"@font-face  | font-family: \"Open Sans\"; | src: url(a.woff2) format(\"woff2\");"},
+		},
+		{
+			"other at-rules",
+			"@charset \"utf-8\"; @keyframes spin { from { rotate: 0 } to { rotate: 1turn } } @page :first { margin: 1in }",
+			[]string{"@charset \"utf-8\"", "@keyframes spin", "from | rotate: 0;", "to | rotate: 1turn;", "@page :first | margin: 1in;"},
+		},
+		{
+			"braces in strings",
+			"a[title=\"}\"] { content: \"{;}\" } b { c: d }",
+			[]string{"a[title=\"}\"] | content: \"{;}\";", "b | c: d;"},
+		},
+		{
+			"invalid declarations and stray braces",
+			"} a { color; : red; width: 1px } ; b { c: d",
+			[]string{"a | width: 1px;", "; b | c: d;"},
+		},
+		{
+			"html comment delimiters",
+			"<!-- a { b: c } -->",
+			[]string{"a | b: c;"},
+		},
+		{
+			"nested block in declarations",
+			"a { b { c: d } e: f; g: h }",
+			[]string{"a | g: h;"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(describeRules(ParseStylesheet(tc.value).Rules), tc.expected)
+		})
+	}
+}
+
+func Test_ImportRule(t *testing.T) {
+	testcases := []struct {
+		name  string
+		value string
+		href  string
+		media string
+	}{
+		{"string", "@import 'a.css';", "a.css", ""},
+		{"url function", "@import url(\"b.css\") screen, print;", "b.css", "screen, print"},
+		{"unquoted url", "@import URL( c.css ) layer(base);", "c.css", "layer(base)"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			rules := ParseStylesheet(tc.value).Rules
+			assert.Len(rules, 1)
+			assert.Equal(rules[0].Type, ImportRule)
+			assert.Equal(rules[0].Href, tc.href)
+			assert.Equal(rules[0].Media, tc.media)
+		})
+	}
+
+	assert.Empty(t, ParseStylesheet("@import foo;").Rules)
+}
+
+func Test_StylesheetString(t *testing.T) {
+	assert := assert.New(t)
+	sheet := ParseStylesheet("@import 'a.css';@media print{a,b{color:red!important}}p{margin:0}")
+
+	sheet.Rules[2].Declarations[0].Value = "1px"
+	assert.Equal(sheet.String(), "@import 'a.css';\n@media print {\n  a,b { color: red !important; }\n}\np { margin: 1px; }")
+	assert.Equal(sheet.Rules[1].Rules[0].Selectors(), []string{"a", "b"})
+	assert.Equal(sheet.Rules[1].Rules[0].Declaration("COLOR").Value, "red")
+	assert.Nil(sheet.Rules[2].Declaration("color"))
+}
+
+func Test_Stylesheets(t *testing.T) {
+	assert := assert.New(t)
+	input := "<html><head>\n" +
+		"  <link rel=\"preload stylesheet\" href=\"example.css\">\n" +
+		"  <link rel=stylesheet href=\"https://cdn.example.com/a.css\">\n" +
+		"  <link rel=icon href=\"favicon.ico\">\n" +
+		"  <style type=text/less>a { b: c }</style>\n" +
+		"  <style>\n    p.note { color: /* é */ red;\n      margin: 0 }\n  </style>\n" +
+		"</head></html>"
+	document, _, _ := parseString(HtmlMode, input)
+
+	sheets, err := document.Stylesheets("test_data")
+	assert.Nil(err)
+	assert.Len(sheets, 2)
+
+	linked := sheets[0]
+	assert.Equal(linked.Href, filepath.Join("test_data", "example.css"))
+	assert.Equal(linked.Owner.Name(), "link")
+	assert.Equal(describeRules(linked.Rules), []string{"@import url(\"print.css\") print", "body | margin: 0;"})
+	assert.Equal([]int{linked.Rules[1].Line, linked.Rules[1].Column}, []int{3, 1})
+	assert.Equal([]int{linked.Rules[1].Declarations[0].Line, linked.Rules[1].Declarations[0].Column}, []int{4, 3})
+
+	embedded := sheets[1]
+	rule := embedded.Rules[0]
+	assert.Equal(embedded.Owner.Name(), "style")
+	assert.Equal([]int{rule.Line, rule.Column}, []int{7, 5})
+	assert.Equal([]int{rule.Declarations[0].Line, rule.Declarations[0].Column}, []int{7, 14})
+	assert.Equal([]int{rule.Declarations[1].Line, rule.Declarations[1].Column}, []int{8, 7})
+
+	selector, err := rule.Selector()
+	assert.Nil(err)
+	assert.Empty(selector.QueryAll(document))
+
+	// Local stylesheets that cannot be read are reported, and the other
+	// sheets are still returned
+	sheets, err = document.Stylesheets("missing")
+	assert.IsType(err, &StylesheetLoadError{})
+	assert.ErrorAs(err, new(*FileNotFoundError))
+	assert.Len(sheets, 1)
+	assert.Equal(sheets[0].Owner.Name(), "style")
+}
+
+func Test_localStylesheetPath(t *testing.T) {
+	absolute, _ := filepath.Abs(filepath.Join("test_data", "example.css"))
+
+	testcases := []struct {
+		name     string
+		href     string
+		expected string
+		ok       bool
+	}{
+		{"relative", "example.css", filepath.Join("test_data", "example.css"), true},
+		{"root relative", "/css/a.css", filepath.Join("test_data", "css", "a.css"), true},
+		{"parent inside dir", "css/../example.css", filepath.Join("test_data", "example.css"), true},
+		{"file url inside dir", "file://" + filepath.ToSlash(absolute), absolute, true},
+		{"escapes dir", "../../../etc/x.css", "", false},
+		{"escapes dir after a folder", "css/../../x.css", "", false},
+		{"file url outside dir", "file:///etc/x.css", "", false},
+		{"remote", "https://cdn.example.com/a.css", "", false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			path, ok := localStylesheetPath(tc.href, "test_data")
+			assert.Equal(ok, tc.ok)
+			assert.Equal(path, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package parseme
+
+import (
+	"strings"
+)
+
+// TextContent returns the value of text, CDATA, comment and processing
+// instruction nodes, and the concatenated text and CDATA of the
+// descendants of documents and tags, script and style bodies included.
+func (e *Element) TextContent() string {
+	if e.elementType != DocumentElement && e.elementType != TagElement {
+		return e.value
+	}
+
+	builder := strings.Builder{}
+	for node := range e.Descendants() {
+		if node.elementType == TextElement || node.elementType == CDATAElement {
+			builder.WriteString(node.value)
+		}
+	}
+
+	return builder.String()
+}
+
+// InnerText approximates the text a browser renders for the element,
+// using the default styles of HTML elements and the display property of
+// style properties. Blocks are separated by line breaks and paragraphs by
+// blank lines, table cells by tabs, and whitespace is collapsed outside
+// preformatted elements. Hidden elements are skipped, and a hidden element
+// returns its TextContent.
+func (e *Element) InnerText() string {
+	if e.elementType == TagElement && !isRendered(e) {
+		return e.TextContent()
+	}
+
+	collector := &innerTextCollector{}
+	for child := e.firstChild; child != nil; child = child.nextSibling {
+		collector.collect(child, false)
+	}
+
+	return collector.String()
+}
+
+// innerTextItem is either text or a number of required line breaks.
+type innerTextItem struct {
+	text      string
+	breaks    int
+	collapses bool
+}
+
+type innerTextCollector struct {
+	items []innerTextItem
+}
+
+func (c *innerTextCollector) text(text string, collapses bool) {
+	c.items = append(c.items, innerTextItem{text: text, collapses: collapses})
+}
+
+func (c *innerTextCollector) lineBreaks(count int) {
+	c.items = append(c.items, innerTextItem{breaks: count})
+}
+
+func (c *innerTextCollector) collect(node *Element, preformatted bool) {
+	switch node.elementType {
+	case TextElement, CDATAElement:
+		c.text(node.value, !preformatted)
+		return
+	case TagElement:
+	default:
+		return
+	}
+
+	if !isRendered(node) {
+		return
+	}
+
+	name := textElementName(node)
+	if name == "br" {
+		c.text("\n", false)
+		return
+	}
+
+	breaks := 0
+	switch {
+	case name == "p":
+		breaks = 2
+	case blockElements[name]:
+		breaks = 1
+	}
+
+	c.lineBreaks(breaks)
+	for child := node.firstChild; child != nil; child = child.nextSibling {
+		c.collect(child, preformatted || preformattedElements[name])
+	}
+	c.lineBreaks(breaks)
+
+	switch name {
+	case "td", "th":
+		if nextSiblingNamed(node, "td", "th") != nil {
+			c.text("\t", false)
+		}
+	case "tr":
+		if nextTableRow(node) != nil {
+			c.lineBreaks(1)
+		}
+	}
+}
+
+// String joins the items. Collapsible whitespace becomes a single space,
+// or nothing at the start and end of a line, and runs of required line
+// breaks become the largest of them.
+func (c *innerTextCollector) String() string {
+	builder := strings.Builder{}
+	pendingBreaks, pendingSpace, lineStart := 0, false, true
+
+	flush := func() {
+		if builder.Len() > 0 {
+			builder.WriteString(strings.Repeat("\n", pendingBreaks))
+		}
+		if pendingSpace {
+			builder.WriteByte(' ')
+		}
+		pendingBreaks, pendingSpace = 0, false
+	}
+
+	for _, item := range c.items {
+		switch {
+		case item.text == "":
+			if item.breaks > 0 {
+				pendingBreaks = max(pendingBreaks, item.breaks)
+				pendingSpace, lineStart = false, true
+			}
+		case item.collapses:
+			for i := 0; i < len(item.text); i++ {
+				if isSpace(item.text[i]) {
+					pendingSpace = pendingSpace || !lineStart
+					continue
+				}
+
+				flush()
+				builder.WriteByte(item.text[i])
+				lineStart = false
+			}
+		default:
+			pendingSpace = pendingSpace && !strings.HasPrefix(item.text, "\n") && item.text != "\t"
+			flush()
+			builder.WriteString(item.text)
+			lineStart = strings.HasSuffix(item.text, "\n") || item.text == "\t"
+		}
+	}
+
+	return builder.String()
+}
+
+// isRendered reports whether the element is displayed according to the
+// default styles, its hidden property and the display property in its
+// style.
+func isRendered(element *Element) bool {
+	if unrenderedElements[textElementName(element)] {
+		return false
+	}
+
+	if hidden := element.Property("hidden"); hidden != nil {
+		if value, _ := hidden.BooleanValue(); value {
+			return false
+		}
+	}
+
+	if style := element.Property("style"); style != nil && !style.IsBoolean() {
+		return !strings.EqualFold(style.Style().Value("display"), "none")
+	}
+
+	return true
+}
+
+// textElementName returns the lowercase name of HTML elements, and an empty
+// name for elements in other namespaces, which are rendered inline.
+func textElementName(element *Element) string {
+	if !isHtmlNamespace(element.namespace) {
+		return ""
+	}
+
+	return strings.ToLower(element.LocalName())
+}
+
+func nextSiblingNamed(element *Element, names ...string) *Element {
+	for sibling := nextElementSibling(element); sibling != nil; sibling = nextElementSibling(sibling) {
+		for _, name := range names {
+			if textElementName(sibling) == name {
+				return sibling
+			}
+		}
+	}
+
+	return nil
+}
+
+// nextTableRow finds the row after the given one in the same table, which
+// may be in the next row group.
+func nextTableRow(row *Element) *Element {
+	if next := nextSiblingNamed(row, "tr"); next != nil {
+		return next
+	}
+
+	group := parentElement(row)
+	if group == nil || textElementName(group) == "table" {
+		return nil
+	}
+
+	for sibling := nextSiblingNamed(group, "thead", "tbody", "tfoot"); sibling != nil; sibling = nextSiblingNamed(sibling, "thead", "tbody", "tfoot") {
+		for child := sibling.firstChild; child != nil; child = child.nextSibling {
+			if child.elementType == TagElement && textElementName(child) == "tr" {
+				return child
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TextContent(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<div>Hello<b>world</b><!-- note --><script>var x;</script> &amp; more</div>")
+	div := document.firstChild
+
+	assert.Equal(div.TextContent(), "Helloworldvar x; & more")
+	assert.Equal(document.TextContent(), "Helloworldvar x; & more")
+	assert.Equal(div.firstChild.TextContent(), "Hello")
+	assert.Equal(div.firstChild.nextSibling.nextSibling.TextContent(), " note ")
+}
+
+func Test_InnerText(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"inline elements", "<div>Hello <b>world</b>!</div>", "Hello world!"},
+		{"blocks", "<div>Hello</div><div>world</div>", "Hello\nworld"},
+		{"words between blocks", "<span>Hello</span><div>world</div>", "Hello\nworld"},
+		{"paragraphs", "<h1>Title</h1><p>One</p><p>Two</p>text", "Title\n\nOne\n\nTwo\n\ntext"},
+		{"collapse whitespace", "<div>\n  Hello \n\t <i> big </i>  world  \n</div>", "Hello big world"},
+		{"line breaks", "<p>a <br> b<br><br>c</p>", "a\nb\n\nc"},
+		{"nested blocks", "<ul>\n  <li>one</li>\n  <li><p>two</p></li>\n</ul>", "one\n\ntwo"},
+		{"table", "<table><tr><td> a </td><td>b</td></tr><tr><th>c</th><td>d</td></tr></table>", "a\tb\nc\td"},
+		{"table sections", "<table><thead><tr><th>h</th></tr></thead><tbody><tr><td>x</td></tr></tbody></table>", "h\nx"},
+		{"preformatted", "<div>a</div><pre>  x\n    y  </pre><div>b  c</div>", "a\n  x\n    y  \nb c"},
+		{"skipped elements", "<div>a<script>s()</script><style>p{}</style><template>t</template><noscript>n</noscript>b</div>", "ab"},
+		{"hidden elements", "<div>a<span hidden>x</span><span style='display: none'>y</span><span style='color:red'>b</span></div>", "ab"},
+		{"head", "<html><head><title>T</title></head><body><p>x</p></body></html>", "x"},
+		{"comments and bogus cdata", "<div>a<!-- c --><![CDATA[b]]></div>", "a"},
+		{"svg text is inline", "<p>a<svg><text>b</text></svg>c</p>", "abc"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			assert.Equal(document.InnerText(), tc.expected)
+		})
+	}
+}
+
+func Test_InnerTextOfElement(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<section><h2>A</h2> <p>b <em>c</em></p></section><script>x()</script>")
+
+	assert.Equal(document.firstChild.InnerText(), "A\n\nb c")
+	assert.Equal(document.firstChild.firstChild.InnerText(), "A")
+
+	// Elements that are not rendered return their text content
+	assert.Equal(document.lastChild.InnerText(), "x()")
+}
@@ -0,0 +1,767 @@
+package parseme
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenizer struct {
+	input      []byte
+	pos        int
+	mode       ParserMode
+	rawTag     string
+	duplicates DuplicatePolicy
+	lineStarts []int
+	errors     *stack[ErrorData]
+}
+
+func newTokenizer(input *[]byte, mode ParserMode, errors *stack[ErrorData]) *tokenizer {
+	lineStarts := []int{0}
+	for i, b := range *input {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	return &tokenizer{input: *input, mode: mode, lineStarts: lineStarts, errors: errors}
+}
+
+func (t *tokenizer) position(offset int) (int, int) {
+	line := sort.Search(len(t.lineStarts), func(i int) bool {
+		return t.lineStarts[i] > offset
+	})
+	column := utf8.RuneCount(t.input[t.lineStarts[line-1]:offset]) + 1
+	return line, column
+}
+
+func (t *tokenizer) fail(offset int, data ErrorData, args ...any) {
+	line, column := t.position(offset)
+	t.errors.Push(data.at(line, column, args...))
+}
+
+func (t *tokenizer) isXml() bool {
+	return t.mode == XmlMode
+}
+
+func (t *tokenizer) next() (*token, bool) {
+	if t.pos >= len(t.input) {
+		return nil, false
+	}
+
+	if t.rawTag != "" {
+		return t.readRawText(), true
+	}
+
+	start := t.pos
+	if t.input[start] != '<' {
+		return t.readText(), true
+	}
+
+	var tok *token
+	switch {
+	case t.hasPrefix("<!--"):
+		tok = t.readComment()
+	case t.hasPrefix("<![CDATA["):
+		if t.isXml() {
+			tok = t.readCDATA()
+		} else {
+			tok = t.readBogusComment(2)
+		}
+	case t.hasPrefixFold("<!DOCTYPE"):
+		tok = t.readDoctype()
+	case t.hasPrefix("<!"):
+		if t.isXml() {
+			t.fail(start, xmlUnescapedLessThanError)
+		}
+		tok = t.readBogusComment(2)
+	case t.hasPrefix("<?"):
+		if t.isXml() {
+			tok = t.readInstruction()
+		} else {
+			tok = t.readBogusComment(1)
+		}
+	case t.hasPrefix("</"):
+		tok = t.readEndTag()
+	case t.pos+1 < len(t.input) && t.isNameStart(t.input[t.pos+1]):
+		tok = t.readStartTag()
+	default:
+		if t.isXml() {
+			t.fail(start, xmlUnescapedLessThanError)
+		}
+		t.pos++
+		tok = &token{tokenType: textToken, value: "<"}
+	}
+
+	if tok == nil {
+		return t.next()
+	}
+
+	tok.start = start
+	tok.end = t.pos
+	return tok, true
+}
+
+func (t *tokenizer) hasPrefix(prefix string) bool {
+	return bytes.HasPrefix(t.input[t.pos:], []byte(prefix))
+}
+
+func (t *tokenizer) hasPrefixFold(prefix string) bool {
+	end := t.pos + len(prefix)
+	if end > len(t.input) {
+		return false
+	}
+
+	return strings.EqualFold(string(t.input[t.pos:end]), prefix)
+}
+
+func (t *tokenizer) isNameStart(b byte) bool {
+	if t.isXml() {
+		return isXmlNameStart(b)
+	}
+
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func (t *tokenizer) normalizeName(name string) string {
+	if t.isXml() {
+		return name
+	}
+
+	return strings.ToLower(name)
+}
+
+func (t *tokenizer) skipSpaces() {
+	for t.pos < len(t.input) && isSpace(t.input[t.pos]) {
+		t.pos++
+	}
+}
+
+func (t *tokenizer) readText() *token {
+	start := t.pos
+	end := bytes.IndexByte(t.input[start:], '<')
+	if end == -1 {
+		t.pos = len(t.input)
+	} else {
+		t.pos = start + end
+	}
+
+	value := t.unescape(start, t.input[start:t.pos], false)
+	return &token{tokenType: textToken, value: value, start: start, end: t.pos}
+}
+
+func (t *tokenizer) readRawText() *token {
+	start := t.pos
+	end := len(t.input)
+
+	for i := start; i < len(t.input); i++ {
+		if t.input[i] != '<' || i+1 >= len(t.input) || t.input[i+1] != '/' {
+			continue
+		}
+
+		nameEnd := i + 2 + len(t.rawTag)
+		if nameEnd > len(t.input) {
+			continue
+		}
+
+		if !strings.EqualFold(string(t.input[i+2:nameEnd]), t.rawTag) {
+			continue
+		}
+
+		if nameEnd == len(t.input) || isSpace(t.input[nameEnd]) || t.input[nameEnd] == '/' || t.input[nameEnd] == '>' {
+			end = i
+			break
+		}
+	}
+
+	raw := t.input[start:end]
+	var value string
+	if escapableRawTextElements[t.rawTag] {
+		value = t.unescape(start, raw, false)
+	} else {
+		value = string(raw)
+	}
+
+	t.pos = end
+	t.rawTag = ""
+	return &token{tokenType: textToken, value: value, start: start, end: end}
+}
+
+func (t *tokenizer) readComment() *token {
+	start := t.pos
+	t.pos += 4
+
+	// HTML allows the abrupt forms <!--> and <!--->
+	if !t.isXml() {
+		if t.hasPrefix(">") {
+			t.pos++
+			return &token{tokenType: commentToken}
+		}
+		if t.hasPrefix("->") {
+			t.pos += 2
+			return &token{tokenType: commentToken}
+		}
+	}
+
+	end := bytes.Index(t.input[t.pos:], []byte("-->"))
+	closing := len("-->")
+
+	// Browsers also end HTML comments at --!>
+	if !t.isXml() {
+		if bang := bytes.Index(t.input[t.pos:], []byte("--!>")); bang != -1 && (end == -1 || bang < end) {
+			end = bang
+			closing = len("--!>")
+		}
+	}
+
+	var value string
+	if end == -1 {
+		if t.isXml() {
+			t.fail(start, xmlUnterminatedError, "a comment")
+		}
+		value = string(t.input[t.pos:])
+		t.pos = len(t.input)
+	} else {
+		value = string(t.input[t.pos : t.pos+end])
+		t.pos += end + closing
+	}
+
+	if t.isXml() && (strings.Contains(value, "--") || strings.HasSuffix(value, "-")) {
+		t.fail(start, xmlInvalidCommentError)
+	}
+
+	return &token{tokenType: commentToken, value: value}
+}
+
+func (t *tokenizer) readBogusComment(skip int) *token {
+	t.pos += skip
+	end := bytes.IndexByte(t.input[t.pos:], '>')
+
+	var value string
+	if end == -1 {
+		value = string(t.input[t.pos:])
+		t.pos = len(t.input)
+	} else {
+		value = string(t.input[t.pos : t.pos+end])
+		t.pos += end + 1
+	}
+
+	return &token{tokenType: commentToken, value: value}
+}
+
+func (t *tokenizer) readCDATA() *token {
+	start := t.pos
+	t.pos += len("<![CDATA[")
+	end := bytes.Index(t.input[t.pos:], []byte("]]>"))
+
+	var value string
+	if end == -1 {
+		t.fail(start, xmlUnterminatedError, "a CDATA section")
+		value = string(t.input[t.pos:])
+		t.pos = len(t.input)
+	} else {
+		value = string(t.input[t.pos : t.pos+end])
+		t.pos += end + 3
+	}
+
+	return &token{tokenType: cdataToken, value: value}
+}
+
+func (t *tokenizer) readDoctype() *token {
+	start := t.pos
+	t.pos += len("<!DOCTYPE")
+	t.skipSpaces()
+
+	nameStart := t.pos
+	for t.pos < len(t.input) && !isSpace(t.input[t.pos]) && t.input[t.pos] != '>' && t.input[t.pos] != '[' {
+		t.pos++
+	}
+	name := t.normalizeName(string(t.input[nameStart:t.pos]))
+	t.skipSpaces()
+
+	valueStart := t.pos
+	var quote byte
+	depth := 0
+	for ; t.pos < len(t.input); t.pos++ {
+		current := t.input[t.pos]
+
+		if quote != 0 {
+			if current == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		if current == '"' || current == '\'' {
+			quote = current
+		} else if current == '[' && t.isXml() {
+			depth++
+		} else if current == ']' && depth > 0 {
+			depth--
+		} else if current == '>' && depth == 0 {
+			break
+		}
+	}
+
+	value := strings.TrimRight(string(t.input[valueStart:t.pos]), " \t\r\n\f")
+	if t.pos >= len(t.input) {
+		if t.isXml() {
+			t.fail(start, xmlUnterminatedError, "a doctype")
+		}
+	} else {
+		t.pos++
+	}
+
+	return &token{tokenType: doctypeToken, name: name, value: value}
+}
+
+func (t *tokenizer) readInstruction() *token {
+	start := t.pos
+	t.pos += 2
+
+	nameStart := t.pos
+	for t.pos < len(t.input) && !isSpace(t.input[t.pos]) && !t.hasPrefix("?>") {
+		t.pos++
+	}
+	name := string(t.input[nameStart:t.pos])
+
+	if !isValidXmlName(name) {
+		t.fail(start, xmlInvalidNameError, name)
+	}
+
+	t.skipSpaces()
+	end := bytes.Index(t.input[t.pos:], []byte("?>"))
+
+	var value string
+	if end == -1 {
+		t.fail(start, xmlUnterminatedError, "a processing instruction")
+		value = string(t.input[t.pos:])
+		t.pos = len(t.input)
+	} else {
+		value = string(t.input[t.pos : t.pos+end])
+		t.pos += end + 2
+	}
+
+	return &token{tokenType: instructionToken, name: name, value: value}
+}
+
+func (t *tokenizer) readEndTag() *token {
+	start := t.pos
+	t.pos += 2
+
+	if t.pos >= len(t.input) {
+		if t.isXml() {
+			t.fail(start, xmlUnterminatedError, "an end tag")
+		}
+		return &token{tokenType: textToken, value: "</"}
+	}
+
+	if !t.isNameStart(t.input[t.pos]) {
+		if t.isXml() {
+			t.fail(start, xmlUnescapedLessThanError)
+			return &token{tokenType: textToken, value: "</"}
+		}
+
+		// </> is dropped entirely, anything else becomes a bogus comment
+		if t.input[t.pos] == '>' {
+			t.pos++
+			return nil
+		}
+		return t.readBogusComment(0)
+	}
+
+	nameStart := t.pos
+	for t.pos < len(t.input) && !isSpace(t.input[t.pos]) && t.input[t.pos] != '/' && t.input[t.pos] != '>' {
+		t.pos++
+	}
+	name := t.normalizeName(string(t.input[nameStart:t.pos]))
+
+	t.skipSpaces()
+	end := bytes.IndexByte(t.input[t.pos:], '>')
+	if end == -1 {
+		if t.isXml() {
+			t.fail(start, xmlUnterminatedError, "an end tag")
+		}
+		t.pos = len(t.input)
+		return nil
+	}
+
+	if t.isXml() && end != 0 {
+		t.fail(start, xmlInvalidNameError, strings.TrimSpace(string(t.input[nameStart:t.pos+end])))
+	}
+
+	t.pos += end + 1
+	return &token{tokenType: endTagToken, name: name}
+}
+
+func (t *tokenizer) readStartTag() *token {
+	start := t.pos
+	t.pos++
+
+	nameStart := t.pos
+	for t.pos < len(t.input) && !isSpace(t.input[t.pos]) && t.input[t.pos] != '/' && t.input[t.pos] != '>' {
+		t.pos++
+	}
+	name := t.normalizeName(string(t.input[nameStart:t.pos]))
+
+	if t.isXml() && !isValidXmlName(name) {
+		t.fail(nameStart, xmlInvalidNameError, name)
+	}
+
+	tok := &token{tokenType: startTagToken, name: name, nameEnd: t.pos}
+	seen := map[string]bool{}
+
+	for {
+		t.skipSpaces()
+
+		if t.pos >= len(t.input) {
+			if t.isXml() {
+				t.fail(start, xmlUnterminatedError, "a start tag")
+				break
+			}
+			return nil
+		}
+
+		current := t.input[t.pos]
+		if current == '>' {
+			t.pos++
+			break
+		}
+
+		if current == '/' {
+			t.pos++
+			if t.pos < len(t.input) && t.input[t.pos] == '>' {
+				tok.selfClosing = true
+				t.pos++
+				break
+			}
+			continue
+		}
+
+		attribute, ok := t.readAttribute()
+		if !ok {
+			continue
+		}
+
+		if seen[attribute.name] {
+			if t.isXml() {
+				t.fail(attribute.offset, xmlDuplicateAttributeError, attribute.name)
+				continue
+			}
+
+			t.fail(attribute.offset, htmlDuplicateAttributeError, attribute.name)
+			if t.duplicates != KeepLastAttribute {
+				continue
+			}
+
+			// The earlier occurrence is dropped so that attributes stay in
+			// source order
+			for i, previous := range tok.attributes {
+				if previous.name == attribute.name {
+					tok.dropped = append(tok.dropped, previous)
+					tok.attributes = append(tok.attributes[:i], tok.attributes[i+1:]...)
+					break
+				}
+			}
+		}
+
+		seen[attribute.name] = true
+		tok.attributes = append(tok.attributes, attribute)
+	}
+
+	if !t.isXml() && isRawTextElement(name) {
+		t.rawTag = name
+	}
+
+	return tok
+}
+
+func (t *tokenizer) readAttribute() (tokenAttribute, bool) {
+	start := t.pos
+
+	// An equals sign is only allowed as the first character of a name
+	if t.input[t.pos] == '=' {
+		t.pos++
+	}
+
+	for t.pos < len(t.input) {
+		current := t.input[t.pos]
+		if isSpace(current) || current == '/' || current == '>' || current == '=' {
+			break
+		}
+		t.pos++
+	}
+
+	name := t.normalizeName(string(t.input[start:t.pos]))
+	attribute := tokenAttribute{name: name, offset: start, nameEnd: t.pos, end: t.pos}
+
+	if t.isXml() && !isValidXmlName(name) {
+		t.fail(start, xmlInvalidNameError, name)
+		if name == "" {
+			t.pos++
+			return attribute, false
+		}
+	}
+
+	t.skipSpaces()
+	if t.pos >= len(t.input) || t.input[t.pos] != '=' {
+		if t.isXml() {
+			t.fail(start, xmlMissingAttributeValueError, name)
+		}
+		return attribute, true
+	}
+
+	t.pos++
+	t.skipSpaces()
+	attribute.hasValue = true
+
+	if t.pos >= len(t.input) {
+		attribute.end = t.pos
+		return attribute, true
+	}
+
+	quote := t.input[t.pos]
+	var raw []byte
+	valueStart := t.pos
+
+	if quote == '"' || quote == '\'' {
+		attribute.quote = quote
+		t.pos++
+		valueStart = t.pos
+		end := bytes.IndexByte(t.input[t.pos:], quote)
+		if end == -1 {
+			raw = t.input[t.pos:]
+			t.pos = len(t.input)
+		} else {
+			raw = t.input[t.pos : t.pos+end]
+			t.pos += end + 1
+		}
+	} else {
+		if t.isXml() {
+			t.fail(start, xmlUnquotedAttributeError, name)
+		}
+		for t.pos < len(t.input) && !isSpace(t.input[t.pos]) && t.input[t.pos] != '>' {
+			t.pos++
+		}
+		raw = t.input[valueStart:t.pos]
+	}
+
+	if t.isXml() && bytes.IndexByte(raw, '<') != -1 {
+		t.fail(start, xmlInvalidAttributeValueError, name)
+	}
+
+	attribute.value = t.unescape(valueStart, raw, true)
+	attribute.end = t.pos
+	return attribute, true
+}
+
+// unescape decodes character references. HTML follows the named reference
+// table of the standard, while XML only knows the five predefined entities
+// and reports anything else.
+func (t *tokenizer) unescape(offset int, raw []byte, inAttribute bool) string {
+	if bytes.IndexByte(raw, '&') == -1 {
+		return string(raw)
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '&' {
+			builder.WriteByte(raw[i])
+			continue
+		}
+
+		var decoded string
+		var consumed int
+		if t.isXml() {
+			decoded, consumed = t.decodeXmlReference(offset+i, raw[i:])
+		} else {
+			decoded, consumed = decodeHtmlReference(raw[i:], inAttribute)
+		}
+
+		if consumed == 0 {
+			builder.WriteByte('&')
+			continue
+		}
+
+		builder.WriteString(decoded)
+		i += consumed - 1
+	}
+
+	return builder.String()
+}
+
+func (t *tokenizer) decodeXmlReference(offset int, raw []byte) (string, int) {
+	end := bytes.IndexByte(raw, ';')
+	if end == -1 || end == 1 {
+		t.fail(offset, xmlInvalidReferenceError, "&")
+		return "", 0
+	}
+
+	name := string(raw[1:end])
+	if name[0] == '#' {
+		r, ok := parseCodePoint(name[1:])
+		if !ok || !isXmlChar(r) {
+			t.fail(offset, xmlInvalidReferenceError, "&"+name+";")
+			return "", 0
+		}
+		return string(r), end + 1
+	}
+
+	switch name {
+	case "amp":
+		return "&", end + 1
+	case "lt":
+		return "<", end + 1
+	case "gt":
+		return ">", end + 1
+	case "quot":
+		return "\"", end + 1
+	case "apos":
+		return "'", end + 1
+	}
+
+	if !isValidXmlName(name) {
+		t.fail(offset, xmlInvalidReferenceError, "&"+name+";")
+	} else {
+		t.fail(offset, xmlUndefinedEntityError, name)
+	}
+	return "", 0
+}
+
+func decodeHtmlReference(raw []byte, inAttribute bool) (string, int) {
+	if len(raw) < 2 {
+		return "", 0
+	}
+
+	if raw[1] == '#' {
+		i := 2
+		hex := i < len(raw) && (raw[i] == 'x' || raw[i] == 'X')
+		if hex {
+			i++
+		}
+
+		digitsStart := i
+		for i < len(raw) && isDigitFor(raw[i], hex) {
+			i++
+		}
+
+		if i == digitsStart {
+			return "", 0
+		}
+
+		digits := string(raw[digitsStart:i])
+		if hex {
+			digits = "x" + digits
+		}
+
+		r, _ := parseCodePoint(digits)
+		if replacement, ok := windows1252[r]; ok {
+			r = replacement
+		}
+		if i < len(raw) && raw[i] == ';' {
+			i++
+		}
+
+		return string(r), i
+	}
+
+	i := 1
+	for i < len(raw) && isAlphaNumeric(raw[i]) {
+		i++
+	}
+
+	name := string(raw[1:i])
+	if i < len(raw) && raw[i] == ';' {
+		if value, ok := htmlEntities[name]; ok {
+			return value, i + 1
+		}
+	}
+
+	// Legacy references may omit the semicolon and end at the longest match
+	for j := len(name); j > 1; j-- {
+		prefix := name[:j]
+		if !legacyEntities[prefix] {
+			continue
+		}
+
+		next := j + 1
+		if inAttribute && next < len(raw) && (raw[next] == '=' || isAlphaNumeric(raw[next])) {
+			return "", 0
+		}
+
+		return htmlEntities[prefix], next
+	}
+
+	return "", 0
+}
+
+// Code points 0x80 to 0x9F are remapped to their windows-1252 meaning, as
+// required for numeric references in HTML.
+var windows1252 = map[rune]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„',
+	0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+	0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ',
+	0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+	0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+	0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func parseCodePoint(digits string) (rune, bool) {
+	var value int64
+	var err error
+
+	if len(digits) > 0 && (digits[0] == 'x' || digits[0] == 'X') {
+		value, err = strconv.ParseInt(digits[1:], 16, 32)
+	} else {
+		value, err = strconv.ParseInt(digits, 10, 32)
+	}
+
+	if err != nil || value == 0 || value > utf8.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+		return utf8.RuneError, false
+	}
+
+	return rune(value), true
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
+
+func isAlphaNumeric(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+func isDigitFor(b byte, hex bool) bool {
+	if b >= '0' && b <= '9' {
+		return true
+	}
+
+	return hex && ((b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F'))
+}
+
+func isXmlNameStart(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_' || b == ':' || b >= 0x80
+}
+
+func isValidXmlName(name string) bool {
+	if len(name) == 0 || !isXmlNameStart(name[0]) {
+		return false
+	}
+
+	for i := 1; i < len(name); i++ {
+		b := name[i]
+		if !isXmlNameStart(b) && !(b >= '0' && b <= '9') && b != '-' && b != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isXmlChar(r rune) bool {
+	return r == 0x9 || r == 0xA || r == 0xD || (r >= 0x20 && r <= 0xD7FF) ||
+		(r >= 0xE000 && r <= 0xFFFD) || (r >= 0x10000 && r <= 0x10FFFF)
+}
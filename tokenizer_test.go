@@ -0,0 +1,191 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_decodeHtmlReference(t *testing.T) {
+	testcases := []struct {
+		name             string
+		value            string
+		inAttribute      bool
+		expected         string
+		expectedConsumed int
+	}{
+		{"named reference", "&amp;rest", false, "&", 5},
+		{"named reference without semicolon", "&copy 2024", false, "©", 5},
+		{"longest legacy match", "&notit;", false, "¬", 4},
+		{"legacy match followed by alnum in attribute", "&copyx", true, "", 0},
+		{"legacy match followed by equals in attribute", "&copy=", true, "", 0},
+		{"unknown reference", "&unknown;", false, "", 0},
+		{"decimal reference", "&#65;", false, "A", 5},
+		{"hexadecimal reference", "&#x263a;", false, "☺", 8},
+		{"numeric reference without semicolon", "&#65 ", false, "A", 4},
+		{"windows-1252 remap", "&#150;", false, "–", 6},
+		{"null reference", "&#0;", false, "�", 4},
+		{"out of range reference", "&#x110000;", false, "�", 10},
+		{"no digits", "&#;", false, "", 0},
+		{"lone ampersand", "&", false, "", 0},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			result, consumed := decodeHtmlReference([]byte(tc.value), tc.inAttribute)
+			assert.Equal(result, tc.expected)
+			assert.Equal(consumed, tc.expectedConsumed)
+		})
+	}
+}
+
+func Test_next(t *testing.T) {
+	testcases := []struct {
+		name     string
+		mode     ParserMode
+		value    string
+		expected []token
+	}{
+		{
+			"tags and text",
+			HtmlMode,
+			"<P Class=a>x</P>",
+			[]token{
+				{tokenType: startTagToken, name: "p", attributes: []tokenAttribute{{name: "class", value: "a", hasValue: true, offset: 3, nameEnd: 8, end: 10}}, start: 0, nameEnd: 2, end: 11},
+				{tokenType: textToken, value: "x", start: 11, end: 12},
+				{tokenType: endTagToken, name: "p", start: 12, end: 16},
+			},
+		},
+		{
+			"self closing tag",
+			XmlMode,
+			"<br/>",
+			[]token{
+				{tokenType: startTagToken, name: "br", selfClosing: true, start: 0, nameEnd: 3, end: 5},
+			},
+		},
+		{
+			"raw text",
+			HtmlMode,
+			"<style>a<b</style>",
+			[]token{
+				{tokenType: startTagToken, name: "style", start: 0, nameEnd: 6, end: 7},
+				{tokenType: textToken, value: "a<b", start: 7, end: 10},
+				{tokenType: endTagToken, name: "style", start: 10, end: 18},
+			},
+		},
+		{
+			"stray less than",
+			HtmlMode,
+			"a < b",
+			[]token{
+				{tokenType: textToken, value: "a ", start: 0, end: 2},
+				{tokenType: textToken, value: "<", start: 2, end: 3},
+				{tokenType: textToken, value: " b", start: 3, end: 5},
+			},
+		},
+		{
+			"empty end tag is dropped",
+			HtmlMode,
+			"</>a",
+			[]token{
+				{tokenType: textToken, value: "a", start: 3, end: 4},
+			},
+		},
+		{
+			"doctype with identifiers",
+			HtmlMode,
+			"<!doctype HTML PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\">",
+			[]token{
+				{tokenType: doctypeToken, name: "html", value: "PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\"", start: 0, end: 57},
+			},
+		},
+		{
+			"doctype with internal subset",
+			XmlMode,
+			"<!DOCTYPE a [<!ENTITY b 'c'>]>",
+			[]token{
+				{tokenType: doctypeToken, name: "a", value: "[<!ENTITY b 'c'>]", start: 0, end: 30},
+			},
+		},
+		{
+			"processing instruction",
+			XmlMode,
+			"<?target some data?>",
+			[]token{
+				{tokenType: instructionToken, name: "target", value: "some data", start: 0, end: 20},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			bytes := []byte(tc.value)
+			tokenizer := newTokenizer(&bytes, tc.mode, &stack[ErrorData]{})
+
+			result := []token{}
+			for {
+				tok, ok := tokenizer.next()
+				if !ok {
+					break
+				}
+				result = append(result, *tok)
+			}
+
+			assert.Equal(result, tc.expected)
+		})
+	}
+}
+
+func Test_position(t *testing.T) {
+	testcases := []struct {
+		name           string
+		offset         int
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"first character", 0, 1, 1},
+		{"end of first line", 3, 1, 4},
+		{"start of second line", 4, 2, 1},
+		{"after multibyte character", 11, 3, 2},
+	}
+
+	bytes := []byte("abc\ndef\n♥x")
+	tokenizer := newTokenizer(&bytes, HtmlMode, &stack[ErrorData]{})
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			line, column := tokenizer.position(tc.offset)
+			assert.Equal(line, tc.expectedLine)
+			assert.Equal(column, tc.expectedColumn)
+		})
+	}
+}
+
+func Test_isValidXmlName(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{"simple name", "root", true},
+		{"prefixed name", "svg:rect", true},
+		{"underscore start", "_a", true},
+		{"digits and punctuation after start", "a1-b.c", true},
+		{"digit start", "1a", false},
+		{"hyphen start", "-a", false},
+		{"empty name", "", false},
+		{"contains space", "a b", false},
+		{"non ascii name", "café", true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(isValidXmlName(tc.value), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package parseme
+
+import (
+	"iter"
+	"strings"
+)
+
+// TokenList edits a property whose value is a set of space-separated
+// tokens, such as class, rel or aria-labelledby. Every change is written
+// back to the property through SetValue.
+type TokenList struct {
+	property *Property
+}
+
+func (p *Property) TokenList() *TokenList {
+	return &TokenList{property: p}
+}
+
+// Values returns the tokens in order with duplicates removed.
+func (l *TokenList) Values() []string {
+	tokens := []string{}
+	seen := map[string]bool{}
+
+	for _, token := range strings.FieldsFunc(l.property.value, func(r rune) bool { return r < 0x80 && isSpace(byte(r)) }) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+func (l *TokenList) All() iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		for i, token := range l.Values() {
+			if !yield(i, token) {
+				return
+			}
+		}
+	}
+}
+
+func (l *TokenList) Length() int {
+	return len(l.Values())
+}
+
+// Item returns the token at index, or an empty string when the index is out
+// of range.
+func (l *TokenList) Item(index int) string {
+	tokens := l.Values()
+	if index < 0 || index >= len(tokens) {
+		return ""
+	}
+
+	return tokens[index]
+}
+
+func (l *TokenList) Contains(token string) bool {
+	for _, value := range l.Values() {
+		if value == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *TokenList) Add(tokens ...string) error {
+	if err := validateTokens(tokens...); err != nil {
+		return err
+	}
+
+	values := l.Values()
+	for _, token := range tokens {
+		if !containsToken(values, token) {
+			values = append(values, token)
+		}
+	}
+
+	return l.write(values)
+}
+
+func (l *TokenList) Remove(tokens ...string) error {
+	if err := validateTokens(tokens...); err != nil {
+		return err
+	}
+
+	values := []string{}
+	for _, value := range l.Values() {
+		if !containsToken(tokens, value) {
+			values = append(values, value)
+		}
+	}
+
+	return l.write(values)
+}
+
+// Toggle removes the token when it is present and adds it otherwise. It
+// returns whether the token is present afterwards.
+func (l *TokenList) Toggle(token string) (bool, error) {
+	if err := validateTokens(token); err != nil {
+		return false, err
+	}
+
+	if l.Contains(token) {
+		return false, l.Remove(token)
+	}
+
+	return true, l.Add(token)
+}
+
+// Replace puts newToken in the place of oldToken and reports whether
+// oldToken was present.
+func (l *TokenList) Replace(oldToken string, newToken string) (bool, error) {
+	if err := validateTokens(oldToken, newToken); err != nil {
+		return false, err
+	}
+
+	if !l.Contains(oldToken) {
+		return false, nil
+	}
+
+	values := []string{}
+	for _, value := range l.Values() {
+		switch value {
+		case oldToken:
+			if !containsToken(values, newToken) {
+				values = append(values, newToken)
+			}
+		case newToken:
+			if !containsToken(values, newToken) {
+				values = append(values, value)
+			}
+		default:
+			values = append(values, value)
+		}
+	}
+
+	return true, l.write(values)
+}
+
+func (l *TokenList) String() string {
+	return strings.Join(l.Values(), " ")
+}
+
+func (l *TokenList) write(tokens []string) error {
+	return l.property.SetValue(strings.Join(tokens, " "))
+}
+
+func validateTokens(tokens ...string) error {
+	for _, token := range tokens {
+		if token == "" {
+			return &PropertyEmptyTokenError{}
+		}
+
+		if strings.ContainsAny(token, " \t\n\r\f") {
+			return &PropertyInvalidTokenError{token: token}
+		}
+	}
+
+	return nil
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, value := range tokens {
+		if value == token {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenList(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		action   func(list *TokenList) error
+		expected string
+	}{
+		{
+			"add new tokens",
+			"a b",
+			func(list *TokenList) error { return list.Add("c", "a", "d") },
+			"a b c d",
+		},
+		{
+			"add removes duplicates and extra spaces",
+			" a  b\ta ",
+			func(list *TokenList) error { return list.Add("b") },
+			"a b",
+		},
+		{
+			"remove tokens",
+			"a b c b",
+			func(list *TokenList) error { return list.Remove("b", "x") },
+			"a c",
+		},
+		{
+			"toggle present token",
+			"a b",
+			func(list *TokenList) error {
+				present, err := list.Toggle("a")
+				assert.False(t, present)
+				return err
+			},
+			"b",
+		},
+		{
+			"toggle missing token",
+			"a",
+			func(list *TokenList) error {
+				present, err := list.Toggle("b")
+				assert.True(t, present)
+				return err
+			},
+			"a b",
+		},
+		{
+			"replace token",
+			"a b c",
+			func(list *TokenList) error {
+				replaced, err := list.Replace("b", "x")
+				assert.True(t, replaced)
+				return err
+			},
+			"a x c",
+		},
+		{
+			"replace with present token",
+			"a b c",
+			func(list *TokenList) error {
+				_, err := list.Replace("c", "a")
+				return err
+			},
+			"a b",
+		},
+		{
+			"replace missing token",
+			"a b",
+			func(list *TokenList) error {
+				replaced, err := list.Replace("x", "y")
+				assert.False(t, replaced)
+				return err
+			},
+			"a b",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			property := NewProperty(Value, "class", tc.value)
+			assert.Nil(t, tc.action(property.TokenList()))
+			assert.Equal(t, property.Value(), tc.expected)
+		})
+	}
+}
+
+func Test_TokenListQueries(t *testing.T) {
+	assert := assert.New(t)
+	list := NewProperty(Value, "rel", "noopener  noreferrer noopener").TokenList()
+
+	assert.Equal(list.Values(), []string{"noopener", "noreferrer"})
+	assert.Equal(list.Length(), 2)
+	assert.Equal(list.Item(1), "noreferrer")
+	assert.Equal(list.Item(2), "")
+	assert.True(list.Contains("noreferrer"))
+	assert.False(list.Contains("nofollow"))
+	assert.Equal(list.String(), "noopener noreferrer")
+
+	tokens := []string{}
+	for i, token := range list.All() {
+		assert.Equal(list.Item(i), token)
+		tokens = append(tokens, token)
+	}
+	assert.Equal(tokens, []string{"noopener", "noreferrer"})
+}
+
+func Test_TokenListErrors(t *testing.T) {
+	property := NewProperty(Value, "class", "a")
+	list := property.TokenList()
+
+	assert.IsType(t, list.Add(""), &PropertyEmptyTokenError{})
+	assert.IsType(t, list.Remove("a b"), &PropertyInvalidTokenError{})
+	_, err := list.Toggle("\t")
+	assert.IsType(t, err, &PropertyInvalidTokenError{})
+	_, err = list.Replace("a", "")
+	assert.IsType(t, err, &PropertyEmptyTokenError{})
+	assert.Equal(t, property.Value(), "a")
+}
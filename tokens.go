@@ -17,3 +17,39 @@ type preToken struct {
 	tokenType preTokenType
 	value     string
 }
+
+type tokenType int
+
+const (
+	textToken tokenType = iota
+	startTagToken
+	endTagToken
+	commentToken
+	doctypeToken
+	cdataToken
+	instructionToken
+)
+
+type tokenAttribute struct {
+	name     string
+	value    string
+	hasValue bool
+	quote    byte
+	offset   int
+	nameEnd  int
+	end      int
+}
+
+type token struct {
+	tokenType  tokenType
+	name       string
+	value      string
+	attributes []tokenAttribute
+	// dropped lists the earlier occurrences of repeated attributes removed
+	// by KeepLastAttribute
+	dropped     []tokenAttribute
+	selfClosing bool
+	start       int
+	nameEnd     int
+	end         int
+}
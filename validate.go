@@ -0,0 +1,416 @@
+package parseme
+
+import (
+	"slices"
+	"strings"
+)
+
+// Validate checks the element and its descendants against the HTML content
+// models, required attributes, enumerated attribute values and obsolete
+// elements. Each finding is returned and reported through the error pool
+// of the logger. SVG and MathML content is not checked.
+func (e *Element) Validate() []ErrorData {
+	validator := &htmlValidator{}
+
+	e.Walk(func(node *Element) WalkAction {
+		if node.elementType != TagElement {
+			return WalkContinue
+		}
+
+		if !isHtmlNamespace(node.namespace) {
+			return WalkSkipChildren
+		}
+
+		name := strings.ToLower(node.name)
+		if name == "svg" || name == "math" {
+			validator.checkPlacement(node, name)
+			return WalkSkipChildren
+		}
+
+		validator.check(node, name)
+		return WalkContinue
+	})
+
+	for _, data := range validator.findings {
+		reportError("Html Validator", data)
+	}
+
+	return validator.findings
+}
+
+type htmlValidator struct {
+	findings []ErrorData
+}
+
+func (v *htmlValidator) report(node *Element, data ErrorData, args ...any) {
+	v.findings = append(v.findings, data.at(node.line, node.column, args...))
+}
+
+func (v *htmlValidator) check(node *Element, name string) {
+	if obsoleteElements[name] {
+		v.report(node, validatorObsoleteElementError, node.name)
+	}
+
+	v.checkPlacement(node, name)
+	v.checkAttributes(node, name)
+}
+
+// checkPlacement checks the element against the content model of its
+// parent and of its interactive ancestors.
+func (v *htmlValidator) checkPlacement(node *Element, name string) {
+	parent := node.parent
+	parentName := ""
+	if parent != nil && parent.elementType == TagElement {
+		parentName = strings.ToLower(parent.name)
+	}
+
+	if parents, ok := requiredParents[name]; ok && parentName != "template" && !slices.Contains(parents, parentName) {
+		inside := parentName
+		if inside == "" {
+			inside = "#document"
+		}
+		v.report(node, validatorMisplacedElementError, node.name, quoteNames(parents), inside)
+	}
+
+	if flowElements[name] {
+		if ancestor := phrasingContext(parent); ancestor != nil {
+			v.report(node, validatorFlowInPhrasingError, node.name, ancestor.name)
+		}
+	}
+
+	if isInteractive(node, name) {
+		for ancestor := range node.Ancestors() {
+			if ancestorName := strings.ToLower(ancestor.name); ancestor.elementType == TagElement && (ancestorName == "a" || ancestorName == "button") {
+				v.report(node, validatorNestedInteractiveError, node.name, ancestor.name)
+				break
+			}
+		}
+	}
+}
+
+func (v *htmlValidator) checkAttributes(node *Element, name string) {
+	for _, alternatives := range requiredAttributes[name] {
+		found := false
+		for _, attribute := range alternatives {
+			found = found || node.Property(attribute) != nil
+		}
+
+		// An area without href is a placeholder and needs no alternative text
+		if name == "area" && node.Property("href") == nil {
+			found = true
+		}
+
+		if !found {
+			v.report(node, validatorMissingAttributeError, node.name, quoteNames(alternatives))
+		}
+	}
+
+	for _, property := range node.properties {
+		if property.IsBoolean() {
+			continue
+		}
+
+		attribute := strings.ToLower(property.name)
+		allowed, ok := enumeratedAttributes[name][attribute]
+		if !ok {
+			allowed, ok = enumeratedAttributes["*"][attribute]
+		}
+
+		if ok {
+			if _, err := property.Enum(allowed...); err != nil {
+				v.report(node, validatorInvalidValueError, property.value, property.name, node.name, quoteNames(allowed))
+			}
+		}
+	}
+}
+
+// phrasingContext returns the nearest ancestor whose content model only
+// accepts phrasing content, looking through transparent elements.
+func phrasingContext(parent *Element) *Element {
+	for node := parent; node != nil && node.elementType == TagElement; node = node.parent {
+		name := strings.ToLower(node.name)
+		if phrasingParents[name] {
+			return node
+		}
+
+		if !transparentElements[name] {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func isInteractive(node *Element, name string) bool {
+	switch name {
+	case "a", "button", "details", "embed", "iframe", "label", "select", "textarea":
+		return true
+	case "audio", "video":
+		return node.Property("controls") != nil
+	case "img", "object":
+		return node.Property("usemap") != nil
+	case "input":
+		inputType := node.Property("type")
+		return inputType == nil || !strings.EqualFold(inputType.value, "hidden")
+	}
+
+	return node.Property("tabindex") != nil
+}
+
+// quoteNames lists names as 'a', 'b' or 'c'.
+func quoteNames(names []string) string {
+	quoted := []string{}
+	for _, name := range names {
+		quoted = append(quoted, "'"+name+"'")
+	}
+
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+var obsoleteElements = map[string]bool{
+	"acronym":   true,
+	"applet":    true,
+	"basefont":  true,
+	"bgsound":   true,
+	"big":       true,
+	"blink":     true,
+	"center":    true,
+	"dir":       true,
+	"font":      true,
+	"frame":     true,
+	"frameset":  true,
+	"isindex":   true,
+	"keygen":    true,
+	"listing":   true,
+	"marquee":   true,
+	"menuitem":  true,
+	"multicol":  true,
+	"nextid":    true,
+	"nobr":      true,
+	"noembed":   true,
+	"noframes":  true,
+	"plaintext": true,
+	"rb":        true,
+	"rtc":       true,
+	"spacer":    true,
+	"strike":    true,
+	"tt":        true,
+	"xmp":       true,
+}
+
+// Elements whose content model is phrasing content.
+var phrasingParents = map[string]bool{
+	"abbr":     true,
+	"b":        true,
+	"bdi":      true,
+	"bdo":      true,
+	"button":   true,
+	"cite":     true,
+	"code":     true,
+	"data":     true,
+	"dfn":      true,
+	"em":       true,
+	"h1":       true,
+	"h2":       true,
+	"h3":       true,
+	"h4":       true,
+	"h5":       true,
+	"h6":       true,
+	"i":        true,
+	"kbd":      true,
+	"label":    true,
+	"mark":     true,
+	"meter":    true,
+	"output":   true,
+	"p":        true,
+	"pre":      true,
+	"progress": true,
+	"q":        true,
+	"s":        true,
+	"samp":     true,
+	"small":    true,
+	"span":     true,
+	"strong":   true,
+	"sub":      true,
+	"sup":      true,
+	"time":     true,
+	"u":        true,
+	"var":      true,
+}
+
+// Elements whose content model is the one of their parent.
+var transparentElements = map[string]bool{
+	"a":        true,
+	"audio":    true,
+	"canvas":   true,
+	"del":      true,
+	"ins":      true,
+	"map":      true,
+	"noscript": true,
+	"object":   true,
+	"slot":     true,
+	"video":    true,
+}
+
+// Flow content elements that are not phrasing content.
+var flowElements = map[string]bool{
+	"address":    true,
+	"article":    true,
+	"aside":      true,
+	"blockquote": true,
+	"details":    true,
+	"dialog":     true,
+	"div":        true,
+	"dl":         true,
+	"fieldset":   true,
+	"figure":     true,
+	"footer":     true,
+	"form":       true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"header":     true,
+	"hgroup":     true,
+	"hr":         true,
+	"main":       true,
+	"menu":       true,
+	"nav":        true,
+	"ol":         true,
+	"p":          true,
+	"pre":        true,
+	"search":     true,
+	"section":    true,
+	"table":      true,
+	"ul":         true,
+}
+
+// Elements that may only appear as children of the listed elements. Rows
+// and columns may be direct children of tables since the HTML parser
+// implies their sections.
+var requiredParents = map[string][]string{
+	"caption":    {"table"},
+	"col":        {"colgroup", "table"},
+	"colgroup":   {"table"},
+	"dd":         {"dl", "div"},
+	"dt":         {"dl", "div"},
+	"figcaption": {"figure"},
+	"legend":     {"fieldset"},
+	"li":         {"menu", "ol", "ul"},
+	"optgroup":   {"select"},
+	"option":     {"datalist", "optgroup", "select"},
+	"param":      {"object"},
+	"rp":         {"ruby"},
+	"rt":         {"ruby"},
+	"source":     {"audio", "picture", "video"},
+	"summary":    {"details"},
+	"tbody":      {"table"},
+	"td":         {"tr"},
+	"tfoot":      {"table"},
+	"th":         {"tr"},
+	"thead":      {"table"},
+	"tr":         {"table", "tbody", "tfoot", "thead"},
+	"track":      {"audio", "video"},
+}
+
+// Properties each element requires. Every entry lists alternatives, one of
+// which must be present.
+var requiredAttributes = map[string][][]string{
+	"area":     {{"alt"}},
+	"base":     {{"href", "target"}},
+	"bdo":      {{"dir"}},
+	"img":      {{"src"}, {"alt"}},
+	"link":     {{"href"}, {"rel", "itemprop"}},
+	"map":      {{"name"}},
+	"meta":     {{"name", "http-equiv", "charset", "itemprop", "property"}},
+	"object":   {{"data"}},
+	"optgroup": {{"label"}},
+	"source":   {{"src", "srcset"}},
+	"track":    {{"src"}},
+}
+
+var (
+	referrerPolicies = []string{
+		"", "no-referrer", "no-referrer-when-downgrade", "same-origin", "origin", "strict-origin",
+		"origin-when-cross-origin", "strict-origin-when-cross-origin", "unsafe-url",
+	}
+	corsSettings  = []string{"", "anonymous", "use-credentials"}
+	formMethods   = []string{"get", "post", "dialog"}
+	formEncodings = []string{"application/x-www-form-urlencoded", "multipart/form-data", "text/plain"}
+	loadingModes  = []string{"lazy", "eager"}
+	priorities    = []string{"high", "low", "auto"}
+	preloadHints  = []string{"", "none", "metadata", "auto"}
+)
+
+// Keywords allowed in enumerated properties, by element. Properties listed
+// under "*" are global.
+var enumeratedAttributes = map[string]map[string][]string{
+	"*": {
+		"autocapitalize":  {"off", "none", "on", "sentences", "words", "characters"},
+		"contenteditable": {"", "true", "false", "plaintext-only"},
+		"dir":             {"ltr", "rtl", "auto"},
+		"draggable":       {"true", "false"},
+		"enterkeyhint":    {"enter", "done", "go", "next", "previous", "search", "send"},
+		"hidden":          {"", "hidden", "until-found"},
+		"inputmode":       {"none", "text", "decimal", "numeric", "tel", "search", "email", "url"},
+		"popover":         {"", "auto", "manual", "hint"},
+		"spellcheck":      {"", "true", "false"},
+		"translate":       {"", "yes", "no"},
+	},
+	"a":    {"referrerpolicy": referrerPolicies},
+	"area": {"referrerpolicy": referrerPolicies, "shape": {"default", "rect", "circle", "poly"}},
+	"audio": {
+		"crossorigin": corsSettings,
+		"preload":     preloadHints,
+	},
+	"button": {
+		"formenctype": formEncodings,
+		"formmethod":  formMethods,
+		"type":        {"submit", "reset", "button"},
+	},
+	"form": {
+		"autocomplete": {"on", "off"},
+		"enctype":      formEncodings,
+		"method":       formMethods,
+	},
+	"iframe": {"loading": loadingModes, "referrerpolicy": referrerPolicies},
+	"img": {
+		"crossorigin":    corsSettings,
+		"decoding":       {"sync", "async", "auto"},
+		"fetchpriority":  priorities,
+		"loading":        loadingModes,
+		"referrerpolicy": referrerPolicies,
+	},
+	"input": {
+		"formenctype": formEncodings,
+		"formmethod":  formMethods,
+		"type": {
+			"hidden", "text", "search", "tel", "url", "email", "password", "date", "month", "week",
+			"time", "datetime-local", "number", "range", "color", "checkbox", "radio", "file",
+			"submit", "image", "reset", "button",
+		},
+	},
+	"link": {
+		"crossorigin":    corsSettings,
+		"fetchpriority":  priorities,
+		"referrerpolicy": referrerPolicies,
+	},
+	"script": {
+		"crossorigin":    corsSettings,
+		"fetchpriority":  priorities,
+		"referrerpolicy": referrerPolicies,
+	},
+	"textarea": {"wrap": {"soft", "hard"}},
+	"th":       {"scope": {"row", "col", "rowgroup", "colgroup"}},
+	"track":    {"kind": {"subtitles", "captions", "descriptions", "chapters", "metadata"}},
+	"video": {
+		"crossorigin": corsSettings,
+		"preload":     preloadHints,
+	},
+}
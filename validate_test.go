@@ -0,0 +1,107 @@
+package parseme
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fueripe-desu/parseme/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"conforming document", "<!DOCTYPE html><html lang=en><head><meta charset=utf-8><title>T</title><link rel=stylesheet href=a.css></head><body><p>Hi <a href=/>x</a></p><ul><li>a</li></ul><img src=a.png alt=''></body></html>", []string{}},
+		{"flow in phrasing", "<span><div>x</div></span><h1><a href=/><ul></ul></a></h1><div><a href=/><div>ok</div></a></div>", []string{"V01 1:7", "V01 1:40"}},
+		{"nested interactive", "<a href=/><button>x</button><span tabindex=0>y</span><input type=hidden></a><button><a href=/>z</a></button>", []string{"V02 1:11", "V02 1:29", "V02 1:85"}},
+		{"misplaced elements", "<li>a</li><div><li>b</li><dt>c</dt></div><table><tr><td>d</td></tr></table><template><li>e</li></template>", []string{"V03 1:1", "V03 1:16"}},
+		{"required attributes", "<img src=a.png><img alt=x><link href=a><area><area href=/><base target=_top><bdo>x</bdo>", []string{"V04 1:1", "V04 1:16", "V04 1:27", "V04 1:46", "V04 1:77"}},
+		{"enumerated values", "<form method=PUT><input type=txt><button type=Submit formmethod=post></button></form><p dir=left hidden>x</p><img src=a alt='' loading=later>", []string{"V05 1:1", "V05 1:18", "V05 1:86", "V05 1:110"}},
+		{"obsolete elements", "<center><font color=red>x</font></center><marquee>y</marquee>", []string{"V06 1:1", "V06 1:9", "V06 1:42"}},
+		{"foreign content", "<p><svg><div>x</div><li>y</li></svg></p>", []string{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			document, _, _ := parseString(HtmlMode, tc.value)
+			result := []string{}
+			for _, data := range document.Validate() {
+				result = append(result, data.Code+" "+fmt.Sprint(data.Line)+":"+fmt.Sprint(data.Column))
+			}
+			assert.Equal(result, tc.expected)
+		})
+	}
+}
+
+func Test_ValidateMessages(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<div><li>a</li></div><p dir=up><link href=a></p><span><ol></ol></span>")
+
+	findings := document.Validate()
+	messages := []string{}
+	for _, data := range findings {
+		messages = append(messages, data.Message)
+	}
+
+	assert.Equal(messages, []string{
+		"Element 'li' must be a child of 'menu', 'ol' or 'ul' but is inside 'div'.",
+		"Value 'up' of property 'dir' on 'p' is not one of 'ltr', 'rtl' or 'auto'.",
+		"Element 'link' is missing the required property 'rel' or 'itemprop'.",
+		"Element 'ol' is not allowed inside 'span', which only accepts phrasing content.",
+	})
+}
+
+func Test_ValidateReports(t *testing.T) {
+	t.Cleanup(func() {
+		errors.InitLogger(nil)
+	})
+	assert := assert.New(t)
+
+	pool := &errors.ErrorPool{}
+	observer := &errorObserver{}
+	pool.Subscribe(observer)
+	errors.InitLogger(pool)
+
+	document, _, _ := parseString(HtmlMode, "<tt>x</tt><img>")
+	document.Validate()
+
+	codes := []string{}
+	for _, info := range observer.infos {
+		codes = append(codes, info.Code)
+		assert.Equal(info.Module, "Html Validator")
+		assert.Equal(info.Level, errors.Error)
+	}
+	assert.Equal(codes, []string{"V06", "V04", "V04"})
+}
+
+func Test_ValidateControlCharacters(t *testing.T) {
+	t.Cleanup(func() {
+		errors.InitLogger(nil)
+	})
+	assert := assert.New(t)
+
+	pool := &errors.ErrorPool{}
+	observer := &errorObserver{}
+	pool.Subscribe(observer)
+	errors.InitLogger(pool)
+
+	inputs := []string{
+		"<form method=\"po\nst\"></form>",
+		"<p dir=\"\x01\">x</p>",
+		"<input type='\t'><button type=\"a\x7fb\"></button>",
+		"<div\x0b><li\x0c>x</li></div>",
+	}
+
+	for _, input := range inputs {
+		document, _, _ := parseString(HtmlMode, input)
+		assert.NotPanics(func() {
+			document.Validate()
+		})
+	}
+
+	assert.Equal(observer.infos[0].Message, "Value 'po\\nst' of property 'method' on 'form' is not one of 'get', 'post' or 'dialog'. (line 1, column 1)")
+}
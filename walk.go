@@ -0,0 +1,75 @@
+package parseme
+
+import "iter"
+
+type WalkAction int
+
+const (
+	// WalkContinue visits the children of the node and then its siblings
+	WalkContinue WalkAction = iota
+	// WalkSkipChildren leaves the children of the node unvisited
+	WalkSkipChildren
+	// WalkStop ends the walk
+	WalkStop
+)
+
+// Walk calls fn for the element and each of its descendants, depth-first in
+// document order. The action returned by fn controls how the walk goes on.
+func (e *Element) Walk(fn func(node *Element) WalkAction) {
+	node := e
+	for node != nil {
+		switch fn(node) {
+		case WalkStop:
+			return
+		case WalkSkipChildren:
+			node = nextOutside(node, e)
+		default:
+			node = nextInOrder(node, e)
+		}
+	}
+}
+
+// Descendants iterates over every node below the element in document order.
+func (e *Element) Descendants() iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		for node := nextInOrder(e, e); node != nil; node = nextInOrder(node, e) {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
+
+// Ancestors iterates from the parent of the element up to the root of its
+// tree.
+func (e *Element) Ancestors() iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		for node := e.parent; node != nil; node = node.parent {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
+
+// nextInOrder returns the node after node in document order without leaving
+// the subtree of root.
+func nextInOrder(node *Element, root *Element) *Element {
+	if node.firstChild != nil {
+		return node.firstChild
+	}
+
+	return nextOutside(node, root)
+}
+
+// nextOutside returns the first node after the subtree of node without
+// leaving the subtree of root.
+func nextOutside(node *Element, root *Element) *Element {
+	for ; node != nil && node != root; node = node.parent {
+		if node.nextSibling != nil {
+			return node.nextSibling
+		}
+	}
+
+	return nil
+}
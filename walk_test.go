@@ -0,0 +1,117 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func names(nodes []*Element) []string {
+	values := []string{}
+	for _, node := range nodes {
+		if node.elementType == TextElement {
+			values = append(values, "#"+node.value)
+		} else {
+			values = append(values, node.name)
+		}
+	}
+	return values
+}
+
+func Test_Walk(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, "<div><p>a<b>b</b></p><ul><li>c</li></ul><span>d</span></div>")
+	div, _ := document.QuerySelector("div")
+
+	testcases := []struct {
+		name     string
+		action   func(node *Element) WalkAction
+		expected []string
+	}{
+		{
+			"whole tree",
+			func(node *Element) WalkAction { return WalkContinue },
+			[]string{"div", "p", "#a", "b", "#b", "ul", "li", "#c", "span", "#d"},
+		},
+		{
+			"skip children",
+			func(node *Element) WalkAction {
+				if node.name == "p" || node.name == "li" {
+					return WalkSkipChildren
+				}
+				return WalkContinue
+			},
+			[]string{"div", "p", "ul", "li", "span", "#d"},
+		},
+		{
+			"stop",
+			func(node *Element) WalkAction {
+				if node.name == "ul" {
+					return WalkStop
+				}
+				return WalkContinue
+			},
+			[]string{"div", "p", "#a", "b", "#b", "ul"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			visited := []*Element{}
+			div.Walk(func(node *Element) WalkAction {
+				visited = append(visited, node)
+				return tc.action(node)
+			})
+			assert.Equal(t, names(visited), tc.expected)
+		})
+	}
+}
+
+func Test_Descendants(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<div><p>a<b>b</b></p><span>c</span></div><i></i>")
+	p, _ := document.QuerySelector("p")
+
+	visited := []*Element{}
+	for node := range p.Descendants() {
+		visited = append(visited, node)
+	}
+	assert.Equal(names(visited), []string{"#a", "b", "#b"})
+
+	visited = []*Element{}
+	for node := range document.Descendants() {
+		if node.name == "span" {
+			break
+		}
+		visited = append(visited, node)
+	}
+	assert.Equal(names(visited), []string{"div", "p", "#a", "b", "#b"})
+}
+
+func Test_Ancestors(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<div><p>a<b>b</b></p></div>")
+	b, _ := document.QuerySelector("b")
+
+	ancestors := []*Element{}
+	for node := range b.Ancestors() {
+		ancestors = append(ancestors, node)
+	}
+	assert.Equal(names(ancestors), []string{"p", "div", ""})
+	assert.Equal(ancestors[len(ancestors)-1], document)
+}
+
+func Test_Navigation(t *testing.T) {
+	assert := assert.New(t)
+	document, _, _ := parseString(HtmlMode, "<ul>x<li>1</li><!-- c --><li>2</li></ul>")
+	ul, _ := document.QuerySelector("ul")
+
+	assert.Equal(names(ul.Children()), []string{"li", "li"})
+	assert.Len(ul.ChildNodes(), 4)
+	assert.Equal(ul.FirstChild().Value(), "x")
+	assert.Equal(ul.LastChild().Name(), "li")
+	assert.Equal(ul.FirstChild().NextSibling().Name(), "li")
+	assert.Equal(ul.LastChild().PrevSibling().Type(), CommentElement)
+	assert.Nil(ul.FirstChild().PrevSibling())
+	assert.Nil(ul.LastChild().NextSibling())
+	assert.Equal(ul.FirstChild().Parent(), ul)
+}
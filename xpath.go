@@ -0,0 +1,913 @@
+package parseme
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XPath is a compiled XPath 1.0 expression that can be evaluated against any
+// number of trees.
+type XPath struct {
+	source string
+	expr   xpathExpr
+}
+
+type XPathOptions struct {
+	// Variables maps the names used as $name in the expression to string,
+	// float64, int, bool, *Element or []*Element values.
+	Variables map[string]any
+	// Namespaces binds the prefixes used in name tests to namespace URIs.
+	Namespaces map[string]string
+}
+
+func NewXPathOptions() *XPathOptions {
+	return &XPathOptions{Variables: map[string]any{}, Namespaces: map[string]string{}}
+}
+
+type XPathResultType int
+
+const (
+	NodeSetResult XPathResultType = iota
+	StringResult
+	NumberResult
+	BooleanResult
+)
+
+// XPathResult holds the value of an evaluated expression. Its accessors
+// convert the value with the rules of the XPath string, number and boolean
+// functions.
+type XPathResult struct {
+	value any
+}
+
+func CompileXPath(expression string) (*XPath, error) {
+	expr, err := parseXPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &XPath{source: expression, expr: expr}, nil
+}
+
+func MustCompileXPath(expression string) *XPath {
+	compiled, err := CompileXPath(expression)
+	if err != nil {
+		panic(err)
+	}
+
+	return compiled
+}
+
+func (x *XPath) String() string {
+	return x.source
+}
+
+// Evaluate evaluates the expression with node as the context node.
+func (x *XPath) Evaluate(node *Element, options *XPathOptions) (*XPathResult, error) {
+	if options == nil {
+		options = NewXPathOptions()
+	}
+
+	e := &xpathEvaluator{expression: x.source, options: options}
+	value, err := x.expr.evaluate(&xpathContext{node: xpathNode{element: node}, position: 1, size: 1, evaluator: e})
+	if err != nil {
+		return nil, err
+	}
+
+	return &XPathResult{value: value}, nil
+}
+
+// Select returns the nodes of a node-set expression in document order.
+// Attribute nodes are left out, use Evaluate to read them.
+func (x *XPath) Select(node *Element) ([]*Element, error) {
+	result, err := x.Evaluate(node, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Type() != NodeSetResult {
+		return nil, &XPathEvaluationError{expression: x.source, message: "the expression does not evaluate to a node-set"}
+	}
+
+	return result.Nodes(), nil
+}
+
+func (e *Element) XPath(expression string) (*XPathResult, error) {
+	compiled, err := CompileXPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.Evaluate(e, nil)
+}
+
+func (e *Element) SelectNodes(expression string) ([]*Element, error) {
+	compiled, err := CompileXPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.Select(e)
+}
+
+func (e *Element) SelectNode(expression string) (*Element, error) {
+	nodes, err := e.SelectNodes(expression)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+
+	return nodes[0], nil
+}
+
+func (r *XPathResult) Type() XPathResultType {
+	switch r.value.(type) {
+	case xpathNodeSet:
+		return NodeSetResult
+	case string:
+		return StringResult
+	case float64:
+		return NumberResult
+	}
+
+	return BooleanResult
+}
+
+// Nodes returns the element, text, comment and processing instruction
+// nodes of a node-set result.
+func (r *XPathResult) Nodes() []*Element {
+	nodes := []*Element{}
+	if set, ok := r.value.(xpathNodeSet); ok {
+		for _, node := range set {
+			if node.property == nil {
+				nodes = append(nodes, node.element)
+			}
+		}
+	}
+
+	return nodes
+}
+
+// Properties returns the attribute nodes of a node-set result.
+func (r *XPathResult) Properties() []*Property {
+	properties := []*Property{}
+	if set, ok := r.value.(xpathNodeSet); ok {
+		for _, node := range set {
+			if node.property != nil {
+				properties = append(properties, node.property)
+			}
+		}
+	}
+
+	return properties
+}
+
+// Strings returns the string value of every node of a node-set result, or
+// the string value of any other result.
+func (r *XPathResult) Strings() []string {
+	set, ok := r.value.(xpathNodeSet)
+	if !ok {
+		return []string{r.String()}
+	}
+
+	values := []string{}
+	for _, node := range set {
+		values = append(values, node.stringValue())
+	}
+
+	return values
+}
+
+func (r *XPathResult) String() string {
+	return xpathString(r.value)
+}
+
+func (r *XPathResult) Number() float64 {
+	return xpathNumber(r.value)
+}
+
+func (r *XPathResult) Boolean() bool {
+	return xpathBoolean(r.value)
+}
+
+// xpathNode is a node of the XPath data model. Attribute nodes are the
+// properties of an element.
+type xpathNode struct {
+	element  *Element
+	property *Property
+}
+
+type xpathNodeSet []xpathNode
+
+func (n xpathNode) stringValue() string {
+	if n.property != nil {
+		value, _ := attributeValue(n.element, n.property.name)
+		return value
+	}
+
+	switch n.element.elementType {
+	case DocumentElement, TagElement:
+		builder := strings.Builder{}
+		collectText(n.element, &builder)
+		return builder.String()
+	}
+
+	return n.element.value
+}
+
+func collectText(element *Element, builder *strings.Builder) {
+	for child := element.firstChild; child != nil; child = child.nextSibling {
+		switch child.elementType {
+		case TextElement, CDATAElement:
+			builder.WriteString(child.value)
+		case TagElement:
+			collectText(child, builder)
+		}
+	}
+}
+
+type xpathEvaluator struct {
+	expression string
+	options    *XPathOptions
+	order      map[*Element]int
+}
+
+type xpathContext struct {
+	node      xpathNode
+	position  int
+	size      int
+	evaluator *xpathEvaluator
+}
+
+func (e *xpathEvaluator) fail(message string) error {
+	return &XPathEvaluationError{expression: e.expression, message: message}
+}
+
+// sort puts the nodes in document order and removes duplicates. The order
+// of the tree is computed the first time it is needed.
+func (e *xpathEvaluator) sort(nodes xpathNodeSet) xpathNodeSet {
+	if len(nodes) < 2 {
+		return nodes
+	}
+
+	if e.order == nil {
+		e.order = map[*Element]int{}
+	}
+
+	if _, ok := e.order[nodes[0].element]; !ok {
+		root := nodes[0].element
+		for root.parent != nil {
+			root = root.parent
+		}
+		e.number(root)
+	}
+
+	position := func(node xpathNode) (int, int) {
+		if node.property == nil {
+			return e.order[node.element], 0
+		}
+
+		for i, property := range node.element.properties {
+			if property == node.property {
+				return e.order[node.element], i + 1
+			}
+		}
+
+		return e.order[node.element], 0
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		first, firstProperty := position(nodes[i])
+		second, secondProperty := position(nodes[j])
+		if first != second {
+			return first < second
+		}
+		return firstProperty < secondProperty
+	})
+
+	unique := nodes[:1]
+	for _, node := range nodes[1:] {
+		if node != unique[len(unique)-1] {
+			unique = append(unique, node)
+		}
+	}
+
+	return unique
+}
+
+func (e *xpathEvaluator) number(element *Element) {
+	e.order[element] = len(e.order)
+	for child := element.firstChild; child != nil; child = child.nextSibling {
+		e.number(child)
+	}
+}
+
+type xpathExpr interface {
+	evaluate(ctx *xpathContext) (any, error)
+}
+
+type literalExpr struct {
+	value any
+}
+
+func (x *literalExpr) evaluate(ctx *xpathContext) (any, error) {
+	return x.value, nil
+}
+
+type variableExpr struct {
+	name string
+}
+
+func (x *variableExpr) evaluate(ctx *xpathContext) (any, error) {
+	value, ok := ctx.evaluator.options.Variables[x.name]
+	if !ok {
+		return nil, ctx.evaluator.fail("undefined variable $" + x.name)
+	}
+
+	switch value := value.(type) {
+	case string, float64, bool:
+		return value, nil
+	case int:
+		return float64(value), nil
+	case *Element:
+		return xpathNodeSet{{element: value}}, nil
+	case []*Element:
+		set := xpathNodeSet{}
+		for _, element := range value {
+			set = append(set, xpathNode{element: element})
+		}
+		return ctx.evaluator.sort(set), nil
+	}
+
+	return nil, ctx.evaluator.fail("unsupported type for variable $" + x.name)
+}
+
+type negateExpr struct {
+	operand xpathExpr
+}
+
+func (x *negateExpr) evaluate(ctx *xpathContext) (any, error) {
+	value, err := x.operand.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return -xpathNumber(value), nil
+}
+
+type unionExpr struct {
+	left  xpathExpr
+	right xpathExpr
+}
+
+func (x *unionExpr) evaluate(ctx *xpathContext) (any, error) {
+	left, err := evaluateNodeSet(x.left, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := evaluateNodeSet(x.right, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	union := append(append(xpathNodeSet{}, left...), right...)
+	return ctx.evaluator.sort(union), nil
+}
+
+func evaluateNodeSet(expr xpathExpr, ctx *xpathContext) (xpathNodeSet, error) {
+	value, err := expr.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set, ok := value.(xpathNodeSet)
+	if !ok {
+		return nil, ctx.evaluator.fail("expected a node-set")
+	}
+
+	return set, nil
+}
+
+type binaryExpr struct {
+	operator string
+	left     xpathExpr
+	right    xpathExpr
+}
+
+func (x *binaryExpr) evaluate(ctx *xpathContext) (any, error) {
+	left, err := x.left.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The right operand of and/or is only evaluated when needed
+	switch x.operator {
+	case "and":
+		if !xpathBoolean(left) {
+			return false, nil
+		}
+	case "or":
+		if xpathBoolean(left) {
+			return true, nil
+		}
+	}
+
+	right, err := x.right.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x.operator {
+	case "and", "or":
+		return xpathBoolean(right), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return xpathCompare(x.operator, left, right), nil
+	}
+
+	a, b := xpathNumber(left), xpathNumber(right)
+	switch x.operator {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "div":
+		return a / b, nil
+	}
+
+	return math.Mod(a, b), nil
+}
+
+// xpathCompare compares two values. Node-sets are compared through the
+// string values of their nodes and the comparison is true when it holds for
+// any of them.
+func xpathCompare(operator string, left any, right any) bool {
+	leftSet, leftIsSet := left.(xpathNodeSet)
+	rightSet, rightIsSet := right.(xpathNodeSet)
+
+	switch {
+	case leftIsSet && rightIsSet:
+		for _, a := range leftSet {
+			for _, b := range rightSet {
+				if compareAtoms(operator, a.stringValue(), b.stringValue()) {
+					return true
+				}
+			}
+		}
+		return false
+	case leftIsSet:
+		if _, ok := right.(bool); ok {
+			return compareAtoms(operator, xpathBoolean(left), right)
+		}
+		for _, a := range leftSet {
+			if compareAtoms(operator, a.stringValue(), right) {
+				return true
+			}
+		}
+		return false
+	case rightIsSet:
+		if _, ok := left.(bool); ok {
+			return compareAtoms(operator, left, xpathBoolean(right))
+		}
+		for _, b := range rightSet {
+			if compareAtoms(operator, left, b.stringValue()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return compareAtoms(operator, left, right)
+}
+
+func compareAtoms(operator string, left any, right any) bool {
+	if operator == "=" || operator == "!=" {
+		equal := false
+
+		_, leftIsBool := left.(bool)
+		_, rightIsBool := right.(bool)
+		_, leftIsNumber := left.(float64)
+		_, rightIsNumber := right.(float64)
+
+		switch {
+		case leftIsBool || rightIsBool:
+			equal = xpathBoolean(left) == xpathBoolean(right)
+		case leftIsNumber || rightIsNumber:
+			equal = xpathNumber(left) == xpathNumber(right)
+		default:
+			equal = xpathString(left) == xpathString(right)
+		}
+
+		return equal == (operator == "=")
+	}
+
+	a, b := xpathNumber(left), xpathNumber(right)
+	switch operator {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	}
+
+	return a >= b
+}
+
+type filterExpr struct {
+	primary    xpathExpr
+	predicates []xpathExpr
+}
+
+func (x *filterExpr) evaluate(ctx *xpathContext) (any, error) {
+	set, err := evaluateNodeSet(x.primary, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyPredicates(set, x.predicates, ctx.evaluator)
+}
+
+// applyPredicates keeps the nodes for which every predicate holds. A number
+// predicate holds for the node at that position.
+func applyPredicates(set xpathNodeSet, predicates []xpathExpr, e *xpathEvaluator) (xpathNodeSet, error) {
+	for _, predicate := range predicates {
+		filtered := xpathNodeSet{}
+
+		for i, node := range set {
+			value, err := predicate.evaluate(&xpathContext{node: node, position: i + 1, size: len(set), evaluator: e})
+			if err != nil {
+				return nil, err
+			}
+
+			if number, ok := value.(float64); ok {
+				if number == float64(i+1) {
+					filtered = append(filtered, node)
+				}
+				continue
+			}
+
+			if xpathBoolean(value) {
+				filtered = append(filtered, node)
+			}
+		}
+
+		set = filtered
+	}
+
+	return set, nil
+}
+
+type pathExpr struct {
+	filter   xpathExpr
+	absolute bool
+	steps    []*xpathStep
+}
+
+func (x *pathExpr) evaluate(ctx *xpathContext) (any, error) {
+	var set xpathNodeSet
+
+	switch {
+	case x.filter != nil:
+		var err error
+		set, err = evaluateNodeSet(x.filter, ctx)
+		if err != nil {
+			return nil, err
+		}
+	case x.absolute:
+		root := ctx.node.element
+		for root.parent != nil {
+			root = root.parent
+		}
+		set = xpathNodeSet{{element: root}}
+	default:
+		set = xpathNodeSet{ctx.node}
+	}
+
+	for _, step := range x.steps {
+		next := xpathNodeSet{}
+
+		for _, node := range set {
+			selected, err := step.evaluate(node, ctx.evaluator)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, selected...)
+		}
+
+		set = ctx.evaluator.sort(next)
+	}
+
+	return set, nil
+}
+
+type xpathStep struct {
+	axis       string
+	test       xpathNodeTest
+	predicates []xpathExpr
+}
+
+// evaluate returns the nodes selected from node, in the order of the axis
+// so that predicates see proximity positions.
+func (s *xpathStep) evaluate(node xpathNode, e *xpathEvaluator) (xpathNodeSet, error) {
+	selected := xpathNodeSet{}
+
+	for _, candidate := range xpathAxis(s.axis, node) {
+		ok, err := s.test.matches(candidate, s.axis, e)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			selected = append(selected, candidate)
+		}
+	}
+
+	return applyPredicates(selected, s.predicates, e)
+}
+
+// xpathChildren returns the children that exist in the XPath data model,
+// which has no doctype and no XML declaration nodes.
+func xpathChildren(element *Element) xpathNodeSet {
+	children := xpathNodeSet{}
+	for child := element.firstChild; child != nil; child = child.nextSibling {
+		if child.elementType != DoctypeElement && child.elementType != DeclarationElement {
+			children = append(children, xpathNode{element: child})
+		}
+	}
+	return children
+}
+
+func xpathDescendants(element *Element, nodes xpathNodeSet) xpathNodeSet {
+	for _, child := range xpathChildren(element) {
+		nodes = append(nodes, child)
+		nodes = xpathDescendants(child.element, nodes)
+	}
+	return nodes
+}
+
+func xpathParent(node xpathNode) (xpathNode, bool) {
+	if node.property != nil {
+		return xpathNode{element: node.element}, true
+	}
+
+	if node.element.parent == nil {
+		return xpathNode{}, false
+	}
+
+	return xpathNode{element: node.element.parent}, true
+}
+
+func xpathAxis(axis string, node xpathNode) xpathNodeSet {
+	nodes := xpathNodeSet{}
+	isAttribute := node.property != nil
+
+	switch axis {
+	case "self":
+		nodes = append(nodes, node)
+	case "child":
+		if !isAttribute {
+			nodes = xpathChildren(node.element)
+		}
+	case "descendant", "descendant-or-self":
+		if axis == "descendant-or-self" {
+			nodes = append(nodes, node)
+		}
+		if !isAttribute {
+			nodes = xpathDescendants(node.element, nodes)
+		}
+	case "parent":
+		if parent, ok := xpathParent(node); ok {
+			nodes = append(nodes, parent)
+		}
+	case "ancestor", "ancestor-or-self":
+		if axis == "ancestor-or-self" {
+			nodes = append(nodes, node)
+		}
+		for parent, ok := xpathParent(node); ok; parent, ok = xpathParent(parent) {
+			nodes = append(nodes, parent)
+		}
+	case "attribute":
+		if !isAttribute && node.element.elementType == TagElement {
+			for _, property := range node.element.properties {
+				if isXPathAttribute(node.element, property) {
+					nodes = append(nodes, xpathNode{element: node.element, property: property})
+				}
+			}
+		}
+	case "following-sibling", "preceding-sibling":
+		if isAttribute {
+			break
+		}
+		for sibling := xpathSibling(node.element, axis); sibling != nil; sibling = xpathSibling(sibling, axis) {
+			if sibling.elementType != DoctypeElement && sibling.elementType != DeclarationElement {
+				nodes = append(nodes, xpathNode{element: sibling})
+			}
+		}
+	case "following":
+		// The descendants of an element follow its attributes
+		if isAttribute {
+			nodes = xpathDescendants(node.element, nodes)
+		}
+		for current := node.element; current != nil; current = current.parent {
+			for _, sibling := range xpathAxis("following-sibling", xpathNode{element: current}) {
+				nodes = append(nodes, sibling)
+				nodes = xpathDescendants(sibling.element, nodes)
+			}
+		}
+	case "preceding":
+		for current := node.element; current != nil; current = current.parent {
+			for _, sibling := range xpathAxis("preceding-sibling", xpathNode{element: current}) {
+				descendants := xpathDescendants(sibling.element, xpathNodeSet{})
+				for i := len(descendants) - 1; i >= 0; i-- {
+					nodes = append(nodes, descendants[i])
+				}
+				nodes = append(nodes, sibling)
+			}
+		}
+	}
+
+	return nodes
+}
+
+func xpathSibling(element *Element, axis string) *Element {
+	if axis == "following-sibling" {
+		return element.nextSibling
+	}
+
+	return element.prevSibling
+}
+
+// Namespace declarations and boolean properties set to false are not
+// attributes.
+func isXPathAttribute(element *Element, property *Property) bool {
+	if element.isXml() && (property.name == "xmlns" || strings.HasPrefix(property.name, "xmlns:")) {
+		return false
+	}
+
+	return !(property.IsBoolean() && property.value == "false")
+}
+
+type xpathNodeTest struct {
+	// Name tests have a name, which may be a star, and an optional prefix
+	prefix string
+	name   string
+
+	// Node type tests have a type and, for processing instructions, an
+	// optional target
+	nodeType string
+	target   string
+}
+
+func (t *xpathNodeTest) matches(node xpathNode, axis string, e *xpathEvaluator) (bool, error) {
+	element := node.element
+
+	if t.nodeType != "" {
+		switch t.nodeType {
+		case "node":
+			return true, nil
+		case "text":
+			return node.property == nil && (element.elementType == TextElement || element.elementType == CDATAElement), nil
+		case "comment":
+			return node.property == nil && element.elementType == CommentElement, nil
+		}
+
+		if node.property != nil || element.elementType != InstructionElement {
+			return false, nil
+		}
+		return t.target == "" || t.target == element.name, nil
+	}
+
+	// Name tests select the principal node type of the axis
+	var name, prefix, namespace string
+	if axis == "attribute" {
+		if node.property == nil {
+			return false, nil
+		}
+		name, namespace = node.property.name, node.property.namespace
+		if index := strings.IndexByte(name, ':'); index != -1 {
+			prefix, name = name[:index], name[index+1:]
+		}
+	} else {
+		if node.property != nil || element.elementType != TagElement {
+			return false, nil
+		}
+		name, prefix, namespace = element.LocalName(), element.prefix, element.namespace
+	}
+
+	// HTML documents have no namespaces and their names ignore case
+	if !element.isXml() {
+		return strings.EqualFold(prefix, t.prefix) && (t.name == "*" || strings.EqualFold(name, t.name)), nil
+	}
+
+	if t.prefix != "" {
+		uri, ok := e.options.Namespaces[t.prefix]
+		if !ok && t.prefix == "xml" {
+			uri, ok = xmlNamespace, true
+		}
+
+		if !ok {
+			return false, e.fail("undeclared namespace prefix '" + t.prefix + "'")
+		}
+
+		return namespace == uri && (t.name == "*" || t.name == name), nil
+	}
+
+	if t.name == "*" {
+		return true, nil
+	}
+
+	return namespace == "" && name == t.name, nil
+}
+
+func xpathString(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return formatXPathNumber(value)
+	case xpathNodeSet:
+		if len(value) == 0 {
+			return ""
+		}
+		return value[0].stringValue()
+	}
+
+	return ""
+}
+
+func formatXPathNumber(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	case value == 0:
+		return "0"
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func xpathNumber(value any) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case bool:
+		if value {
+			return 1
+		}
+		return 0
+	}
+
+	return parseXPathNumber(xpathString(value))
+}
+
+// parseXPathNumber accepts an optional minus sign followed by digits with
+// an optional decimal point, and returns NaN for anything else.
+func parseXPathNumber(value string) float64 {
+	value = strings.Trim(value, " \t\n\r")
+	digits := strings.TrimPrefix(value, "-")
+
+	if digits == "" || digits == "." || strings.Count(digits, ".") > 1 {
+		return math.NaN()
+	}
+
+	for i := 0; i < len(digits); i++ {
+		if digits[i] != '.' && (digits[i] < '0' || digits[i] > '9') {
+			return math.NaN()
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return math.NaN()
+	}
+
+	return number
+}
+
+func xpathBoolean(value any) bool {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case float64:
+		return value != 0 && !math.IsNaN(value)
+	case string:
+		return value != ""
+	case xpathNodeSet:
+		return len(value) > 0
+	}
+
+	return false
+}
@@ -0,0 +1,382 @@
+package parseme
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+type xpathFunction struct {
+	minArguments int
+	// maxArguments is -1 for functions that accept any number of arguments
+	maxArguments int
+	call         func(ctx *xpathContext, arguments []any) (any, error)
+}
+
+type functionCall struct {
+	name      string
+	function  xpathFunction
+	arguments []xpathExpr
+}
+
+func (x *functionCall) evaluate(ctx *xpathContext) (any, error) {
+	arguments := []any{}
+	for _, argument := range x.arguments {
+		value, err := argument.evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, value)
+	}
+
+	return x.function.call(ctx, arguments)
+}
+
+var xpathFunctions = map[string]xpathFunction{
+	// Node set functions
+	"last":          {0, 0, xpathLast},
+	"position":      {0, 0, xpathPosition},
+	"count":         {1, 1, xpathCount},
+	"id":            {1, 1, xpathId},
+	"local-name":    {0, 1, xpathLocalName},
+	"namespace-uri": {0, 1, xpathNamespaceUri},
+	"name":          {0, 1, xpathName},
+
+	// String functions
+	"string":           {0, 1, xpathStringFunction},
+	"concat":           {2, -1, xpathConcat},
+	"starts-with":      {2, 2, xpathStartsWith},
+	"contains":         {2, 2, xpathContains},
+	"substring-before": {2, 2, xpathSubstringBefore},
+	"substring-after":  {2, 2, xpathSubstringAfter},
+	"substring":        {2, 3, xpathSubstring},
+	"string-length":    {0, 1, xpathStringLength},
+	"normalize-space":  {0, 1, xpathNormalizeSpace},
+	"translate":        {3, 3, xpathTranslate},
+
+	// Boolean functions
+	"boolean": {1, 1, xpathBooleanFunction},
+	"not":     {1, 1, xpathNot},
+	"true":    {0, 0, xpathTrue},
+	"false":   {0, 0, xpathFalse},
+	"lang":    {1, 1, xpathLang},
+
+	// Number functions
+	"number":  {0, 1, xpathNumberFunction},
+	"sum":     {1, 1, xpathSum},
+	"floor":   {1, 1, xpathFloor},
+	"ceiling": {1, 1, xpathCeiling},
+	"round":   {1, 1, xpathRound},
+}
+
+// contextArgument returns the only argument of the functions that default
+// to the context node.
+func contextArgument(ctx *xpathContext, arguments []any) any {
+	if len(arguments) == 0 {
+		return xpathNodeSet{ctx.node}
+	}
+
+	return arguments[0]
+}
+
+// firstNode returns the first node of a node-set argument.
+func firstNode(ctx *xpathContext, arguments []any) (xpathNode, bool, error) {
+	set, ok := contextArgument(ctx, arguments).(xpathNodeSet)
+	if !ok {
+		return xpathNode{}, false, ctx.evaluator.fail("expected a node-set argument")
+	}
+
+	if len(set) == 0 {
+		return xpathNode{}, false, nil
+	}
+
+	return set[0], true, nil
+}
+
+func xpathLast(ctx *xpathContext, arguments []any) (any, error) {
+	return float64(ctx.size), nil
+}
+
+func xpathPosition(ctx *xpathContext, arguments []any) (any, error) {
+	return float64(ctx.position), nil
+}
+
+func xpathCount(ctx *xpathContext, arguments []any) (any, error) {
+	set, ok := arguments[0].(xpathNodeSet)
+	if !ok {
+		return nil, ctx.evaluator.fail("count expects a node-set")
+	}
+
+	return float64(len(set)), nil
+}
+
+func xpathId(ctx *xpathContext, arguments []any) (any, error) {
+	ids := map[string]bool{}
+
+	if set, ok := arguments[0].(xpathNodeSet); ok {
+		for _, node := range set {
+			for _, id := range strings.Fields(node.stringValue()) {
+				ids[id] = true
+			}
+		}
+	} else {
+		for _, id := range strings.Fields(xpathString(arguments[0])) {
+			ids[id] = true
+		}
+	}
+
+	root := ctx.node.element
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	found := xpathNodeSet{}
+	walkElements(root, func(element *Element) bool {
+		if value, ok := attributeValue(element, "id"); ok && ids[value] {
+			found = append(found, xpathNode{element: element})
+			delete(ids, value)
+		}
+		return len(ids) > 0
+	})
+
+	return found, nil
+}
+
+func xpathLocalName(ctx *xpathContext, arguments []any) (any, error) {
+	node, ok, err := firstNode(ctx, arguments)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	if node.property != nil {
+		name := node.property.name
+		return name[strings.IndexByte(name, ':')+1:], nil
+	}
+
+	switch node.element.elementType {
+	case TagElement:
+		return node.element.LocalName(), nil
+	case InstructionElement:
+		return node.element.name, nil
+	}
+
+	return "", nil
+}
+
+func xpathNamespaceUri(ctx *xpathContext, arguments []any) (any, error) {
+	node, ok, err := firstNode(ctx, arguments)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	if node.property != nil {
+		return node.property.namespace, nil
+	}
+
+	if node.element.elementType == TagElement {
+		return node.element.namespace, nil
+	}
+
+	return "", nil
+}
+
+func xpathName(ctx *xpathContext, arguments []any) (any, error) {
+	node, ok, err := firstNode(ctx, arguments)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	if node.property != nil {
+		return node.property.name, nil
+	}
+
+	switch node.element.elementType {
+	case TagElement, InstructionElement:
+		return node.element.name, nil
+	}
+
+	return "", nil
+}
+
+func xpathStringFunction(ctx *xpathContext, arguments []any) (any, error) {
+	return xpathString(contextArgument(ctx, arguments)), nil
+}
+
+func xpathConcat(ctx *xpathContext, arguments []any) (any, error) {
+	builder := strings.Builder{}
+	for _, argument := range arguments {
+		builder.WriteString(xpathString(argument))
+	}
+
+	return builder.String(), nil
+}
+
+func xpathStartsWith(ctx *xpathContext, arguments []any) (any, error) {
+	return strings.HasPrefix(xpathString(arguments[0]), xpathString(arguments[1])), nil
+}
+
+func xpathContains(ctx *xpathContext, arguments []any) (any, error) {
+	return strings.Contains(xpathString(arguments[0]), xpathString(arguments[1])), nil
+}
+
+func xpathSubstringBefore(ctx *xpathContext, arguments []any) (any, error) {
+	value, separator := xpathString(arguments[0]), xpathString(arguments[1])
+	if index := strings.Index(value, separator); index != -1 {
+		return value[:index], nil
+	}
+
+	return "", nil
+}
+
+func xpathSubstringAfter(ctx *xpathContext, arguments []any) (any, error) {
+	value, separator := xpathString(arguments[0]), xpathString(arguments[1])
+	if index := strings.Index(value, separator); index != -1 {
+		return value[index+len(separator):], nil
+	}
+
+	return "", nil
+}
+
+// xpathSubstring keeps the characters whose 1-based position p satisfies
+// round(start) <= p < round(start) + round(length), which gives the
+// behaviour the standard requires for NaN and infinite arguments.
+func xpathSubstring(ctx *xpathContext, arguments []any) (any, error) {
+	value := []rune(xpathString(arguments[0]))
+	start := roundXPathNumber(xpathNumber(arguments[1]))
+
+	end := math.Inf(1)
+	if len(arguments) == 3 {
+		end = start + roundXPathNumber(xpathNumber(arguments[2]))
+	}
+
+	builder := strings.Builder{}
+	for i, r := range value {
+		position := float64(i + 1)
+		if position >= start && position < end {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+func xpathStringLength(ctx *xpathContext, arguments []any) (any, error) {
+	return float64(utf8.RuneCountInString(xpathString(contextArgument(ctx, arguments)))), nil
+}
+
+func xpathNormalizeSpace(ctx *xpathContext, arguments []any) (any, error) {
+	return strings.Join(strings.FieldsFunc(xpathString(contextArgument(ctx, arguments)), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}), " "), nil
+}
+
+func xpathTranslate(ctx *xpathContext, arguments []any) (any, error) {
+	from := []rune(xpathString(arguments[1]))
+	to := []rune(xpathString(arguments[2]))
+
+	// Only the first occurrence of a character in from counts
+	replacements := map[rune]rune{}
+	for i, r := range from {
+		if _, ok := replacements[r]; ok {
+			continue
+		}
+
+		if i < len(to) {
+			replacements[r] = to[i]
+		} else {
+			replacements[r] = -1
+		}
+	}
+
+	builder := strings.Builder{}
+	for _, r := range xpathString(arguments[0]) {
+		replacement, ok := replacements[r]
+		switch {
+		case !ok:
+			builder.WriteRune(r)
+		case replacement != -1:
+			builder.WriteRune(replacement)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+func xpathBooleanFunction(ctx *xpathContext, arguments []any) (any, error) {
+	return xpathBoolean(arguments[0]), nil
+}
+
+func xpathNot(ctx *xpathContext, arguments []any) (any, error) {
+	return !xpathBoolean(arguments[0]), nil
+}
+
+func xpathTrue(ctx *xpathContext, arguments []any) (any, error) {
+	return true, nil
+}
+
+func xpathFalse(ctx *xpathContext, arguments []any) (any, error) {
+	return false, nil
+}
+
+// xpathLang looks for the closest xml:lang property, or lang property in
+// HTML documents, and compares it ignoring case and subtags.
+func xpathLang(ctx *xpathContext, arguments []any) (any, error) {
+	expected := strings.ToLower(xpathString(arguments[0]))
+
+	for element := ctx.node.element; element != nil; element = element.parent {
+		if element.elementType != TagElement {
+			continue
+		}
+
+		value, ok := attributeValue(element, "xml:lang")
+		if !ok && !element.isXml() {
+			value, ok = attributeValue(element, "lang")
+		}
+
+		if ok {
+			value = strings.ToLower(value)
+			return value == expected || strings.HasPrefix(value, expected+"-"), nil
+		}
+	}
+
+	return false, nil
+}
+
+func xpathNumberFunction(ctx *xpathContext, arguments []any) (any, error) {
+	return xpathNumber(contextArgument(ctx, arguments)), nil
+}
+
+func xpathSum(ctx *xpathContext, arguments []any) (any, error) {
+	set, ok := arguments[0].(xpathNodeSet)
+	if !ok {
+		return nil, ctx.evaluator.fail("sum expects a node-set")
+	}
+
+	sum := 0.0
+	for _, node := range set {
+		sum += parseXPathNumber(node.stringValue())
+	}
+
+	return sum, nil
+}
+
+func xpathFloor(ctx *xpathContext, arguments []any) (any, error) {
+	return math.Floor(xpathNumber(arguments[0])), nil
+}
+
+func xpathCeiling(ctx *xpathContext, arguments []any) (any, error) {
+	return math.Ceil(xpathNumber(arguments[0])), nil
+}
+
+func xpathRound(ctx *xpathContext, arguments []any) (any, error) {
+	return roundXPathNumber(xpathNumber(arguments[0])), nil
+}
+
+// roundXPathNumber rounds halves towards positive infinity.
+func roundXPathNumber(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+
+	return math.Floor(value + 0.5)
+}
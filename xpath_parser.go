@@ -0,0 +1,659 @@
+package parseme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type xpathTokenType int
+
+const (
+	xpathEndToken xpathTokenType = iota
+	xpathNumberToken
+	xpathLiteralToken
+	xpathVariableToken
+	xpathSymbolToken
+	xpathOperatorToken
+	xpathNameTestToken
+	xpathNodeTypeToken
+	xpathFunctionToken
+	xpathAxisToken
+)
+
+type xpathToken struct {
+	tokenType xpathTokenType
+	value     string
+	position  int
+}
+
+var xpathNodeTypes = map[string]bool{
+	"comment":                true,
+	"text":                   true,
+	"processing-instruction": true,
+	"node":                   true,
+}
+
+var xpathAxes = map[string]bool{
+	"ancestor":           true,
+	"ancestor-or-self":   true,
+	"attribute":          true,
+	"child":              true,
+	"descendant":         true,
+	"descendant-or-self": true,
+	"following":          true,
+	"following-sibling":  true,
+	"namespace":          true,
+	"parent":             true,
+	"preceding":          true,
+	"preceding-sibling":  true,
+	"self":               true,
+}
+
+// Axes whose proximity positions count backwards in document order.
+var xpathReverseAxes = map[string]bool{
+	"ancestor":          true,
+	"ancestor-or-self":  true,
+	"preceding":         true,
+	"preceding-sibling": true,
+}
+
+type xpathLexer struct {
+	input  string
+	pos    int
+	tokens []xpathToken
+}
+
+func tokenizeXPath(input string) ([]xpathToken, error) {
+	l := &xpathLexer{input: input}
+
+	for {
+		for l.pos < len(l.input) && isSpace(l.input[l.pos]) {
+			l.pos++
+		}
+
+		if l.pos >= len(l.input) {
+			l.tokens = append(l.tokens, xpathToken{tokenType: xpathEndToken, position: l.pos})
+			return l.tokens, nil
+		}
+
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *xpathLexer) fail(format string, args ...any) error {
+	return &XPathSyntaxError{expression: l.input, position: l.pos, message: fmt.Sprintf(format, args...)}
+}
+
+func (l *xpathLexer) emit(tokenType xpathTokenType, value string, start int) {
+	l.tokens = append(l.tokens, xpathToken{tokenType: tokenType, value: value, position: start})
+}
+
+// operatorExpected applies the disambiguation rules of the XPath grammar: a
+// star or a name is an operator when it follows a token that can end an
+// operand.
+func (l *xpathLexer) operatorExpected() bool {
+	if len(l.tokens) == 0 {
+		return false
+	}
+
+	previous := l.tokens[len(l.tokens)-1]
+	switch previous.tokenType {
+	case xpathOperatorToken, xpathAxisToken:
+		return false
+	case xpathSymbolToken:
+		switch previous.value {
+		case "@", "::", "(", "[", ",", "/", "//", "|", "+", "-", "=", "!=", "<", "<=", ">", ">=":
+			return false
+		}
+	case xpathNodeTypeToken, xpathFunctionToken:
+		return false
+	}
+
+	return true
+}
+
+func (l *xpathLexer) next() error {
+	start := l.pos
+	b := l.input[l.pos]
+	rest := l.input[l.pos:]
+
+	switch {
+	case b == '"' || b == '\'':
+		end := strings.IndexByte(rest[1:], b)
+		if end == -1 {
+			return l.fail("unterminated string literal")
+		}
+		l.pos += end + 2
+		l.emit(xpathLiteralToken, rest[1:end+1], start)
+	case (b >= '0' && b <= '9') || (b == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9'):
+		for l.pos < len(l.input) && (l.input[l.pos] >= '0' && l.input[l.pos] <= '9') {
+			l.pos++
+		}
+		if l.pos < len(l.input) && l.input[l.pos] == '.' {
+			l.pos++
+			for l.pos < len(l.input) && (l.input[l.pos] >= '0' && l.input[l.pos] <= '9') {
+				l.pos++
+			}
+		}
+		l.emit(xpathNumberToken, l.input[start:l.pos], start)
+	case b == '$':
+		l.pos++
+		name := l.readQName()
+		if name == "" {
+			return l.fail("expected a variable name")
+		}
+		l.emit(xpathVariableToken, name, start)
+	case strings.HasPrefix(rest, "//"), strings.HasPrefix(rest, ".."), strings.HasPrefix(rest, "::"),
+		strings.HasPrefix(rest, "!="), strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, ">="):
+		l.pos += 2
+		l.emit(xpathSymbolToken, rest[:2], start)
+	case b == '*':
+		l.pos++
+		if l.operatorExpected() {
+			l.emit(xpathOperatorToken, "*", start)
+		} else {
+			l.emit(xpathNameTestToken, "*", start)
+		}
+	case strings.IndexByte("/()[].@,|+-=<>", b) != -1:
+		l.pos++
+		l.emit(xpathSymbolToken, rest[:1], start)
+	case isXPathNameStart(b):
+		return l.readName(start)
+	default:
+		return l.fail("unexpected '%v'", string(b))
+	}
+
+	return nil
+}
+
+func (l *xpathLexer) readName(start int) error {
+	if l.operatorExpected() {
+		name := l.readNCName()
+		switch name {
+		case "and", "or", "mod", "div":
+			l.emit(xpathOperatorToken, name, start)
+			return nil
+		}
+		l.pos = start
+		return l.fail("expected an operator")
+	}
+
+	name := l.readQName()
+
+	// A prefixed wildcard such as svg:* is a name test
+	if strings.HasSuffix(name, ":") {
+		if l.pos < len(l.input) && l.input[l.pos] == '*' {
+			l.pos++
+			l.emit(xpathNameTestToken, name+"*", start)
+			return nil
+		}
+		l.pos = start
+		return l.fail("invalid qualified name")
+	}
+
+	lookahead := l.pos
+	for lookahead < len(l.input) && isSpace(l.input[lookahead]) {
+		lookahead++
+	}
+	rest := l.input[lookahead:]
+
+	switch {
+	case strings.HasPrefix(rest, "::"):
+		if !xpathAxes[name] {
+			l.pos = start
+			return l.fail("unknown axis '%v'", name)
+		}
+		l.emit(xpathAxisToken, name, start)
+	case strings.HasPrefix(rest, "("):
+		if xpathNodeTypes[name] {
+			l.emit(xpathNodeTypeToken, name, start)
+		} else {
+			l.emit(xpathFunctionToken, name, start)
+		}
+	default:
+		l.emit(xpathNameTestToken, name, start)
+	}
+
+	return nil
+}
+
+func (l *xpathLexer) readNCName() string {
+	start := l.pos
+	if l.pos < len(l.input) && isXPathNameStart(l.input[l.pos]) {
+		l.pos++
+		for l.pos < len(l.input) && isXPathNameChar(l.input[l.pos]) {
+			l.pos++
+		}
+	}
+	return l.input[start:l.pos]
+}
+
+// readQName reads a name with an optional prefix. A trailing colon is kept
+// when the prefix is followed by a star.
+func (l *xpathLexer) readQName() string {
+	start := l.pos
+	if l.readNCName() == "" {
+		return ""
+	}
+
+	if l.pos+1 < len(l.input) && l.input[l.pos] == ':' && l.input[l.pos+1] != ':' {
+		l.pos++
+		if l.input[l.pos] == '*' {
+			return l.input[start:l.pos]
+		}
+		if l.readNCName() == "" {
+			l.pos--
+		}
+	}
+
+	return l.input[start:l.pos]
+}
+
+func isXPathNameStart(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_' || b >= 0x80
+}
+
+func isXPathNameChar(b byte) bool {
+	return isXPathNameStart(b) || (b >= '0' && b <= '9') || b == '-' || b == '.'
+}
+
+type xpathParser struct {
+	input  string
+	tokens []xpathToken
+	pos    int
+}
+
+func parseXPath(input string) (xpathExpr, error) {
+	tokens, err := tokenizeXPath(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &xpathParser{input: input, tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().tokenType != xpathEndToken {
+		return nil, p.fail("unexpected '%v'", p.peek().value)
+	}
+
+	return expr, nil
+}
+
+func (p *xpathParser) fail(format string, args ...any) error {
+	return &XPathSyntaxError{expression: p.input, position: p.peek().position, message: fmt.Sprintf(format, args...)}
+}
+
+func (p *xpathParser) peek() xpathToken {
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) advance() xpathToken {
+	tok := p.tokens[p.pos]
+	if tok.tokenType != xpathEndToken {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *xpathParser) isSymbol(values ...string) bool {
+	tok := p.peek()
+	if tok.tokenType != xpathSymbolToken {
+		return false
+	}
+
+	for _, value := range values {
+		if tok.value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *xpathParser) isOperator(values ...string) bool {
+	tok := p.peek()
+	if tok.tokenType != xpathOperatorToken && tok.tokenType != xpathSymbolToken {
+		return false
+	}
+
+	for _, value := range values {
+		if tok.value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *xpathParser) expect(symbol string) error {
+	if !p.isSymbol(symbol) {
+		if p.peek().tokenType == xpathEndToken {
+			return p.fail("expected '%v' before the end of the expression", symbol)
+		}
+		return p.fail("expected '%v'", symbol)
+	}
+
+	p.advance()
+	return nil
+}
+
+func (p *xpathParser) parseExpr() (xpathExpr, error) {
+	return p.parseBinary(0)
+}
+
+// Binary operators grouped by precedence, from the loosest binding.
+var xpathPrecedence = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "div", "mod"},
+}
+
+func (p *xpathParser) parseBinary(level int) (xpathExpr, error) {
+	if level == len(xpathPrecedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(xpathPrecedence[level]...) {
+		operator := p.advance().value
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *xpathParser) parseUnary() (xpathExpr, error) {
+	if p.isSymbol("-") {
+		p.advance()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateExpr{operand: operand}, nil
+	}
+
+	left, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isSymbol("|") {
+		p.advance()
+		right, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		left = &unionExpr{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *xpathParser) parsePath() (xpathExpr, error) {
+	tok := p.peek()
+
+	switch tok.tokenType {
+	case xpathNumberToken, xpathLiteralToken, xpathVariableToken, xpathFunctionToken:
+	default:
+		if !p.isSymbol("(") {
+			return p.parseLocationPath()
+		}
+	}
+
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	predicates, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(predicates) > 0 {
+		primary = &filterExpr{primary: primary, predicates: predicates}
+	}
+
+	if !p.isSymbol("/", "//") {
+		return primary, nil
+	}
+
+	path := &pathExpr{filter: primary}
+	if err := p.parseRelativePath(path); err != nil {
+		return nil, err
+	}
+
+	return path, nil
+}
+
+func (p *xpathParser) parsePrimary() (xpathExpr, error) {
+	tok := p.advance()
+
+	switch tok.tokenType {
+	case xpathNumberToken:
+		value, _ := strconv.ParseFloat(tok.value, 64)
+		return &literalExpr{value: value}, nil
+	case xpathLiteralToken:
+		return &literalExpr{value: tok.value}, nil
+	case xpathVariableToken:
+		return &variableExpr{name: tok.value}, nil
+	case xpathFunctionToken:
+		return p.parseFunctionCall(tok)
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return expr, nil
+}
+
+func (p *xpathParser) parseFunctionCall(name xpathToken) (xpathExpr, error) {
+	function, ok := xpathFunctions[name.value]
+	if !ok {
+		p.pos--
+		return nil, p.fail("unknown function '%v'", name.value)
+	}
+
+	p.advance()
+	call := &functionCall{name: name.value, function: function}
+
+	for !p.isSymbol(")") {
+		if len(call.arguments) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		argument, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.arguments = append(call.arguments, argument)
+	}
+	p.advance()
+
+	count := len(call.arguments)
+	if count < function.minArguments || (function.maxArguments != -1 && count > function.maxArguments) {
+		p.pos--
+		return nil, p.fail("wrong number of arguments for '%v'", name.value)
+	}
+
+	return call, nil
+}
+
+func (p *xpathParser) parsePredicates() ([]xpathExpr, error) {
+	predicates := []xpathExpr{}
+
+	for p.isSymbol("[") {
+		p.advance()
+		predicate, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+
+	return predicates, nil
+}
+
+func (p *xpathParser) parseLocationPath() (xpathExpr, error) {
+	path := &pathExpr{}
+
+	if p.isSymbol("/", "//") {
+		path.absolute = true
+
+		// A lone slash selects the root node
+		if p.isSymbol("/") && !p.startsStep(p.tokens[p.pos+1]) {
+			p.advance()
+			return path, nil
+		}
+
+		if err := p.parseRelativePath(path); err != nil {
+			return nil, err
+		}
+		return path, nil
+	}
+
+	step, err := p.parseStep()
+	if err != nil {
+		return nil, err
+	}
+	path.steps = append(path.steps, step)
+
+	if p.isSymbol("/", "//") {
+		if err := p.parseRelativePath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return path, nil
+}
+
+// parseRelativePath reads the steps that follow a slash, expanding each
+// double slash into a descendant-or-self step.
+func (p *xpathParser) parseRelativePath(path *pathExpr) error {
+	for p.isSymbol("/", "//") {
+		if p.advance().value == "//" {
+			path.steps = append(path.steps, &xpathStep{axis: "descendant-or-self", test: xpathNodeTest{nodeType: "node"}})
+		}
+
+		step, err := p.parseStep()
+		if err != nil {
+			return err
+		}
+		path.steps = append(path.steps, step)
+	}
+
+	return nil
+}
+
+func (p *xpathParser) startsStep(tok xpathToken) bool {
+	switch tok.tokenType {
+	case xpathAxisToken, xpathNameTestToken, xpathNodeTypeToken:
+		return true
+	case xpathSymbolToken:
+		return tok.value == "@" || tok.value == "." || tok.value == ".."
+	}
+	return false
+}
+
+func (p *xpathParser) parseStep() (*xpathStep, error) {
+	if p.isSymbol(".") {
+		p.advance()
+		return &xpathStep{axis: "self", test: xpathNodeTest{nodeType: "node"}}, nil
+	}
+
+	if p.isSymbol("..") {
+		p.advance()
+		return &xpathStep{axis: "parent", test: xpathNodeTest{nodeType: "node"}}, nil
+	}
+
+	step := &xpathStep{axis: "child"}
+
+	if p.isSymbol("@") {
+		p.advance()
+		step.axis = "attribute"
+	} else if p.peek().tokenType == xpathAxisToken {
+		step.axis = p.advance().value
+		p.advance()
+	}
+
+	if step.axis == "namespace" {
+		p.pos -= 2
+		return nil, p.fail("the namespace axis is not supported")
+	}
+
+	test, err := p.parseNodeTest()
+	if err != nil {
+		return nil, err
+	}
+	step.test = test
+
+	step.predicates, err = p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+
+	return step, nil
+}
+
+func (p *xpathParser) parseNodeTest() (xpathNodeTest, error) {
+	tok := p.peek()
+
+	switch tok.tokenType {
+	case xpathNameTestToken:
+		p.advance()
+		test := xpathNodeTest{name: tok.value}
+		if index := strings.IndexByte(tok.value, ':'); index != -1 {
+			test.prefix = tok.value[:index]
+			test.name = tok.value[index+1:]
+		}
+		return test, nil
+	case xpathNodeTypeToken:
+		p.advance()
+		test := xpathNodeTest{nodeType: tok.value}
+
+		if err := p.expect("("); err != nil {
+			return test, err
+		}
+
+		if tok.value == "processing-instruction" && p.peek().tokenType == xpathLiteralToken {
+			test.target = p.advance().value
+		}
+
+		return test, p.expect(")")
+	}
+
+	if tok.tokenType == xpathEndToken {
+		return xpathNodeTest{}, p.fail("unexpected end of expression")
+	}
+
+	return xpathNodeTest{}, p.fail("expected a node test")
+}
@@ -0,0 +1,74 @@
+package parseme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_tokenizeXPath(t *testing.T) {
+	testcases := []struct {
+		expression string
+		expected   []xpathTokenType
+	}{
+		{"div", []xpathTokenType{xpathNameTestToken}},
+		{"* * *", []xpathTokenType{xpathNameTestToken, xpathOperatorToken, xpathNameTestToken}},
+		{"div div div", []xpathTokenType{xpathNameTestToken, xpathOperatorToken, xpathNameTestToken}},
+		{"child::text()", []xpathTokenType{xpathAxisToken, xpathSymbolToken, xpathNodeTypeToken, xpathSymbolToken, xpathSymbolToken}},
+		{"count (a)", []xpathTokenType{xpathFunctionToken, xpathSymbolToken, xpathNameTestToken, xpathSymbolToken}},
+		{"svg:* | x:y", []xpathTokenType{xpathNameTestToken, xpathSymbolToken, xpathNameTestToken}},
+		{"$a:b-c .5 'x'", []xpathTokenType{xpathVariableToken, xpathNumberToken, xpathLiteralToken}},
+		{"@and and 1", []xpathTokenType{xpathSymbolToken, xpathNameTestToken, xpathOperatorToken, xpathNumberToken}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expression, func(t *testing.T) {
+			tokens, err := tokenizeXPath(tc.expression)
+			assert.Nil(t, err)
+
+			types := []xpathTokenType{}
+			for _, tok := range tokens[:len(tokens)-1] {
+				types = append(types, tok.tokenType)
+			}
+			assert.Equal(t, types, tc.expected)
+		})
+	}
+}
+
+func Test_parseXPath(t *testing.T) {
+	testcases := []struct {
+		expression string
+		position   int
+	}{
+		{"", 0},
+		{"//", 2},
+		{"a[", 2},
+		{"a[1", 3},
+		{"'abc", 0},
+		{"foo::a", 0},
+		{"namespace::a", 0},
+		{"unknown()", 0},
+		{"count()", 6},
+		{"a b", 2},
+		{"a !", 2},
+		{"1 +", 3},
+		{"(1", 2},
+		{"a/", 2},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expression, func(t *testing.T) {
+			_, err := parseXPath(tc.expression)
+			assert.IsType(t, err, &XPathSyntaxError{})
+			assert.Equal(t, err.(*XPathSyntaxError).position, tc.position)
+		})
+	}
+
+	// Double slashes expand to a descendant-or-self step
+	expr, err := parseXPath("/a//b")
+	assert.Nil(t, err)
+	path := expr.(*pathExpr)
+	assert.True(t, path.absolute)
+	assert.Len(t, path.steps, 3)
+	assert.Equal(t, path.steps[1].axis, "descendant-or-self")
+}
@@ -0,0 +1,214 @@
+package parseme
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xpathDocument = `<html>
+<body lang="en-GB">
+	<div id="menu" class="nav"><a href="/home">Home</a><a href="/about" class="active">About  us</a><!-- note --></div>
+	<table id="prices">
+		<tr><td>Apple</td><td>1.5</td></tr>
+		<tr><td>Pear</td><td>2</td></tr>
+		<tr><td>Plum</td><td>0.25</td></tr>
+	</table>
+	<p id="x" title="first">One <b>two</b> three</p>
+	<p id="y">Four</p>
+</body>
+</html>`
+
+func Test_XPathNodes(t *testing.T) {
+	document, _, err := parseString(HtmlMode, xpathDocument)
+	assert.Nil(t, err)
+
+	testcases := []struct {
+		expression string
+		expected   string
+	}{
+		{"//a", "Home|About  us"},
+		{"//A", "Home|About  us"},
+		{"/html/body/p", "One two three|Four"},
+		{"//div/a[2]", "About  us"},
+		{"//a[last()]", "About  us"},
+		{"//a[position() < 2]", "Home"},
+		{"//a[@class='active']", "About  us"},
+		{"//a[not(@class)]", "Home"},
+		{"//p[b]", "One two three"},
+		{"//td[1][../td[2] > 1]", "Apple|Pear"},
+		{"//tr[td = 'Pear']/td[2]", "2"},
+		{"//p[contains(., 'two')]", "One two three"},
+		{"//a[normalize-space() = 'About us']", "About  us"},
+		{"//a[starts-with(@href, '/h')]", "Home"},
+		{"//b/ancestor::*[1]", "One two three"},
+		{"//b/ancestor::*[last()]/body/p[2]", "Four"},
+		{"//p[1]/following-sibling::p", "Four"},
+		{"//p[2]/preceding-sibling::*[1]", "One two three"},
+		{"//p[2]/preceding::td[1]", "0.25"},
+		{"//a[1]/following::a", "About  us"},
+		{"//tr[2]/parent::*/tr[1]/td[1]", "Apple"},
+		{"//b/..", "One two three"},
+		{"//td[. = 'Plum'] | //a[1]", "Home|Plum"},
+		{"id('y x')", "One two three|Four"},
+		{"//p[lang('en')][1]", "One two three"},
+		{"//div/comment()", " note "},
+		{"//p[1]/text()", "One | three"},
+		{"(//td)[4]", "2"},
+		{"//*[@id='prices']//td[. > 1]", "1.5|2"},
+		{"//p[@title]/@title", "first"},
+		{"//a/@*", "/home|/about|active"},
+		{"//body/self::body/@lang", "en-GB"},
+		{"//*[local-name() = 'b']", "two"},
+		{"//b[count(ancestor::*) = 3]", "two"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expression, func(t *testing.T) {
+			result, err := document.XPath(tc.expression)
+			assert.Nil(t, err)
+			assert.Equal(t, result.Type(), NodeSetResult)
+
+			values := ""
+			for i, value := range result.Strings() {
+				if i > 0 {
+					values += "|"
+				}
+				values += value
+			}
+			assert.Equal(t, values, tc.expected)
+		})
+	}
+}
+
+func Test_XPathValues(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, xpathDocument)
+
+	testcases := []struct {
+		expression string
+		expected   any
+	}{
+		{"count(//td)", 6.0},
+		{"sum(//tr/td[2])", 3.75},
+		{"1 + 2 * 3", 7.0},
+		{"7 mod 3", 1.0},
+		{"-(3 div 2)", -1.5},
+		{"round(2.5)", 3.0},
+		{"round(-2.5)", -2.0},
+		{"floor(-1.5)", -2.0},
+		{"ceiling(1.2)", 2.0},
+		{"string-length('héllo')", 5.0},
+		{"number('  12.5 ')", 12.5},
+		{"string(1 div 0)", "Infinity"},
+		{"string(0 div 0)", "NaN"},
+		{"string(100000000000)", "100000000000"},
+		{"string(-0.5)", "-0.5"},
+		{"string(//a/@href)", "/home"},
+		{"concat('a', 1, true())", "a1true"},
+		{"substring('12345', 2, 3)", "234"},
+		{"substring('12345', 1.5, 2.6)", "234"},
+		{"substring('12345', 0 div 0, 3)", ""},
+		{"substring('12345', -42, 1 div 0)", "12345"},
+		{"substring-before('1999/04/01', '/')", "1999"},
+		{"substring-after('1999/04/01', '/')", "04/01"},
+		{"translate('--aaa--', 'abc-', 'ABC')", "AAA"},
+		{"normalize-space('  a \n b  ')", "a b"},
+		{"name(//body/*[1])", "div"},
+		{"local-name(//comment())", ""},
+		{"//td = 'Plum'", true},
+		{"//td != 'Plum'", true},
+		{"//td > 1.9", true},
+		{"//td > 2", false},
+		{"//nothing = //nothing", false},
+		{"//a = true()", true},
+		{"boolean(//table)", true},
+		{"not(//table)", false},
+		{"1 = 1 and 2 > 3 or 'a' = 'a'", true},
+		{"'10' < '9'", false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expression, func(t *testing.T) {
+			result, err := document.XPath(tc.expression)
+			assert.Nil(t, err)
+
+			switch expected := tc.expected.(type) {
+			case float64:
+				assert.Equal(t, result.Type(), NumberResult)
+				assert.Equal(t, result.Number(), expected)
+			case string:
+				assert.Equal(t, result.Type(), StringResult)
+				assert.Equal(t, result.String(), expected)
+			case bool:
+				assert.Equal(t, result.Type(), BooleanResult)
+				assert.Equal(t, result.Boolean(), expected)
+			}
+		})
+	}
+
+	result, _ := document.XPath("number('abc')")
+	assert.True(t, math.IsNaN(result.Number()))
+}
+
+func Test_XPathSelect(t *testing.T) {
+	document, _, _ := parseString(HtmlMode, xpathDocument)
+
+	link, err := document.SelectNode("//a[@class]")
+	assert.Nil(t, err)
+	assert.Equal(t, link.Property("href").Value(), "/about")
+
+	// Relative paths start from the context node
+	menu, _ := document.SelectNode("//div")
+	links, _ := menu.SelectNodes("a")
+	assert.Len(t, links, 2)
+
+	missing, err := document.SelectNode("//video")
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+
+	_, err = document.SelectNodes("count(//a)")
+	assert.IsType(t, err, &XPathEvaluationError{})
+
+	_, err = document.SelectNodes("//a[")
+	assert.IsType(t, err, &XPathSyntaxError{})
+
+	result, _ := document.XPath("//a/@href")
+	assert.Len(t, result.Properties(), 2)
+	assert.Len(t, result.Nodes(), 0)
+}
+
+func Test_XPathOptions(t *testing.T) {
+	document, _, err := parseString(XmlMode, `<feed xmlns="urn:feed" xmlns:m="urn:media">
+	<entry m:id="1"><title>First</title><m:thumb url="a.png"/></entry>
+	<entry m:id="2"><title>Second</title></entry>
+</feed>`)
+	assert.Nil(t, err)
+
+	xpath := MustCompileXPath("//f:entry[@m:id = $id]/f:title")
+	options := NewXPathOptions()
+	options.Namespaces["f"] = "urn:feed"
+	options.Namespaces["m"] = "urn:media"
+	options.Variables["id"] = 2
+
+	result, err := xpath.Evaluate(document, options)
+	assert.Nil(t, err)
+	assert.Equal(t, result.String(), "Second")
+
+	// Unprefixed names only select elements without a namespace
+	result, _ = document.XPath("//entry")
+	assert.Len(t, result.Nodes(), 0)
+
+	// Every prefix must be bound
+	_, err = xpath.Evaluate(document, &XPathOptions{Namespaces: map[string]string{"f": "urn:feed"}, Variables: map[string]any{"id": "1"}})
+	assert.IsType(t, err, &XPathEvaluationError{})
+
+	result, _ = MustCompileXPath("//m:*/@url").Evaluate(document, options)
+	assert.Equal(t, result.Strings(), []string{"a.png"})
+
+	result, _ = MustCompileXPath("namespace-uri(//m:thumb)").Evaluate(document, options)
+	assert.Equal(t, result.String(), "urn:media")
+
+	_, err = MustCompileXPath("$missing").Evaluate(document, nil)
+	assert.IsType(t, err, &XPathEvaluationError{})
+}